@@ -119,6 +119,14 @@ func DefaultConfig() SsmagentConfig {
 		SessionLogsDestination:                SessionLogsDestinationNone,
 		PluginLocalOutputCleanup:              DefaultPluginOutputRetention,
 		OrchestrationDirectoryCleanup:         DefaultOrchestrationDirCleanup,
+		StepRetryInitialIntervalMillis:        DefaultStepRetryInitialIntervalMillis,
+		StepRetryMaxIntervalMillis:            DefaultStepRetryMaxIntervalMillis,
+		StepRetryJitterPercent:                DefaultStepRetryJitterPercent,
+		ParallelStepsLimit:                    DefaultParallelStepsLimit,
+		ParameterCacheTTLSeconds:              DefaultParameterCacheTTLSeconds,
+		OutputMaskingPatterns:                 DefaultOutputMaskingPatterns,
+		OutputSpoolMaxSizeMB:                  DefaultOutputSpoolMaxSizeMB,
+		CommandTerminationGracePeriodSeconds:  DefaultCommandTerminationGracePeriodSeconds,
 	}
 	var agent = AgentInfo{
 		Name:                                    "amazon-ssm-agent",
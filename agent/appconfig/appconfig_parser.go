@@ -133,6 +133,41 @@ func parser(config *SsmagentConfig) {
 	config.Ssm.OrchestrationDirectoryCleanup = getStringEnum(config.Ssm.OrchestrationDirectoryCleanup,
 		OrchestartionDirCleanupOtions,
 		DefaultOrchestrationDirCleanup)
+	config.Ssm.StepRetryInitialIntervalMillis = getNumericValue(
+		config.Ssm.StepRetryInitialIntervalMillis,
+		DefaultStepRetryInitialIntervalMillisMin,
+		DefaultStepRetryInitialIntervalMillisMax,
+		DefaultStepRetryInitialIntervalMillis)
+	config.Ssm.StepRetryMaxIntervalMillis = getNumericValue(
+		config.Ssm.StepRetryMaxIntervalMillis,
+		DefaultStepRetryMaxIntervalMillisMin,
+		DefaultStepRetryMaxIntervalMillisMax,
+		DefaultStepRetryMaxIntervalMillis)
+	config.Ssm.StepRetryJitterPercent = getNumericValue(
+		config.Ssm.StepRetryJitterPercent,
+		DefaultStepRetryJitterPercentMin,
+		DefaultStepRetryJitterPercentMax,
+		DefaultStepRetryJitterPercent)
+	config.Ssm.ParallelStepsLimit = getNumericValue(
+		config.Ssm.ParallelStepsLimit,
+		DefaultParallelStepsLimitMin,
+		DefaultParallelStepsLimitMax,
+		DefaultParallelStepsLimit)
+	config.Ssm.ParameterCacheTTLSeconds = getNumericValue(
+		config.Ssm.ParameterCacheTTLSeconds,
+		DefaultParameterCacheTTLSecondsMin,
+		DefaultParameterCacheTTLSecondsMax,
+		DefaultParameterCacheTTLSeconds)
+	config.Ssm.OutputSpoolMaxSizeMB = getNumericValue(
+		config.Ssm.OutputSpoolMaxSizeMB,
+		DefaultOutputSpoolMaxSizeMBMin,
+		DefaultOutputSpoolMaxSizeMBMax,
+		DefaultOutputSpoolMaxSizeMB)
+	config.Ssm.CommandTerminationGracePeriodSeconds = getNumericValue(
+		config.Ssm.CommandTerminationGracePeriodSeconds,
+		DefaultCommandTerminationGracePeriodSecondsMin,
+		DefaultCommandTerminationGracePeriodSecondsMax,
+		DefaultCommandTerminationGracePeriodSeconds)
 
 	config.Identity.Ec2SystemInfoDetectionResponse = getStringEnum(config.Identity.Ec2SystemInfoDetectionResponse, booleanStringOptions, "")
 	IdentityConsumptionOrderOptions := map[string]bool{
@@ -161,3 +161,15 @@ func TestIdentityCredentialsValue_InvalidCredentialsProviderToDefault(t *testing
 	parser(&agentConfig)
 	assert.Equal(t, agentConfig.Identity.CustomIdentities[0].CredentialsProvider, DefaultCustomIdentityCredentialsProvider)
 }
+
+func TestStepRetryConfig_OutOfRangeValues(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Ssm.StepRetryInitialIntervalMillis = 1
+	agentConfig.Ssm.StepRetryMaxIntervalMillis = 120000
+	agentConfig.Ssm.StepRetryJitterPercent = 150
+	parser(&agentConfig)
+
+	assert.Equal(t, DefaultStepRetryInitialIntervalMillis, agentConfig.Ssm.StepRetryInitialIntervalMillis)
+	assert.Equal(t, 120000, agentConfig.Ssm.StepRetryMaxIntervalMillis)
+	assert.Equal(t, DefaultStepRetryJitterPercent, agentConfig.Ssm.StepRetryJitterPercent)
+}
@@ -91,6 +91,37 @@ const (
 	// RunCommandLogsRetentionDurationHours, and SessionLogsRetentionDurationHours
 	DefaultPluginOutputRetention = "default"
 
+	// Exponential backoff between retries of a failed document step that declares maxAttempts
+	DefaultStepRetryInitialIntervalMillis    = 1000
+	DefaultStepRetryInitialIntervalMillisMin = 100
+	DefaultStepRetryInitialIntervalMillisMax = 300000
+	DefaultStepRetryMaxIntervalMillis        = 60000
+	DefaultStepRetryMaxIntervalMillisMin     = 100
+	DefaultStepRetryMaxIntervalMillisMax     = 3600000
+	DefaultStepRetryJitterPercent            = 20
+	DefaultStepRetryJitterPercentMin         = 0
+	DefaultStepRetryJitterPercentMax         = 100
+
+	// Number of steps of a parallelGroup that run at the same time
+	DefaultParallelStepsLimit    = 4
+	DefaultParallelStepsLimitMin = 1
+	DefaultParallelStepsLimitMax = 32
+
+	// Seconds the values of parameter store parameters are cached, the cache is disabled by default
+	DefaultParameterCacheTTLSeconds    = 0
+	DefaultParameterCacheTTLSecondsMin = 0
+	DefaultParameterCacheTTLSecondsMax = 3600
+
+	// Size in MB of the local spool of compressed command output, the spool is disabled by default
+	DefaultOutputSpoolMaxSizeMB    = 0
+	DefaultOutputSpoolMaxSizeMBMin = 0
+	DefaultOutputSpoolMaxSizeMBMax = 102400
+
+	// Seconds the processes of a cancelled or timed out command get to exit after SIGTERM before they are killed
+	DefaultCommandTerminationGracePeriodSeconds    = 5
+	DefaultCommandTerminationGracePeriodSecondsMin = 0
+	DefaultCommandTerminationGracePeriodSecondsMax = 300
+
 	//aws-ssm-agent state and orchestration logs duration for Run Command and Association
 	DefaultAssociationLogsRetentionDurationHours           = 24  // 1 day default retention
 	DefaultRunCommandLogsRetentionDurationHours            = 336 // 14 days default retention
@@ -196,6 +227,9 @@ const (
 	// PluginRunDocument is the name of the run document plugin
 	PluginRunDocument = "aws:runDocument"
 
+	// PluginNameCustomPrefix is the prefix of the names of the external plugins found in ExternalPluginPath
+	PluginNameCustomPrefix = "custom:"
+
 	// PluginNameAwsSoftwareInventory is the name for inventory plugin
 	PluginNameAwsSoftwareInventory = "aws:softwareInventory"
 
@@ -224,6 +258,9 @@ const (
 
 	SeelogConfigFileName = "seelog.xml"
 
+	// TrustedKeysFolderName is the folder under the program folder holding the public keys trusted to sign scripts and content
+	TrustedKeysFolderName = "trusted-keys"
+
 	// Output truncation limits
 	MaxStdoutLength = 24000
 	MaxStderrLength = 8000
@@ -254,6 +291,15 @@ const (
 // Default deny list IP addresses for remote host port forwarding: IMDS (ipv4, ipv6); VPC (ipv4, ipv6); Amazon Time Sync (ipv4, ipv6); Amazon Windows license activation (2x ipv4, ipv6)
 var DefaultDeniedPortForwardingRemoteIPs = []string{"169.254.169.254", "fd00:ec2::254", "169.254.169.253", "fd00:ec2::253", "169.254.169.123", "fd00:ec2::123", "169.254.169.250", "169.254.169.251", "fd00:ec2::240"}
 
+// Default patterns of secrets masked in the output of commands: AWS access key ids and secret access keys,
+// bearer tokens and password assignments. Only the first group is masked for patterns with groups.
+var DefaultOutputMaskingPatterns = []string{
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`,
+	`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	`(?i)\b(?:password|passwd)\s*[=:]\s*("[^"]*"|'[^']*'|\S+)`,
+}
+
 // Document versions that are supported by this Agent version.
 // Note that 1.1 and 2.1 are deprecated schemas and hence are not added here.
 // Version 2.0.1, 2.0.2, and 2.0.3 are added to support install documents for configurePackage
@@ -69,6 +69,9 @@ var (
 	// DefaultPluginPath represents the directory for storing plugins in SSM
 	DefaultPluginPath = DefaultProgramFolder + "plugins"
 
+	// ExternalPluginPath represents the directory of the executables registered as custom plugins
+	ExternalPluginPath = DefaultProgramFolder + "plugins.d"
+
 	// ManifestCacheDirectory represents the directory for storing all downloaded manifest files
 	ManifestCacheDirectory = DefaultProgramFolder + "manifests"
 
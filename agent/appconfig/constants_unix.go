@@ -76,6 +76,9 @@ var (
 	// DefaultPluginPath represents the directory for storing plugins in SSM
 	DefaultPluginPath = AgentData + "plugins"
 
+	// ExternalPluginPath represents the directory of the executables registered as custom plugins
+	ExternalPluginPath = AgentData + "plugins.d"
+
 	// ManifestCacheDirectory represents the directory for storing all downloaded manifest files
 	ManifestCacheDirectory = AgentData + "manifests"
 
@@ -126,6 +126,9 @@ var LocalCommandRootInvalid string
 // DefaultPluginPath represents the directory for storing plugins in SSM
 var DefaultPluginPath string
 
+// ExternalPluginPath represents the directory of the executables registered as custom plugins
+var ExternalPluginPath string
+
 // ManifestCacheDirectory represents the directory for storing all downloaded manifest files
 var ManifestCacheDirectory string
 
@@ -195,6 +198,7 @@ func init() {
 
 	DefaultProgramFolder = filepath.Join(EnvProgramFiles, SSMFolder)
 	DefaultPluginPath = filepath.Join(EnvProgramFiles, SSMPluginFolder)
+	ExternalPluginPath = filepath.Join(DefaultProgramFolder, "plugins.d")
 	DefaultSSMAgentBinaryPath = filepath.Join(DefaultProgramFolder, "amazon-ssm-agent.exe")
 	DefaultSSMAgentWorker = filepath.Join(DefaultProgramFolder, "ssm-agent-worker.exe")
 	DefaultDocumentWorker = filepath.Join(DefaultProgramFolder, "ssm-document-worker.exe")
@@ -50,6 +50,59 @@ type SsmCfg struct {
 	PluginLocalOutputCleanup string
 	// Configure only when it is safe to delete orchestration folder after document execution. This config overrides PluginLocalOutputCleanup when set.
 	OrchestrationDirectoryCleanup string
+	// Milliseconds to wait before retrying a failed document step that declares maxAttempts
+	StepRetryInitialIntervalMillis int
+	// Upper bound in milliseconds for the wait between retries of a failed document step
+	StepRetryMaxIntervalMillis int
+	// Percentage of random jitter applied to the wait between retries of a failed document step
+	StepRetryJitterPercent int
+	// Maximum number of steps of a parallelGroup that run at the same time
+	ParallelStepsLimit int
+	// Local parameter references that documents are allowed to use, e.g. env:HOME, file:/etc/app/* or imds:placement/*.
+	// Entries ending with * allow any reference with the same prefix. When empty, local parameters are disabled
+	// and the references are left in the documents as they are.
+	LocalParameterSources []string
+	// Local parameter references whose values are secrets masked in the output of documents, in the format of
+	// LocalParameterSources, e.g. env:APP_TOKEN or file:/etc/app/secrets/*. The values of other references are not masked.
+	LocalParameterSecretSources []string
+	// Address of the HashiCorp Vault server resolving {{vault:path#key}} references, e.g. https://vault.example.com:8200
+	VaultAddress string
+	// File holding the token used to read secrets from HashiCorp Vault, it must only be accessible by root, or LocalSystem and the Administrators on windows
+	VaultTokenFile string
+	// Encrypted file holding the secrets resolved for {{localsecret:name}} references, it must only be accessible by root, or LocalSystem and the Administrators on windows
+	LocalSecretsFile string
+	// File holding the AES-256 key of LocalSecretsFile, it must only be accessible by root, or LocalSystem and the Administrators on windows
+	LocalSecretsKeyFile string
+	// Seconds the values of resolved parameter store parameters are cached in memory, 0 disables the cache
+	ParameterCacheTTLSeconds int
+	// Whether the decrypted values of SecureString parameters are cached as well, they are only ever kept in memory
+	// and are masked in the output of documents like the values requested from parameter store
+	ParameterCacheSecureParameters bool
+	// Default share of one CPU in percent that aws:runShellScript commands can use, 0 means unlimited.
+	// Resource limits are enforced with cgroup v2 on linux, in the cgroup delegated to the agent service, and can be overridden by the resourceLimits input of a step.
+	RunShellScriptCPUQuotaPercent int
+	// Default memory in MB that aws:runShellScript commands can use before they are killed, 0 means unlimited
+	RunShellScriptMemoryMaxMB int
+	// Default number of processes and threads aws:runShellScript commands can run, 0 means unlimited
+	RunShellScriptPidsMax int
+	// Default block IO weight of aws:runShellScript commands from 1 to 10000, 0 keeps the weight of the agent
+	RunShellScriptIOWeight int
+	// Users that the runAsUser input of aws:runShellScript steps can run commands as, runAsUser is disabled when empty
+	RunShellScriptRunAsUsers []string
+	// Regular expressions of secrets masked in the output of commands, in addition to the values of secure parameters
+	// and secret backends. Only the first group is masked for patterns with groups.
+	OutputMaskingPatterns []string
+	// Size in MB of the local spool keeping the gzip compressed stdout and stderr of commands after the orchestration
+	// directory is cleaned up, the output of the oldest executions is deleted first. 0 disables the spool.
+	OutputSpoolMaxSizeMB int
+	// Seconds the process group of a cancelled or timed out command gets to exit after SIGTERM before it is sent
+	// SIGKILL. 0 kills the process group right away.
+	CommandTerminationGracePeriodSeconds int
+	// Whether aws:runShellScript steps must carry a signature of their commands by a key of the trust store,
+	// plugins that run other code of the document, external plugins and CommandSucceeds preconditions do not run then
+	RequireSignedScripts bool
+	// Whether aws:downloadContent steps must carry a signature of the downloaded file by a key of the trust store
+	RequireSignedContent bool
 }
 
 // AgentInfo represents metadata for amazon-ssm-agent
@@ -102,6 +102,7 @@ func InitializeDocumentState(context context.T,
 		Description:   payload.DocumentContent.Description,
 		RuntimeConfig: payload.DocumentContent.RuntimeConfig,
 		MainSteps:     payload.DocumentContent.MainSteps,
+		FinallySteps:  payload.DocumentContent.FinallySteps,
 		Parameters:    payload.DocumentContent.Parameters,
 	}
 	return docparser.InitializeDocState(context, contracts.Association, docContent, documentInfo, parserInfo, payload.Parameters)
@@ -47,7 +47,11 @@ func RunCommand(args []string, out io.Writer) (exitCode int) {
 			fmt.Fprint(out, cmd.Help())
 		} else {
 			cmdErr, result := cmd.Execute(subcommands, parameters)
-			if cmdErr != nil {
+			if cmdErr != nil && result != "" {
+				// the command ran and reported why it failed, usage would only hide the report
+				fmt.Fprintln(out, result)
+				return cliutil.CLI_COMMAND_FAIL_EXITCODE
+			} else if cmdErr != nil {
 				displayUsage(out)
 				fmt.Fprintln(out, "\nerror: "+cmdErr.Error())
 				// Exit 255 if command failed
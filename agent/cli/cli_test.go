@@ -16,6 +16,7 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/cli/clicommand"
@@ -68,3 +69,33 @@ func TestCliCmdExecSuccess(t *testing.T) {
 	assert.Equal(t, cliutil.CLI_SUCCESS_EXITCODE, exitCode, "command execution success return exit code 0")
 	cliCmdMock.AssertExpectations(t)
 }
+
+func TestCliCmdExecErrorWithReport(t *testing.T) {
+	var buffer bytes.Buffer
+	cliCmdMock := &CliCommandMock.CliCommand{}
+	cliCmdMock.On("Name").Return("cli-command-report-mock").Once()
+	cliCmdMock.On("Execute", mock.AnythingOfType("[]string"), mock.AnythingOfType("map[string][]string")).Return(errors.New("failed"), "report").Once()
+	cliutil.Register(cliCmdMock)
+
+	args := []string{"ssm-cli", "cli-command-report-mock"}
+	exitCode := RunCommand(args, &buffer)
+	assert.Equal(t, cliutil.CLI_COMMAND_FAIL_EXITCODE, exitCode, "command execution error return exit code 255")
+	assert.Equal(t, "report\n", buffer.String(), "the report of a failed command is printed without usage")
+	cliCmdMock.AssertExpectations(t)
+}
+
+func TestCliValidateDocument(t *testing.T) {
+	cliutil.Register(&clicommand.ValidateDocumentCommand{})
+
+	var buffer bytes.Buffer
+	validDocument := `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"run","inputs":{"runCommand":["echo hello"]}}]}`
+	exitCode := RunCommand([]string{"ssm-cli", "validate-document", "--content", validDocument}, &buffer)
+	assert.Equal(t, cliutil.CLI_SUCCESS_EXITCODE, exitCode)
+	assert.Equal(t, "Document is valid\n", buffer.String())
+
+	buffer.Reset()
+	invalidDocument := "schemaVersion: '2.2'\nmainSteps:\n- action: aws:unknown\n  name: run\n"
+	exitCode = RunCommand([]string{"ssm-cli", "validate-document", "--content", invalidDocument, "--output", "json"}, &buffer)
+	assert.Equal(t, cliutil.CLI_COMMAND_FAIL_EXITCODE, exitCode)
+	assert.JSONEq(t, `{"valid":false,"errors":[{"path":"$.mainSteps[0].action","step":"run","message":"plugin aws:unknown is not supported by this version of ssm agent"}]}`, buffer.String())
+}
@@ -0,0 +1,480 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+)
+
+const (
+	getExecutionOutput          = "get-execution-output"
+	tailExecutionOutput         = "tail-execution-output"
+	executionOutputExecutionID  = "execution-id"
+	executionOutputStep         = "step"
+	executionOutputStream       = "stream"
+	executionOutputLines        = "lines"
+	executionOutputFollow       = "follow"
+	executionOutputStdout       = "stdout"
+	executionOutputStderr       = "stderr"
+	executionOutputDefaultLines = 10
+)
+
+// followPollInterval is how often a followed spool file is read again for new output
+var followPollInterval = time.Second
+
+const getExecutionOutputHelp = `NAME:
+    {{.CommandName}}
+
+DESCRIPTION
+    Prints the full stdout or stderr of an execution from the local output spool of the amazon-ssm-agent.
+    The agent keeps the gzip compressed output of commands in the spool when OutputSpoolMaxSizeMB is set in
+    the Ssm section of the agent configuration, the output of the oldest executions is deleted first when
+    the spool grows larger than the configured size.
+
+SYNOPSIS
+    {{.CommandName}}
+    {{.ExecutionIDFlag}} <value>
+    [{{.StepFlag}}]
+    [{{.StreamFlag}}]
+
+PARAMETERS
+    {{.ExecutionIDFlag}} (string) The id of the command or association execution, as printed by list-executions.
+
+    {{.StepFlag}} (string) Only print the output of the given step.
+
+    {{.StreamFlag}} (string) The output stream to print, {{.Stdout}} (default) or {{.Stderr}}.
+
+EXAMPLES
+    This example prints the stdout of the install step of a command.
+
+    Command:
+
+      {{.SsmCliName}} {{.CommandName}} {{.ExecutionIDFlag}} 01234567-890a-bcde-f012-34567890abcd {{.StepFlag}} install
+
+OUTPUT
+    The output of the step, preceded by the path of the spool file when more than one step matches
+`
+
+const tailExecutionOutputHelp = `NAME:
+    {{.CommandName}}
+
+DESCRIPTION
+    Prints the last lines of the stdout or stderr of an execution from the local output spool of the
+    amazon-ssm-agent, and optionally keeps printing the output while the step runs. The agent keeps the
+    gzip compressed output of commands in the spool when OutputSpoolMaxSizeMB is set in the Ssm section
+    of the agent configuration.
+
+SYNOPSIS
+    {{.CommandName}}
+    {{.ExecutionIDFlag}} <value>
+    [{{.StepFlag}}]
+    [{{.StreamFlag}}]
+    [{{.LinesFlag}}]
+    [{{.FollowFlag}}]
+
+PARAMETERS
+    {{.ExecutionIDFlag}} (string) The id of the command or association execution, as printed by list-executions.
+
+    {{.StepFlag}} (string) Only print the output of the given step, required with {{.FollowFlag}} when the execution has several steps.
+
+    {{.StreamFlag}} (string) The output stream to print, {{.Stdout}} (default) or {{.Stderr}}.
+
+    {{.LinesFlag}} (integer) The number of lines to print, 10 by default.
+
+    {{.FollowFlag}} (flag) Keep printing the output as it is written until the step completes.
+
+EXAMPLES
+    This example follows the stderr of the install step of a running command.
+
+    Command:
+
+      {{.SsmCliName}} {{.CommandName}} {{.ExecutionIDFlag}} 01234567-890a-bcde-f012-34567890abcd {{.StepFlag}} install {{.StreamFlag}} {{.Stderr}} {{.FollowFlag}}
+
+OUTPUT
+    The last lines of the output of the step, followed by the output written after the command started when following
+`
+
+type executionOutputHelpParams struct {
+	SsmCliName      string
+	CommandName     string
+	ExecutionIDFlag string
+	StepFlag        string
+	StreamFlag      string
+	LinesFlag       string
+	FollowFlag      string
+	Stdout          string
+	Stderr          string
+}
+
+// executionOutputRequest holds the output of an execution requested from the output spool
+type executionOutputRequest struct {
+	executionID string
+	step        string
+	stream      string
+	lines       int
+	follow      bool
+}
+
+func init() {
+	cliutil.Register(&GetExecutionOutputCommand{})
+	cliutil.Register(&TailExecutionOutputCommand{out: os.Stdout})
+}
+
+type GetExecutionOutputCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the get-execution-output cli command
+func (c *GetExecutionOutputCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, request := validateExecutionOutputInput(getExecutionOutput, subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	spoolDir, err := outputSpoolDir()
+	if err != nil {
+		return err, ""
+	}
+	return getSpooledOutput(spoolDir, request)
+}
+
+// Help prints help for the get-execution-output cli command
+func (c *GetExecutionOutputCommand) Help() string {
+	if len(c.helpText) == 0 {
+		c.helpText = executionOutputHelpText(getExecutionOutput, getExecutionOutputHelp)
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (GetExecutionOutputCommand) Name() string {
+	return getExecutionOutput
+}
+
+type TailExecutionOutputCommand struct {
+	helpText string
+	out      io.Writer
+}
+
+// Execute validates and executes the tail-execution-output cli command
+func (c *TailExecutionOutputCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, request := validateExecutionOutputInput(tailExecutionOutput, subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	spoolDir, err := outputSpoolDir()
+	if err != nil {
+		return err, ""
+	}
+	if request.follow {
+		return followSpooledOutput(c.out, spoolDir, request), ""
+	}
+	return tailSpooledOutput(spoolDir, request)
+}
+
+// Help prints help for the tail-execution-output cli command
+func (c *TailExecutionOutputCommand) Help() string {
+	if len(c.helpText) == 0 {
+		c.helpText = executionOutputHelpText(tailExecutionOutput, tailExecutionOutputHelp)
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (TailExecutionOutputCommand) Name() string {
+	return tailExecutionOutput
+}
+
+// executionOutputHelpText renders the help of the execution output commands
+func executionOutputHelpText(commandName string, helpTemplate string) string {
+	t, _ := template.New(commandName).Parse(helpTemplate)
+	params := executionOutputHelpParams{
+		cliutil.SsmCliName,
+		commandName,
+		cliutil.FormatFlag(executionOutputExecutionID),
+		cliutil.FormatFlag(executionOutputStep),
+		cliutil.FormatFlag(executionOutputStream),
+		cliutil.FormatFlag(executionOutputLines),
+		cliutil.FormatFlag(executionOutputFollow),
+		executionOutputStdout,
+		executionOutputStderr,
+	}
+	buf := new(bytes.Buffer)
+	t.Execute(buf, params)
+	return buf.String()
+}
+
+// outputSpoolDir returns the output spool of the agent
+func outputSpoolDir() (string, error) {
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	shortInstanceID, err := agentIdentity.ShortInstanceID()
+	if err != nil {
+		return "", err
+	}
+	return docmanager.OutputSpoolDir(shortInstanceID), nil
+}
+
+// validateExecutionOutputInput checks the subcommands and parameters for required values, format, and unsupported values
+func validateExecutionOutputInput(commandName string, subcommands []string, parameters map[string][]string) (validation []string, request executionOutputRequest) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", commandName, subcommands), "")
+		return validation, request // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[executionOutputExecutionID]; !exists || len(values) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(executionOutputExecutionID)))
+	} else if request.executionID = values[0]; !validExecutionID(request.executionID) {
+		validation = append(validation, fmt.Sprintf("%v value %v is not a valid execution id", cliutil.FormatFlag(executionOutputExecutionID), request.executionID))
+	}
+
+	if values, exists := parameters[executionOutputStep]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(executionOutputStep)))
+		} else {
+			request.step = values[0]
+		}
+	}
+
+	request.stream = executionOutputStdout
+	if values, exists := parameters[executionOutputStream]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(executionOutputStream)))
+		} else if request.stream = values[0]; request.stream != executionOutputStdout && request.stream != executionOutputStderr {
+			validation = append(validation, fmt.Sprintf("%v value must be %v or %v",
+				cliutil.FormatFlag(executionOutputStream), executionOutputStdout, executionOutputStderr))
+		}
+	}
+
+	supported := []string{executionOutputExecutionID, executionOutputStep, executionOutputStream}
+	if commandName == tailExecutionOutput {
+		supported = append(supported, executionOutputLines, executionOutputFollow)
+
+		request.lines = executionOutputDefaultLines
+		if values, exists := parameters[executionOutputLines]; exists {
+			var err error
+			if len(values) != 1 {
+				validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(executionOutputLines)))
+			} else if request.lines, err = strconv.Atoi(values[0]); err != nil || request.lines < 0 {
+				validation = append(validation, fmt.Sprintf("%v value must be a non-negative integer", cliutil.FormatFlag(executionOutputLines)))
+			}
+		}
+
+		if values, exists := parameters[executionOutputFollow]; exists {
+			if len(values) != 0 {
+				validation = append(validation, fmt.Sprintf("parameter %v does not take a value", cliutil.FormatFlag(executionOutputFollow)))
+			}
+			request.follow = true
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if !containsString(supported, key) {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, request
+}
+
+// validExecutionID returns true if the execution id names a command or association execution, and not another path
+func validExecutionID(executionID string) bool {
+	return executionID != "" && !strings.ContainsAny(executionID, `/\`) && !strings.Contains(executionID, "..")
+}
+
+// containsString returns true if the list contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// findSpooledOutput returns the spool files holding the requested stream of the execution
+func findSpooledOutput(spoolDir string, request executionOutputRequest) ([]string, error) {
+	// the output of an association execution is spooled under the association id and the run id
+	executionDir := filepath.Join(spoolDir, filepath.Join(strings.SplitN(request.executionID, ".", 2)...))
+	if _, err := os.Stat(executionDir); err != nil {
+		return nil, fmt.Errorf("no spooled output found for execution %v", request.executionID)
+	}
+
+	var files []string
+	fileName := request.stream + ".gz"
+	filepath.Walk(executionDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != fileName {
+			return nil
+		}
+		relativePath, _ := filepath.Rel(executionDir, filepath.Dir(path))
+		if request.step == "" || containsString(strings.Split(relativePath, string(filepath.Separator)), request.step) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) == 0 {
+		if request.step != "" {
+			return nil, fmt.Errorf("no spooled %v found for step %v of execution %v", request.stream, request.step, request.executionID)
+		}
+		return nil, fmt.Errorf("no spooled %v found for execution %v", request.stream, request.executionID)
+	}
+	return files, nil
+}
+
+// readSpooledOutput decompresses a spool file, complete is false when the step is still writing to the file
+func readSpooledOutput(filePath string) (output []byte, complete bool, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err == io.EOF {
+		// nothing was written to the file yet
+		return []byte{}, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to read spooled output %v: %v", filePath, err)
+	}
+	defer reader.Close()
+
+	output, err = io.ReadAll(reader)
+	if err == io.ErrUnexpectedEOF {
+		// the compressed stream is flushed but not closed while the step runs
+		return output, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to read spooled output %v: %v", filePath, err)
+	}
+	return output, true, nil
+}
+
+// getSpooledOutput returns the full output of the requested stream of the execution
+func getSpooledOutput(spoolDir string, request executionOutputRequest) (error, string) {
+	files, err := findSpooledOutput(spoolDir, request)
+	if err != nil {
+		return err, ""
+	}
+
+	var buf bytes.Buffer
+	for _, filePath := range files {
+		output, _, err := readSpooledOutput(filePath)
+		if err != nil {
+			return err, ""
+		}
+		writeSpooledOutput(&buf, filePath, output, len(files) > 1)
+	}
+	return nil, strings.TrimSuffix(buf.String(), "\n")
+}
+
+// tailSpooledOutput returns the last lines of the requested stream of the execution
+func tailSpooledOutput(spoolDir string, request executionOutputRequest) (error, string) {
+	files, err := findSpooledOutput(spoolDir, request)
+	if err != nil {
+		return err, ""
+	}
+
+	var buf bytes.Buffer
+	for _, filePath := range files {
+		output, _, err := readSpooledOutput(filePath)
+		if err != nil {
+			return err, ""
+		}
+		writeSpooledOutput(&buf, filePath, lastLines(output, request.lines), len(files) > 1)
+	}
+	return nil, strings.TrimSuffix(buf.String(), "\n")
+}
+
+// followSpooledOutput writes the last lines of the requested stream and then the output written to it until the step completes
+func followSpooledOutput(out io.Writer, spoolDir string, request executionOutputRequest) error {
+	files, err := findSpooledOutput(spoolDir, request)
+	if err != nil {
+		return err
+	}
+	if len(files) > 1 {
+		return fmt.Errorf("%v steps of execution %v have spooled %v, use %v to follow one of them",
+			len(files), request.executionID, request.stream, cliutil.FormatFlag(executionOutputStep))
+	}
+
+	output, complete, err := readSpooledOutput(files[0])
+	if err != nil {
+		return err
+	}
+	out.Write(lastLines(output, request.lines))
+	offset := len(output)
+	for !complete {
+		time.Sleep(followPollInterval)
+		if output, complete, err = readSpooledOutput(files[0]); err != nil {
+			return err
+		}
+		if len(output) > offset {
+			out.Write(output[offset:])
+			offset = len(output)
+		}
+	}
+	return nil
+}
+
+// writeSpooledOutput writes the output of a spool file, preceded by the path of the file if several files are written
+func writeSpooledOutput(buf *bytes.Buffer, filePath string, output []byte, withHeader bool) {
+	if withHeader {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "==> %v <==\n", filePath)
+	}
+	buf.Write(output)
+	if len(output) > 0 && output[len(output)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+}
+
+// lastLines returns the last n lines of the output
+func lastLines(output []byte, n int) []byte {
+	if n == 0 {
+		return []byte{}
+	}
+	end := len(output)
+	if end > 0 && output[end-1] == '\n' {
+		end--
+	}
+	for start := end - 1; start >= 0; start-- {
+		if output[start] == '\n' {
+			if n--; n == 0 {
+				return output[start+1:]
+			}
+		}
+	}
+	return output
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestSpoolFile(t *testing.T, filePath string, output string, complete bool) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(output))
+	if complete {
+		assert.NoError(t, writer.Close())
+	} else {
+		assert.NoError(t, writer.Flush())
+	}
+	assert.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0600))
+}
+
+func TestValidateExecutionOutputInput(t *testing.T) {
+	validation, request := validateExecutionOutputInput(tailExecutionOutput, nil, map[string][]string{
+		executionOutputExecutionID: {"association-1.run-1"},
+		executionOutputLines:       {"5"},
+		executionOutputFollow:      {},
+	})
+	assert.Empty(t, validation)
+	assert.Equal(t, executionOutputRequest{executionID: "association-1.run-1", stream: executionOutputStdout, lines: 5, follow: true}, request)
+
+	validation, _ = validateExecutionOutputInput(getExecutionOutput, nil, map[string][]string{
+		executionOutputExecutionID: {"../command-1"},
+		executionOutputStream:      {"stdin"},
+		executionOutputFollow:      {},
+	})
+	assert.Len(t, validation, 3)
+}
+
+func TestGetSpooledOutput(t *testing.T) {
+	spoolDir := t.TempDir()
+	writeTestSpoolFile(t, filepath.Join(spoolDir, "command-1", "awsrunShellScript", "install", "stdout.gz"), "installing\ndone\n", true)
+	writeTestSpoolFile(t, filepath.Join(spoolDir, "command-1", "awsrunShellScript", "verify", "stdout.gz"), "verified", true)
+	writeTestSpoolFile(t, filepath.Join(spoolDir, "association-1", "run-1", "awsrunShellScript", "stderr.gz"), "warning\n", true)
+
+	err, output := getSpooledOutput(spoolDir, executionOutputRequest{executionID: "command-1", step: "install", stream: executionOutputStdout})
+	assert.NoError(t, err)
+	assert.Equal(t, "installing\ndone", output)
+
+	err, output = getSpooledOutput(spoolDir, executionOutputRequest{executionID: "command-1", stream: executionOutputStdout})
+	assert.NoError(t, err)
+	assert.Contains(t, output, filepath.Join("install", "stdout.gz")+" <==\ninstalling\ndone\n\n==> ")
+	assert.Contains(t, output, filepath.Join("verify", "stdout.gz")+" <==\nverified")
+
+	err, output = getSpooledOutput(spoolDir, executionOutputRequest{executionID: "association-1.run-1", stream: executionOutputStderr})
+	assert.NoError(t, err)
+	assert.Equal(t, "warning", output)
+
+	err, _ = getSpooledOutput(spoolDir, executionOutputRequest{executionID: "command-2", stream: executionOutputStdout})
+	assert.Error(t, err)
+}
+
+func TestTailSpooledOutput_StepInProgress(t *testing.T) {
+	spoolDir := t.TempDir()
+	writeTestSpoolFile(t, filepath.Join(spoolDir, "command-1", "awsrunShellScript", "stdout.gz"), "1\n2\n3\n4\n", false)
+
+	err, output := tailSpooledOutput(spoolDir, executionOutputRequest{executionID: "command-1", stream: executionOutputStdout, lines: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n4", output)
+}
+
+func TestFollowSpooledOutput(t *testing.T) {
+	defer func(interval time.Duration) { followPollInterval = interval }(followPollInterval)
+	followPollInterval = 10 * time.Millisecond
+
+	spoolDir := t.TempDir()
+	filePath := filepath.Join(spoolDir, "command-1", "awsrunShellScript", "stdout.gz")
+	writeTestSpoolFile(t, filePath, "1\n2\n", false)
+
+	done := make(chan error)
+	var out bytes.Buffer
+	go func() {
+		done <- followSpooledOutput(&out, spoolDir, executionOutputRequest{executionID: "command-1", stream: executionOutputStdout, lines: 1})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	writeTestSpoolFile(t, filePath, "1\n2\n3\n", true)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Equal(t, "2\n3\n", out.String())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "follow did not stop when the step completed")
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	assert.Equal(t, "b\nc\n", string(lastLines([]byte("a\nb\nc\n"), 2)))
+	assert.Equal(t, "b\nc", string(lastLines([]byte("a\nb\nc"), 2)))
+	assert.Equal(t, "a\nb\nc", string(lastLines([]byte("a\nb\nc"), 10)))
+	assert.Equal(t, "", string(lastLines([]byte("a\nb\nc"), 0)))
+}
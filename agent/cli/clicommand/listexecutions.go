@@ -0,0 +1,528 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+)
+
+const (
+	listExecutions             = "list-executions"
+	listExecutionsStatus       = "status"
+	listExecutionsDocumentName = "document-name"
+	listExecutionsStartTime    = "start-time"
+	listExecutionsEndTime      = "end-time"
+	listExecutionsOutput       = "output"
+	listExecutionsOutputTable  = "table"
+	listExecutionsOutputJson   = "json"
+)
+
+const (
+	executionStatusPending = "Pending"
+	executionStatusCorrupt = "Corrupt"
+)
+
+const listExecutionsHelp = `NAME:
+    {{.ListExecutionsName}}
+
+DESCRIPTION
+    Lists the run command, association and offline command executions known to the local amazon-ssm-agent,
+    with the status, exit code and output location of each step. Executions are read from the pending, current,
+    completed and corrupt document state folders of the agent and from the results of offline commands, so
+    the command works when the instance cannot reach Systems Manager.
+
+SYNOPSIS
+    {{.ListExecutionsName}}
+    [{{.StatusFlag}}]
+    [{{.DocumentNameFlag}}]
+    [{{.StartTimeFlag}}]
+    [{{.EndTimeFlag}}]
+    [{{.OutputFlag}}]
+
+PARAMETERS
+    {{.StatusFlag}} (list) Only list executions with one of the given statuses, for example Pending InProgress Failed.
+
+    {{.DocumentNameFlag}} (string) Only list executions of the given document.
+
+    {{.StartTimeFlag}} (string) Only list executions created at or after the given RFC 3339 time.
+
+    {{.EndTimeFlag}} (string) Only list executions created at or before the given RFC 3339 time.
+
+    {{.OutputFlag}} (string) Format of the list, {{.OutputTable}} (default) or {{.OutputJson}}.
+
+EXAMPLES
+    This example lists the failed executions created since the start of 2024.
+
+    Command:
+
+      {{.SsmCliName}} {{.ListExecutionsName}} {{.StatusFlag}} Failed {{.StartTimeFlag}} 2024-01-01T00:00:00Z
+
+    Output:
+
+      EXECUTION ID                          TYPE         DOCUMENT            STATUS  CREATED               STEP     STEP STATUS  EXIT CODE  OUTPUT PATH
+      01234567-890a-bcde-f012-34567890abcd  SendCommand  AWS-RunShellScript  Failed  2024-01-01T10:00:00Z  install  Failed       1          /var/lib/amazon/ssm/...
+
+OUTPUT
+    The executions, most recent first, with one row per step
+`
+
+type listExecutionsHelpParams struct {
+	SsmCliName         string
+	ListExecutionsName string
+	StatusFlag         string
+	DocumentNameFlag   string
+	StartTimeFlag      string
+	EndTimeFlag        string
+	OutputFlag         string
+	OutputTable        string
+	OutputJson         string
+}
+
+// executionFilter holds the conditions an execution has to meet to be listed
+type executionFilter struct {
+	statuses     []string
+	documentName string
+	startTime    time.Time
+	endTime      time.Time
+}
+
+// executionSummary describes an execution found on the instance
+type executionSummary struct {
+	ExecutionID            string          `json:"executionId"`
+	ExecutionType          string          `json:"executionType"`
+	DocumentName           string          `json:"documentName"`
+	Status                 string          `json:"status"`
+	StateFolder            string          `json:"stateFolder"`
+	CreatedDate            time.Time       `json:"createdDate"`
+	OrchestrationDirectory string          `json:"orchestrationDirectory,omitempty"`
+	Steps                  []executionStep `json:"steps"`
+}
+
+// executionStep describes the result of a step of an execution
+type executionStep struct {
+	StepName   string `json:"stepName"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exitCode"`
+	OutputPath string `json:"outputPath,omitempty"`
+}
+
+// executionStore locates the files the agent keeps about the executions of documents
+type executionStore struct {
+	documentStateDir     func(locationFolder string) string
+	orchestrationRootDir string
+	offlineCompletedDir  string
+	offlineSubmittedDir  string
+}
+
+func init() {
+	cliutil.Register(&ListExecutionsCommand{})
+}
+
+type ListExecutionsCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the list-executions cli command
+func (c *ListExecutionsCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, filter, outputFormat := c.validateListExecutionsInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return err, ""
+	}
+
+	shortInstanceID, err := agentIdentity.ShortInstanceID()
+	if err != nil {
+		return err, ""
+	}
+
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return err, ""
+	}
+
+	store := executionStore{
+		documentStateDir: func(locationFolder string) string {
+			return docmanager.DocumentStateDir(shortInstanceID, locationFolder)
+		},
+		orchestrationRootDir: filepath.Join(appconfig.DefaultDataStorePath,
+			shortInstanceID,
+			appconfig.DefaultDocumentRootDirName,
+			config.Agent.OrchestrationRootDir),
+		offlineCompletedDir: appconfig.LocalCommandRootCompleted,
+		offlineSubmittedDir: appconfig.LocalCommandRootSubmitted,
+	}
+	return formatExecutions(filterExecutions(store.listExecutions(), filter), outputFormat)
+}
+
+// Help prints help for the list-executions cli command
+func (c *ListExecutionsCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ListExecutionsHelp").Parse(listExecutionsHelp)
+		params := listExecutionsHelpParams{
+			cliutil.SsmCliName,
+			listExecutions,
+			cliutil.FormatFlag(listExecutionsStatus),
+			cliutil.FormatFlag(listExecutionsDocumentName),
+			cliutil.FormatFlag(listExecutionsStartTime),
+			cliutil.FormatFlag(listExecutionsEndTime),
+			cliutil.FormatFlag(listExecutionsOutput),
+			listExecutionsOutputTable,
+			listExecutionsOutputJson,
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ListExecutionsCommand) Name() string {
+	return listExecutions
+}
+
+// validateListExecutionsInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ListExecutionsCommand) validateListExecutionsInput(subcommands []string, parameters map[string][]string) (validation []string, filter executionFilter, outputFormat string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", listExecutions, subcommands), "")
+		return validation, filter, "" // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	if values, exists := parameters[listExecutionsStatus]; exists {
+		if len(values) == 0 {
+			validation = append(validation, fmt.Sprintf("expected at least 1 value for parameter %v", cliutil.FormatFlag(listExecutionsStatus)))
+		}
+		filter.statuses = values
+	}
+
+	if values, exists := parameters[listExecutionsDocumentName]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(listExecutionsDocumentName)))
+		} else {
+			filter.documentName = values[0]
+		}
+	}
+
+	for _, timeFlag := range []struct {
+		flag  string
+		value *time.Time
+	}{{listExecutionsStartTime, &filter.startTime}, {listExecutionsEndTime, &filter.endTime}} {
+		flag := timeFlag.flag
+		values, exists := parameters[flag]
+		if !exists {
+			continue
+		}
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(flag)))
+			continue
+		}
+		parsedTime, err := time.Parse(time.RFC3339, values[0])
+		if err != nil {
+			validation = append(validation, fmt.Sprintf("%v value must be a RFC 3339 time such as 2024-01-01T00:00:00Z", cliutil.FormatFlag(flag)))
+			continue
+		}
+		*timeFlag.value = parsedTime
+	}
+	if !filter.startTime.IsZero() && !filter.endTime.IsZero() && filter.endTime.Before(filter.startTime) {
+		validation = append(validation, fmt.Sprintf("%v must not be before %v", cliutil.FormatFlag(listExecutionsEndTime), cliutil.FormatFlag(listExecutionsStartTime)))
+	}
+
+	outputFormat = listExecutionsOutputTable
+	if values, exists := parameters[listExecutionsOutput]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(listExecutionsOutput)))
+		} else if outputFormat = values[0]; outputFormat != listExecutionsOutputTable && outputFormat != listExecutionsOutputJson {
+			validation = append(validation, fmt.Sprintf("%v value must be %v or %v",
+				cliutil.FormatFlag(listExecutionsOutput), listExecutionsOutputTable, listExecutionsOutputJson))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		switch key {
+		case listExecutionsStatus, listExecutionsDocumentName, listExecutionsStartTime, listExecutionsEndTime, listExecutionsOutput:
+		default:
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, filter, outputFormat
+}
+
+// listExecutions returns the executions found in the document state folders and the results of offline commands
+func (store executionStore) listExecutions() (executions []executionSummary) {
+	listedIDs := make(map[string]bool)
+	for _, locationFolder := range []string{
+		appconfig.DefaultLocationOfPending,
+		appconfig.DefaultLocationOfCurrent,
+		appconfig.DefaultLocationOfCompleted,
+		appconfig.DefaultLocationOfCorrupt} {
+
+		stateDir := store.documentStateDir(locationFolder)
+		fileNames, err := fileutil.GetFileNames(stateDir)
+		if err != nil {
+			continue
+		}
+		for _, fileName := range fileNames {
+			execution := readDocumentStateExecution(filepath.Join(stateDir, fileName), locationFolder)
+			listedIDs[execution.ExecutionID] = true
+			executions = append(executions, execution)
+		}
+	}
+
+	// offline commands that are still running are already listed from their document state
+	fileNames, _ := fileutil.GetFileNames(store.offlineCompletedDir)
+	for _, commandID := range fileNames {
+		if listedIDs[commandID] {
+			continue
+		}
+		if execution, ok := store.readOfflineExecution(commandID); ok {
+			executions = append(executions, execution)
+		}
+	}
+
+	sort.SliceStable(executions, func(i, j int) bool {
+		return executions[i].CreatedDate.After(executions[j].CreatedDate)
+	})
+	return executions
+}
+
+// readDocumentStateExecution describes the execution persisted in a document state file
+func readDocumentStateExecution(stateFilePath string, locationFolder string) executionSummary {
+	execution := executionSummary{
+		ExecutionID: filepath.Base(stateFilePath),
+		StateFolder: locationFolder,
+		Steps:       []executionStep{},
+	}
+	execution.CreatedDate, _ = fileutil.GetFileModificationTime(stateFilePath)
+
+	var docState contracts.DocumentState
+	if err := jsonutil.UnmarshalFile(stateFilePath, &docState); err != nil {
+		// the agent moves the state files it cannot read to the corrupt folder
+		execution.Status = executionStatusCorrupt
+		return execution
+	}
+
+	docInfo := docState.DocumentInformation
+	if docInfo.DocumentID != "" {
+		execution.ExecutionID = docInfo.DocumentID
+	}
+	execution.ExecutionType = string(docState.DocumentType)
+	execution.DocumentName = docInfo.DocumentName
+	execution.OrchestrationDirectory = docState.IOConfig.OrchestrationDirectory
+	if createdDate, err := time.Parse(time.RFC3339, docInfo.CreatedDate); err == nil {
+		execution.CreatedDate = createdDate
+	}
+
+	switch {
+	case locationFolder == appconfig.DefaultLocationOfPending:
+		execution.Status = executionStatusPending
+	case locationFolder == appconfig.DefaultLocationOfCorrupt:
+		execution.Status = executionStatusCorrupt
+	case docInfo.DocumentStatus != "":
+		execution.Status = string(docInfo.DocumentStatus)
+	default:
+		execution.Status = string(contracts.ResultStatusInProgress)
+	}
+
+	for _, pluginState := range docState.InstancePluginsInformation {
+		step := executionStep{
+			StepName:   pluginState.Id,
+			Action:     pluginState.Name,
+			Status:     string(pluginState.Result.Status),
+			ExitCode:   pluginState.Result.Code,
+			OutputPath: stepOutputPath(execution.OrchestrationDirectory, pluginState.Name, pluginState.Id, pluginState.Configuration),
+		}
+		if step.Status == "" {
+			step.Status = string(contracts.ResultStatusNotStarted)
+		}
+		execution.Steps = append(execution.Steps, step)
+	}
+	return execution
+}
+
+// readOfflineExecution describes the offline command from the result written by the offline service
+func (store executionStore) readOfflineExecution(commandID string) (execution executionSummary, ok bool) {
+	resultPath := filepath.Join(store.offlineCompletedDir, commandID)
+	var payload messageContracts.SendReplyPayload
+	if err := jsonutil.UnmarshalFile(resultPath, &payload); err != nil || payload.DocumentStatus == "" {
+		// dry runs write their execution plan instead of a result
+		return execution, false
+	}
+
+	execution = executionSummary{
+		ExecutionID:   commandID,
+		ExecutionType: string(contracts.SendCommandOffline),
+		Status:        string(payload.DocumentStatus),
+		StateFolder:   appconfig.DefaultLocationOfCompleted,
+		Steps:         []executionStep{},
+	}
+	execution.CreatedDate, _ = fileutil.GetFileModificationTime(resultPath)
+
+	// the offline service renames submitted documents to <document name>.<command id>
+	fileNames, _ := fileutil.GetFileNames(store.offlineSubmittedDir)
+	for _, fileName := range fileNames {
+		if documentName := strings.TrimSuffix(fileName, "."+commandID); documentName != fileName {
+			execution.DocumentName = documentName
+			execution.CreatedDate, _ = fileutil.GetFileModificationTime(filepath.Join(store.offlineSubmittedDir, fileName))
+			break
+		}
+	}
+
+	if orchestrationDir := filepath.Join(store.orchestrationRootDir, commandID); fileutil.Exists(orchestrationDir) {
+		execution.OrchestrationDirectory = orchestrationDir
+	}
+	stepIDs := make([]string, 0, len(payload.RuntimeStatus))
+	for stepID := range payload.RuntimeStatus {
+		stepIDs = append(stepIDs, stepID)
+	}
+	sort.SliceStable(stepIDs, func(i, j int) bool {
+		left, right := payload.RuntimeStatus[stepIDs[i]], payload.RuntimeStatus[stepIDs[j]]
+		if left.StartDateTime != right.StartDateTime {
+			return left.StartDateTime < right.StartDateTime
+		}
+		return stepIDs[i] < stepIDs[j]
+	})
+	for _, stepID := range stepIDs {
+		status := payload.RuntimeStatus[stepID]
+		execution.Steps = append(execution.Steps, executionStep{
+			StepName:   stepID,
+			Action:     status.Name,
+			Status:     string(status.Status),
+			ExitCode:   status.Code,
+			OutputPath: stepOutputPath(execution.OrchestrationDirectory, status.Name, stepID, contracts.Configuration{PluginName: status.Name, PluginID: stepID}),
+		})
+	}
+	return execution, true
+}
+
+// stepOutputPath returns the folder the stdout and stderr of a step are written to, if it still exists
+func stepOutputPath(orchestrationDir string, pluginName string, stepName string, config contracts.Configuration) string {
+	if orchestrationDir == "" {
+		return ""
+	}
+	outputPath := fileutil.BuildPath(orchestrationDir, pluginName)
+	if config.PluginName != config.PluginID {
+		// steps of schema 2.0 and later write to a folder named after the step
+		outputPath = fileutil.BuildPath(outputPath, stepName)
+	}
+	if !fileutil.Exists(outputPath) {
+		return ""
+	}
+	return outputPath
+}
+
+// filterExecutions returns the executions that meet the conditions of the filter
+func filterExecutions(executions []executionSummary, filter executionFilter) []executionSummary {
+	filtered := make([]executionSummary, 0, len(executions))
+	for _, execution := range executions {
+		if filter.documentName != "" && execution.DocumentName != filter.documentName {
+			continue
+		}
+		if !filter.startTime.IsZero() && execution.CreatedDate.Before(filter.startTime) {
+			continue
+		}
+		if !filter.endTime.IsZero() && execution.CreatedDate.After(filter.endTime) {
+			continue
+		}
+		if len(filter.statuses) > 0 && !matchesAnyStatus(execution.Status, filter.statuses) {
+			continue
+		}
+		filtered = append(filtered, execution)
+	}
+	return filtered
+}
+
+// matchesAnyStatus returns whether the status is one of the given statuses, ignoring case
+func matchesAnyStatus(status string, statuses []string) bool {
+	for _, candidate := range statuses {
+		if strings.EqualFold(status, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatExecutions prints the executions as a table or as JSON
+func formatExecutions(executions []executionSummary, outputFormat string) (error, string) {
+	if outputFormat == listExecutionsOutputJson {
+		output, err := json.MarshalIndent(struct {
+			Executions []executionSummary `json:"executions"`
+		}{executions}, "", "  ")
+		return err, string(output)
+	}
+
+	if len(executions) == 0 {
+		return nil, "No executions found"
+	}
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "EXECUTION ID\tTYPE\tDOCUMENT\tSTATUS\tCREATED\tSTEP\tSTEP STATUS\tEXIT CODE\tOUTPUT PATH")
+	for _, execution := range executions {
+		executionColumns := strings.Join([]string{
+			execution.ExecutionID,
+			valueOrDash(execution.ExecutionType),
+			valueOrDash(execution.DocumentName),
+			execution.Status,
+			execution.CreatedDate.UTC().Format(time.RFC3339),
+		}, "\t")
+		if len(execution.Steps) == 0 {
+			fmt.Fprintf(writer, "%s\t-\t-\t-\t-\n", executionColumns)
+			continue
+		}
+		for index, step := range execution.Steps {
+			if index > 0 {
+				// the execution is only printed on the row of its first step
+				executionColumns = "\t\t\t\t"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+				executionColumns, step.StepName, step.Status, strconv.Itoa(step.ExitCode), valueOrDash(step.OutputPath))
+		}
+	}
+	writer.Flush()
+	return nil, strings.TrimSuffix(buf.String(), "\n")
+}
+
+// valueOrDash returns a dash for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
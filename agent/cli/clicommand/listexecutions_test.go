@@ -0,0 +1,221 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestExecutionStore(t *testing.T) executionStore {
+	root := t.TempDir()
+	store := executionStore{
+		documentStateDir: func(locationFolder string) string {
+			return filepath.Join(root, "state", locationFolder)
+		},
+		orchestrationRootDir: filepath.Join(root, "orchestration"),
+		offlineCompletedDir:  filepath.Join(root, "localcommands", "completed"),
+		offlineSubmittedDir:  filepath.Join(root, "localcommands", "submitted"),
+	}
+	for _, dir := range []string{
+		store.documentStateDir(appconfig.DefaultLocationOfPending),
+		store.documentStateDir(appconfig.DefaultLocationOfCurrent),
+		store.documentStateDir(appconfig.DefaultLocationOfCorrupt),
+		store.offlineCompletedDir,
+		store.offlineSubmittedDir,
+	} {
+		assert.NoError(t, os.MkdirAll(dir, 0700))
+	}
+	return store
+}
+
+func writeTestJson(t *testing.T, filePath string, value interface{}) {
+	content, err := jsonutil.Marshal(value)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+}
+
+func TestListExecutions(t *testing.T) {
+	store := newTestExecutionStore(t)
+	orchestrationDir := filepath.Join(store.orchestrationRootDir, "command-1")
+	assert.NoError(t, os.MkdirAll(filepath.Join(orchestrationDir, "awsrunShellScript", "install"), 0700))
+
+	writeTestJson(t, filepath.Join(store.documentStateDir(appconfig.DefaultLocationOfCurrent), "command-1"), contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{
+			DocumentID:     "command-1",
+			DocumentName:   "AWS-RunShellScript",
+			DocumentStatus: contracts.ResultStatusInProgress,
+			CreatedDate:    "2024-01-02T10:00:00.000Z",
+		},
+		DocumentType: contracts.SendCommand,
+		IOConfig:     contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir},
+		InstancePluginsInformation: []contracts.PluginState{
+			{
+				Id:            "install",
+				Name:          "aws:runShellScript",
+				Configuration: contracts.Configuration{PluginID: "install", PluginName: "aws:runShellScript"},
+				Result:        contracts.PluginResult{Status: contracts.ResultStatusFailed, Code: 1},
+			},
+			{
+				Id:            "configure",
+				Name:          "aws:runShellScript",
+				Configuration: contracts.Configuration{PluginID: "configure", PluginName: "aws:runShellScript"},
+			},
+		},
+	})
+	writeTestJson(t, filepath.Join(store.documentStateDir(appconfig.DefaultLocationOfPending), "association-1.run-1"), contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{
+			DocumentID:   "association-1.run-1",
+			DocumentName: "AWS-UpdateSSMAgent",
+			CreatedDate:  "2024-01-03T10:00:00.000Z",
+		},
+		DocumentType: contracts.Association,
+	})
+	assert.NoError(t, os.WriteFile(filepath.Join(store.documentStateDir(appconfig.DefaultLocationOfCorrupt), "command-2"), []byte("{"), 0600))
+
+	writeTestJson(t, filepath.Join(store.offlineCompletedDir, "command-3"), messageContracts.SendReplyPayload{
+		DocumentStatus: contracts.ResultStatusSuccess,
+		RuntimeStatus: map[string]*contracts.PluginRuntimeStatus{
+			"second": {Name: "aws:runShellScript", Status: contracts.ResultStatusSuccess, StartDateTime: "2024-01-01T10:00:02.000Z"},
+			"first":  {Name: "aws:runShellScript", Status: contracts.ResultStatusSuccess, StartDateTime: "2024-01-01T10:00:01.000Z"},
+		},
+	})
+	assert.NoError(t, os.WriteFile(filepath.Join(store.offlineSubmittedDir, "offline.json.command-3"), []byte("{}"), 0600))
+	createdDate := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filepath.Join(store.offlineSubmittedDir, "offline.json.command-3"), createdDate, createdDate))
+	writeTestJson(t, filepath.Join(store.offlineCompletedDir, "command-4"), messageContracts.DryRunPayload{DryRun: true})
+	// an offline command that is still running is listed from its document state
+	writeTestJson(t, filepath.Join(store.offlineCompletedDir, "command-1"), messageContracts.SendReplyPayload{DocumentStatus: contracts.ResultStatusInProgress})
+
+	executions := store.listExecutions()
+
+	var executionIDs []string
+	for _, execution := range executions {
+		executionIDs = append(executionIDs, execution.ExecutionID)
+	}
+	// the corrupt execution is dated by its file, which was written last
+	assert.Equal(t, []string{"command-2", "association-1.run-1", "command-1", "command-3"}, executionIDs)
+
+	assert.Equal(t, executionStatusCorrupt, executions[0].Status)
+	assert.Equal(t, executionStatusPending, executions[1].Status)
+	assert.Equal(t, string(contracts.Association), executions[1].ExecutionType)
+
+	assert.Equal(t, "InProgress", executions[2].Status)
+	assert.Equal(t, []executionStep{
+		{StepName: "install", Action: "aws:runShellScript", Status: "Failed", ExitCode: 1, OutputPath: filepath.Join(orchestrationDir, "awsrunShellScript", "install")},
+		{StepName: "configure", Action: "aws:runShellScript", Status: "NotStarted"},
+	}, executions[2].Steps)
+
+	assert.Equal(t, "offline.json", executions[3].DocumentName)
+	assert.Equal(t, string(contracts.SendCommandOffline), executions[3].ExecutionType)
+	assert.Equal(t, createdDate, executions[3].CreatedDate.UTC())
+	assert.Equal(t, "first", executions[3].Steps[0].StepName)
+	assert.Equal(t, "second", executions[3].Steps[1].StepName)
+}
+
+func TestFilterExecutions(t *testing.T) {
+	executions := []executionSummary{
+		{ExecutionID: "1", DocumentName: "A", Status: "Failed", CreatedDate: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{ExecutionID: "2", DocumentName: "B", Status: "Success", CreatedDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ExecutionID: "3", DocumentName: "A", Status: "TimedOut", CreatedDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	testCases := []struct {
+		name   string
+		filter executionFilter
+		ids    []string
+	}{
+		{"no filter", executionFilter{}, []string{"1", "2", "3"}},
+		{"status ignores case", executionFilter{statuses: []string{"failed", "TimedOut"}}, []string{"1", "3"}},
+		{"document name", executionFilter{documentName: "B"}, []string{"2"}},
+		{"time range", executionFilter{
+			startTime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			endTime:   time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		}, []string{"2"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ids := []string{}
+			for _, execution := range filterExecutions(executions, testCase.filter) {
+				ids = append(ids, execution.ExecutionID)
+			}
+			assert.Equal(t, testCase.ids, ids)
+		})
+	}
+}
+
+func TestValidateListExecutionsInput(t *testing.T) {
+	validation, filter, outputFormat := ListExecutionsCommand{}.validateListExecutionsInput(nil, map[string][]string{
+		listExecutionsStatus:    {"Failed", "Cancelled"},
+		listExecutionsStartTime: {"2024-01-01T00:00:00Z"},
+		listExecutionsOutput:    {"json"},
+	})
+	assert.Empty(t, validation)
+	assert.Equal(t, []string{"Failed", "Cancelled"}, filter.statuses)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.startTime)
+	assert.Equal(t, listExecutionsOutputJson, outputFormat)
+
+	validation, _, _ = ListExecutionsCommand{}.validateListExecutionsInput(nil, map[string][]string{
+		listExecutionsStartTime: {"2024-01-02T00:00:00Z"},
+		listExecutionsEndTime:   {"2024-01-01T00:00:00Z"},
+		listExecutionsOutput:    {"yaml"},
+		"unknown":               {},
+	})
+	assert.ElementsMatch(t, []string{
+		"--end-time must not be before --start-time",
+		"--output value must be table or json",
+		"unknown parameter --unknown",
+	}, validation)
+}
+
+func TestFormatExecutionsAsTable(t *testing.T) {
+	err, output := formatExecutions([]executionSummary{
+		{
+			ExecutionID:   "command-1",
+			ExecutionType: "SendCommand",
+			DocumentName:  "AWS-RunShellScript",
+			Status:        "Failed",
+			CreatedDate:   time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			Steps: []executionStep{
+				{StepName: "install", Status: "Failed", ExitCode: 1, OutputPath: "/out/install"},
+				{StepName: "configure", Status: "Skipped"},
+			},
+		},
+	}, listExecutionsOutputTable)
+
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []string{"EXECUTION", "ID", "TYPE", "DOCUMENT", "STATUS", "CREATED", "STEP", "STEP", "STATUS", "EXIT", "CODE", "OUTPUT", "PATH"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"command-1", "SendCommand", "AWS-RunShellScript", "Failed", "2024-01-01T10:00:00Z", "install", "Failed", "1", "/out/install"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"configure", "Skipped", "0", "-"}, strings.Fields(lines[2]))
+
+	err, output = formatExecutions([]executionSummary{}, listExecutionsOutputTable)
+	assert.NoError(t, err)
+	assert.Equal(t, "No executions found", output)
+
+	err, output = formatExecutions([]executionSummary{}, listExecutionsOutputJson)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"executions":[]}`, output)
+}
@@ -39,6 +39,7 @@ import (
 const (
 	sendCommand        = "send-offline-command"
 	sendCommandContent = "content"
+	sendCommandDryRun  = "dry-run"
 )
 
 const sendCommandHelp = `NAME:
@@ -48,12 +49,19 @@ DESCRIPTION
 SYNOPSIS
     {{.SendCommandName}}
     {{.ContentFlag}}
+    [{{.DryRunFlag}}]
 
 PARAMETERS
     {{.ContentFlag}} (string) JSON or URL to command document.
     A valid command document is a configuration document with all parameters filled in.
     For information about writing a configuration document, see Configuration Document in the SSM API Reference.
 
+    {{.DryRunFlag}} (boolean) true if provided. The agent parses the document, resolves its parameters and evaluates
+    preconditions and control flow, then writes the execution plan to the completed folder instead of running any plugin.
+    The plan shows the inputs of the steps with the document and SSM parameters resolved. SecureString and secret
+    backend parameters are not resolved and the values of secure local parameters are redacted.
+    Documents with "dryRun": true are processed the same way.
+
 EXAMPLES
     This example runs a command in a document in S3.
 
@@ -73,6 +81,7 @@ type sendCommandHelpParams struct {
 	SsmCliName      string
 	SendCommandName string
 	ContentFlag     string
+	DryRunFlag      string
 }
 
 func init() {
@@ -96,9 +105,15 @@ func (c *SendOfflineCommand) Execute(subcommands []string, parameters map[string
 		return err, ""
 	}
 
-	if err, content := c.loadContent(agentIdentity, parameters[sendCommandContent][0]); err != nil {
+	err, content := c.loadContent(agentIdentity, parameters[sendCommandContent][0])
+	if err != nil {
 		return err, ""
-	} else if err := c.validateContent(content); err != nil {
+	}
+	if _, dryRun := parameters[sendCommandDryRun]; dryRun {
+		content.DryRun = true
+	}
+
+	if err := c.validateContent(content); err != nil {
 		return err, ""
 	} else if contentString, err := jsonutil.Marshal(content); err != nil {
 		return err, ""
@@ -113,7 +128,7 @@ func (c *SendOfflineCommand) Execute(subcommands []string, parameters map[string
 func (c *SendOfflineCommand) Help() string {
 	if len(c.helpText) == 0 {
 		t, _ := template.New("SendOfflineCommandHelp").Parse(sendCommandHelp)
-		params := sendCommandHelpParams{cliutil.SsmCliName, sendCommand, cliutil.FormatFlag(sendCommandContent)}
+		params := sendCommandHelpParams{cliutil.SsmCliName, sendCommand, cliutil.FormatFlag(sendCommandContent), cliutil.FormatFlag(sendCommandDryRun)}
 		buf := new(bytes.Buffer)
 		t.Execute(buf, params)
 		c.helpText = buf.String()
@@ -147,9 +162,13 @@ func (SendOfflineCommand) validateSendCommandInput(subcommands []string, paramet
 		}
 	}
 
+	if values, dryRun := parameters[sendCommandDryRun]; dryRun && len(values) > 0 {
+		validation = append(validation, fmt.Sprintf("flag %v should not have any values", cliutil.FormatFlag(sendCommandDryRun)))
+	}
+
 	// look for unsupported parameters
 	for key := range parameters {
-		if key != sendCommandContent {
+		if key != sendCommandContent && key != sendCommandDryRun {
 			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
 		}
 	}
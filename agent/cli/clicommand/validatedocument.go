@@ -0,0 +1,322 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/externalplugin"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	validateDocument           = "validate-document"
+	validateDocumentContent    = "content"
+	validateDocumentParameters = "parameters"
+	validateDocumentOutput     = "output"
+	validateDocumentOutputText = "text"
+	validateDocumentOutputJson = "json"
+)
+
+const validateDocumentHelp = `NAME:
+    {{.ValidateDocumentName}}
+
+DESCRIPTION
+    Validates a command or session document without running it. The document schema, the plugins used by the
+    document and the supplied parameters are checked the same way the agent checks them before running a document.
+    Parameters stored in parameter store are not resolved.
+
+SYNOPSIS
+    {{.ValidateDocumentName}}
+    {{.ContentFlag}}
+    [{{.ParametersFlag}}]
+    [{{.OutputFlag}}]
+
+PARAMETERS
+    {{.ContentFlag}} (string) JSON or YAML document, path or URL to a JSON or YAML document.
+
+    {{.ParametersFlag}} (string) JSON object with the values of the document parameters.
+
+    {{.OutputFlag}} (string) Format of the report, {{.OutputText}} (default) or {{.OutputJson}}.
+
+EXAMPLES
+    This example validates a command document stored on the instance.
+
+    Command:
+
+      {{.SsmCliName}} {{.ValidateDocumentName}} {{.ContentFlag}} file:///tmp/document.yaml {{.ParametersFlag}} '{"message":"hello"}'
+
+    Output:
+
+      $.mainSteps[1].action (step install): plugin aws:unknown is not supported by this version of ssm agent
+
+OUTPUT
+    Document is valid or the list of problems found in the document, with the JSON path of each problem
+`
+
+type validateDocumentHelpParams struct {
+	SsmCliName           string
+	ValidateDocumentName string
+	ContentFlag          string
+	ParametersFlag       string
+	OutputFlag           string
+	OutputText           string
+	OutputJson           string
+}
+
+// validateDocumentReport is the report printed by the validate-document cli command
+type validateDocumentReport struct {
+	Valid  bool                        `json:"valid"`
+	Errors []docparser.ValidationError `json:"errors"`
+}
+
+func init() {
+	cliutil.Register(&ValidateDocumentCommand{})
+}
+
+type ValidateDocumentCommand struct {
+	helpText string
+}
+
+// Execute validates and executes the validate-document cli command
+func (c *ValidateDocumentCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	validation, params, outputFormat := c.validateValidateDocumentInput(subcommands, parameters)
+	// return validation errors if any were found
+	if len(validation) > 0 {
+		return errors.New(strings.Join(validation, "\n")), ""
+	}
+
+	log := logger.NewSilentLogger()
+	rawContent, err := c.loadContent(log, parameters[validateDocumentContent][0])
+	if err != nil {
+		return err, ""
+	}
+
+	validationErrors, err := c.validateContent(log, rawContent, params)
+	if err != nil {
+		return err, ""
+	}
+
+	report, err := c.formatReport(validationErrors, outputFormat)
+	if err != nil {
+		return err, ""
+	}
+	if len(validationErrors) > 0 {
+		return fmt.Errorf("document is invalid"), report
+	}
+	return nil, report
+}
+
+// Help prints help for the validate-document cli command
+func (c *ValidateDocumentCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("ValidateDocumentHelp").Parse(validateDocumentHelp)
+		params := validateDocumentHelpParams{
+			cliutil.SsmCliName,
+			validateDocument,
+			cliutil.FormatFlag(validateDocumentContent),
+			cliutil.FormatFlag(validateDocumentParameters),
+			cliutil.FormatFlag(validateDocumentOutput),
+			validateDocumentOutputText,
+			validateDocumentOutputJson,
+		}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (ValidateDocumentCommand) Name() string {
+	return validateDocument
+}
+
+// validateValidateDocumentInput checks the subcommands and parameters for required values, format, and unsupported values
+func (ValidateDocumentCommand) validateValidateDocumentInput(subcommands []string, parameters map[string][]string) (validation []string, params map[string]interface{}, outputFormat string) {
+	validation = make([]string, 0)
+	if subcommands != nil && len(subcommands) > 0 {
+		validation = append(validation, fmt.Sprintf("%v does not support subcommand %v", validateDocument, subcommands), "")
+		return validation, nil, "" // invalid subcommand is an attempt to execute something that really isn't this command, so the rest of the validation is skipped in this case
+	}
+
+	// look for required parameters
+	if _, exists := parameters[validateDocumentContent]; !exists {
+		validation = append(validation, fmt.Sprintf("%v is required", cliutil.FormatFlag(validateDocumentContent)))
+	} else if len(parameters[validateDocumentContent]) != 1 {
+		validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(validateDocumentContent)))
+	}
+
+	if values, exists := parameters[validateDocumentParameters]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(validateDocumentParameters)))
+		} else if err := json.Unmarshal([]byte(values[0]), &params); err != nil {
+			validation = append(validation, fmt.Sprintf("%v value must be a JSON object", cliutil.FormatFlag(validateDocumentParameters)))
+		}
+	}
+
+	outputFormat = validateDocumentOutputText
+	if values, exists := parameters[validateDocumentOutput]; exists {
+		if len(values) != 1 {
+			validation = append(validation, fmt.Sprintf("expected 1 value for parameter %v", cliutil.FormatFlag(validateDocumentOutput)))
+		} else if outputFormat = values[0]; outputFormat != validateDocumentOutputText && outputFormat != validateDocumentOutputJson {
+			validation = append(validation, fmt.Sprintf("%v value must be %v or %v",
+				cliutil.FormatFlag(validateDocumentOutput), validateDocumentOutputText, validateDocumentOutputJson))
+		}
+	}
+
+	// look for unsupported parameters
+	for key := range parameters {
+		if key != validateDocumentContent && key != validateDocumentParameters && key != validateDocumentOutput {
+			validation = append(validation, fmt.Sprintf("unknown parameter %v", cliutil.FormatFlag(key)))
+		}
+	}
+	return validation, params, outputFormat
+}
+
+// loadContent returns the raw document given inline, in a local file or at a URL
+func (ValidateDocumentCommand) loadContent(log log.T, rawContent string) ([]byte, error) {
+	if cliutil.ValidJson(rawContent) {
+		return []byte(rawContent), nil
+	}
+
+	path := rawContent
+	if strings.HasPrefix(strings.ToLower(path), "file://") {
+		path = path[7:]
+	}
+	if fileutil.Exists(path) {
+		content, err := fileutil.ReadAllText(path)
+		return []byte(content), err
+	}
+
+	lowerContent := strings.ToLower(rawContent)
+	if !strings.HasPrefix(lowerContent, "http://") && !strings.HasPrefix(lowerContent, "https://") {
+		// neither a file nor a URL, the content is an inline YAML document
+		return []byte(rawContent), nil
+	}
+
+	// only remote documents need the agent identity, to download from S3
+	agentIdentity, err := cliutil.GetAgentIdentity()
+	if err != nil {
+		return nil, err
+	}
+	context := context.Default(log, appconfig.DefaultConfig(), agentIdentity)
+	output, err := artifact.Download(context, artifact.DownloadInput{SourceURL: rawContent})
+	if err != nil {
+		return nil, err
+	}
+	content, err := fileutil.ReadAllText(output.LocalFilePath)
+	return []byte(content), err
+}
+
+// validateContent decodes the JSON or YAML document and validates it as a session document if it declares
+// a sessionType, or as a command document otherwise
+func (ValidateDocumentCommand) validateContent(log log.T, rawContent []byte, params map[string]interface{}) ([]docparser.ValidationError, error) {
+	var content interface{}
+	if err := json.Unmarshal(rawContent, &content); err != nil {
+		if err := yaml.Unmarshal(rawContent, &content); err != nil {
+			return nil, fmt.Errorf("document must be valid JSON or YAML: %v", err)
+		}
+		content = convertYamlToJsonCompatible(content)
+	}
+	contentMap, ok := content.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document must be a JSON or YAML object")
+	}
+
+	// decode through JSON so that YAML documents are decoded exactly like JSON documents
+	contentJson, err := json.Marshal(contentMap)
+	if err != nil {
+		return nil, err
+	}
+
+	isPluginSupported := func(pluginName string) (isKnown bool, isSupported bool) {
+		if _, found := externalplugin.Lookup(pluginName); found {
+			return true, true
+		}
+		isKnown, isSupported, _ = runpluginutil.IsPluginSupportedForCurrentPlatform(log, pluginName)
+		return isKnown, isSupported
+	}
+	if _, isSessionDocument := contentMap["sessionType"]; isSessionDocument {
+		var sessionDocContent docparser.SessionDocContent
+		if err := json.Unmarshal(contentJson, &sessionDocContent); err != nil {
+			return nil, fmt.Errorf("invalid session document: %v", err)
+		}
+		return sessionDocContent.Validate(log, params, isPluginSupported), nil
+	}
+	var docContent docparser.DocContent
+	if err := json.Unmarshal(contentJson, &docContent); err != nil {
+		return nil, fmt.Errorf("invalid command document: %v", err)
+	}
+	return docContent.Validate(log, params, isPluginSupported), nil
+}
+
+// formatReport formats the errors found in the document as text or JSON
+func (ValidateDocumentCommand) formatReport(validationErrors []docparser.ValidationError, outputFormat string) (string, error) {
+	if outputFormat == validateDocumentOutputJson {
+		report := validateDocumentReport{Valid: len(validationErrors) == 0, Errors: validationErrors}
+		if report.Errors == nil {
+			report.Errors = []docparser.ValidationError{}
+		}
+		reportJson, err := json.MarshalIndent(report, "", "  ")
+		return string(reportJson), err
+	}
+
+	if len(validationErrors) == 0 {
+		return "Document is valid", nil
+	}
+	lines := make([]string, 0, len(validationErrors))
+	for _, validationError := range validationErrors {
+		if validationError.Step != "" {
+			lines = append(lines, fmt.Sprintf("%v (step %v): %v", validationError.Path, validationError.Step, validationError.Message))
+		} else {
+			lines = append(lines, fmt.Sprintf("%v: %v", validationError.Path, validationError.Message))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// convertYamlToJsonCompatible converts the map[interface{}]interface{} decoded from YAML to map[string]interface{}
+func convertYamlToJsonCompatible(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			converted[fmt.Sprintf("%v", k)] = convertYamlToJsonCompatible(v)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, v := range value {
+			converted[i] = convertYamlToJsonCompatible(v)
+		}
+		return converted
+	}
+	return value
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validJsonDocument = `{
+  "schemaVersion": "2.2",
+  "parameters": {"message": {"type": "String", "allowedPattern": "^[a-z]+$"}},
+  "mainSteps": [{"action": "aws:runShellScript", "name": "hello", "inputs": {"runCommand": ["echo {{ message }}"]}}]
+}`
+
+const validYamlDocument = `schemaVersion: "2.2"
+parameters:
+  message:
+    type: String
+    allowedPattern: "^[a-z]+$"
+mainSteps:
+  - action: aws:runShellScript
+    name: hello
+    inputs:
+      runCommand:
+        - echo {{ message }}
+`
+
+const unknownActionDocument = `{
+  "schemaVersion": "2.2",
+  "mainSteps": [
+    {"action": "aws:runShellScript", "name": "check", "inputs": {"runCommand": ["true"]}},
+    {"action": "aws:unknown", "name": "install", "inputs": {}}
+  ]
+}`
+
+const invalidDocument = `schemaVersion: "2.2"
+parameters:
+  count:
+    type: String
+    allowedPattern: "^[0-9]+$"
+  mode:
+    type: String
+    allowedValues: [fast, safe]
+mainSteps:
+  - action: aws:runShellScript
+    name: check
+    inputs:
+      runCommand: ["true"]
+  - action: aws:unknown
+    name: install
+    inputs: {}
+  - action: aws:runShellScript
+    name: check
+    maxAttempts: -1
+    inputs:
+      runCommand: ["true"]
+`
+
+const invalidDocumentReport = `$.parameters.count: error thrown in 'AllowedRegexParamValidator' while validating parameter /count/: parameter value /many/ does not match the allowed pattern /^[0-9]+$/
+$.parameters.mode: error thrown in 'AllowedValueParamValidator' while validating parameter /mode/: parameter value /slow/ is not in the allowed list [fast safe]
+$.mainSteps[1].action (step install): plugin aws:unknown is not supported by this version of ssm agent
+$.mainSteps[2].name (step check): step name check is used by more than one step
+$.mainSteps[2].maxAttempts (step check): maxAttempts cannot be negative`
+
+func TestValidateDocument(t *testing.T) {
+	documentPath := filepath.Join(t.TempDir(), "document.yaml")
+	assert.NoError(t, os.WriteFile(documentPath, []byte(validYamlDocument), 0600))
+
+	testCases := []struct {
+		name           string
+		parameters     map[string][]string
+		expectedErr    string
+		expectedReport string
+	}{
+		{
+			name:           "valid JSON document",
+			parameters:     map[string][]string{"content": {validJsonDocument}, "parameters": {`{"message":"hello"}`}},
+			expectedReport: "Document is valid",
+		},
+		{
+			name:           "valid YAML document",
+			parameters:     map[string][]string{"content": {validYamlDocument}, "parameters": {`{"message":"hello"}`}},
+			expectedReport: "Document is valid",
+		},
+		{
+			name:           "YAML document in a file",
+			parameters:     map[string][]string{"content": {"file://" + documentPath}, "parameters": {`{"message":"hello"}`}},
+			expectedReport: "Document is valid",
+		},
+		{
+			name:           "unknown action",
+			parameters:     map[string][]string{"content": {unknownActionDocument}},
+			expectedErr:    "document is invalid",
+			expectedReport: "$.mainSteps[1].action (step install): plugin aws:unknown is not supported by this version of ssm agent",
+		},
+		{
+			name:           "parameter validator failure",
+			parameters:     map[string][]string{"content": {validJsonDocument}, "parameters": {`{"message":"Hello World"}`}},
+			expectedErr:    "document is invalid",
+			expectedReport: "$.parameters.message: error thrown in 'AllowedRegexParamValidator' while validating parameter /message/: parameter value /Hello World/ does not match the allowed pattern /^[a-z]+$/",
+		},
+		{
+			name:           "missing parameter",
+			parameters:     map[string][]string{"content": {validJsonDocument}},
+			expectedErr:    "document is invalid",
+			expectedReport: "$.parameters.message: parameter message has no default value and must be supplied",
+		},
+		{
+			name:           "all errors are reported",
+			parameters:     map[string][]string{"content": {invalidDocument}, "parameters": {`{"count":"many","mode":"slow"}`}},
+			expectedErr:    "document is invalid",
+			expectedReport: invalidDocumentReport,
+		},
+		{
+			name:        "document is neither JSON nor YAML",
+			parameters:  map[string][]string{"content": {"mainSteps: [unclosed"}},
+			expectedErr: "document must be valid JSON or YAML: yaml: line 1: did not find expected ',' or ']'",
+		},
+		{
+			name:        "missing content",
+			parameters:  map[string][]string{"output": {"json"}},
+			expectedErr: "--content is required",
+		},
+		{
+			name:        "unsupported output format",
+			parameters:  map[string][]string{"content": {validJsonDocument}, "output": {"xml"}},
+			expectedErr: "--output value must be text or json",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err, report := (&ValidateDocumentCommand{}).Execute(nil, tc.parameters)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectedReport, report)
+		})
+	}
+}
+
+func TestValidateDocumentJsonOutput(t *testing.T) {
+	testCases := []struct {
+		name           string
+		parameters     map[string][]string
+		expectedErr    string
+		expectedReport string
+	}{
+		{
+			name:           "valid document",
+			parameters:     map[string][]string{"content": {validYamlDocument}, "parameters": {`{"message":"hello"}`}, "output": {"json"}},
+			expectedReport: `{"valid": true, "errors": []}`,
+		},
+		{
+			name:        "all errors are reported",
+			parameters:  map[string][]string{"content": {invalidDocument}, "parameters": {`{"count":"many","mode":"slow"}`}, "output": {"json"}},
+			expectedErr: "document is invalid",
+			expectedReport: `{"valid": false, "errors": [
+				{"path": "$.parameters.count", "message": "error thrown in 'AllowedRegexParamValidator' while validating parameter /count/: parameter value /many/ does not match the allowed pattern /^[0-9]+$/"},
+				{"path": "$.parameters.mode", "message": "error thrown in 'AllowedValueParamValidator' while validating parameter /mode/: parameter value /slow/ is not in the allowed list [fast safe]"},
+				{"path": "$.mainSteps[1].action", "step": "install", "message": "plugin aws:unknown is not supported by this version of ssm agent"},
+				{"path": "$.mainSteps[2].name", "step": "check", "message": "step name check is used by more than one step"},
+				{"path": "$.mainSteps[2].maxAttempts", "step": "check", "message": "maxAttempts cannot be negative"}
+			]}`,
+		},
+		{
+			name:        "unknown session type",
+			parameters:  map[string][]string{"content": {`{"schemaVersion": "1.0", "sessionType": "Unknown"}`}, "output": {"json"}},
+			expectedErr: "document is invalid",
+			expectedReport: `{"valid": false, "errors": [
+				{"path": "$.sessionType", "message": "plugin Unknown is not supported by this version of ssm agent"}
+			]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err, report := (&ValidateDocumentCommand{}).Execute(nil, tc.parameters)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+			assert.JSONEq(t, tc.expectedReport, report)
+		})
+	}
+}
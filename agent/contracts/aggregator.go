@@ -15,6 +15,7 @@
 package contracts
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -26,9 +27,17 @@ import (
 // for a particular plugin.
 func prepareRuntimeStatus(log log.T, pluginResult PluginResult) PluginRuntimeStatus {
 	var resultAsString string
+	structuredOutput, isStructured := pluginResult.Output.(map[string]interface{})
 
 	if pluginResult.Error == "" {
 		resultAsString = fmt.Sprintf("%v", pluginResult.Output)
+		if isStructured {
+			if structuredOutputJSON, err := json.Marshal(structuredOutput); err == nil {
+				resultAsString = string(structuredOutputJSON)
+			} else {
+				log.Warnf("Failed to serialize the structured output of %s: %v", pluginResult.PluginName, err)
+			}
+		}
 	} else {
 		resultAsString = pluginResult.Error
 	}
@@ -42,6 +51,10 @@ func prepareRuntimeStatus(log log.T, pluginResult PluginResult) PluginRuntimeSta
 		EndDateTime:    times.ToIso8601UTC(pluginResult.EndDateTime),
 		StandardOutput: pluginResult.StandardOutput,
 		StandardError:  pluginResult.StandardError,
+		StepOutputs:    pluginResult.StepOutputs,
+	}
+	if isStructured {
+		runtimeStatus.StructuredOutput = structuredOutput
 	}
 
 	if pluginResult.OutputS3BucketName != "" {
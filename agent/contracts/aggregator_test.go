@@ -40,6 +40,41 @@ func TestPrepareRuntimeStatus(t *testing.T) {
 				StandardOutput: "output",
 			},
 		},
+		{
+			Input: PluginResult{
+				PluginName:    "aws:runShellScript",
+				Status:        "Success",
+				Output:        "version=1.2.3",
+				StartDateTime: times.ParseIso8601UTC("2015-07-09T23:23:39.019Z"),
+				EndDateTime:   times.ParseIso8601UTC("2015-07-09T23:23:39.023Z"),
+				StepOutputs:   map[string]string{"version": "1.2.3"},
+			},
+			Output: PluginRuntimeStatus{
+				Name:          "aws:runShellScript",
+				Status:        "Success",
+				Output:        "version=1.2.3",
+				StartDateTime: "2015-07-09T23:23:39.019Z",
+				EndDateTime:   "2015-07-09T23:23:39.023Z",
+				StepOutputs:   map[string]string{"version": "1.2.3"},
+			},
+		},
+		{
+			Input: PluginResult{
+				PluginName:    "aws:runShellScript",
+				Status:        "Success",
+				Output:        map[string]interface{}{"version": "1.2.3", "healthy": true},
+				StartDateTime: times.ParseIso8601UTC("2015-07-09T23:23:39.019Z"),
+				EndDateTime:   times.ParseIso8601UTC("2015-07-09T23:23:39.023Z"),
+			},
+			Output: PluginRuntimeStatus{
+				Name:             "aws:runShellScript",
+				Status:           "Success",
+				Output:           `{"healthy":true,"version":"1.2.3"}`,
+				StartDateTime:    "2015-07-09T23:23:39.019Z",
+				EndDateTime:      "2015-07-09T23:23:39.023Z",
+				StructuredOutput: map[string]interface{}{"version": "1.2.3", "healthy": true},
+			},
+		},
 	}
 
 	// run test cases
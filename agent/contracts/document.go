@@ -86,6 +86,7 @@ type DocumentInfo struct {
 	ClientId        string
 	RunAsUser       string
 	SessionOwner    string
+	DryRun          bool
 }
 
 // CloudWatchConfiguration represents information relevant to command output in cloudWatch
@@ -101,6 +102,8 @@ type IOConfiguration struct {
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
 	CloudWatchConfig       CloudWatchConfiguration
+	// SecretValues are the values of the local parameters resolved for the document, its output masks them
+	SecretValues []string `json:",omitempty"`
 }
 
 // DocumentState represents information relevant to a command that gets executed by agent
@@ -124,6 +127,46 @@ func (c *DocumentState) IsAssociation() bool {
 	return c.DocumentType == Association
 }
 
+// IsResumed returns if the document resumes steps that were running when its document worker stopped
+func (c *DocumentState) IsResumed() bool {
+	for _, pluginState := range c.InstancePluginsInformation {
+		if pluginState.Configuration.IsResumed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasIncompleteSteps returns whether steps of the document are left to run
+func (c *DocumentState) HasIncompleteSteps() bool {
+	for _, pluginState := range c.InstancePluginsInformation {
+		if isIncompleteStep(pluginState) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkInterruptedSteps flags the incomplete steps that were running when the document worker stopped, the flags of an
+// earlier resume are cleared. The state file only learns about a step when it completes, isStepStarted tells from the
+// orchestration directory of a step whether it started running.
+func (c *DocumentState) MarkInterruptedSteps(isStepStarted func(orchestrationDirectory string) bool) {
+	for i := range c.InstancePluginsInformation {
+		pluginState := &c.InstancePluginsInformation[i]
+		pluginState.Configuration.IsResumed = isIncompleteStep(*pluginState) &&
+			isStepStarted(pluginState.Configuration.OrchestrationDirectory)
+	}
+}
+
+// isIncompleteStep returns whether the step has not completed, a step that requested a reboot continues after it
+func isIncompleteStep(pluginState PluginState) bool {
+	switch pluginState.Result.Status {
+	case "", ResultStatusNotStarted, ResultStatusInProgress:
+		return true
+	}
+	return false
+}
+
 // CancelCommandInfo represents information relevant to a cancel-command that agent receives
 // TODO  This might be revisited when Agent-cli is written to list previously executed commands
 type CancelCommandInfo struct {
@@ -0,0 +1,54 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package contracts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkInterruptedSteps(t *testing.T) {
+	docState := DocumentState{
+		DocumentInformation: DocumentInfo{DocumentStatus: ResultStatusInProgress},
+		InstancePluginsInformation: []PluginState{
+			{Id: "done", Result: PluginResult{Status: ResultStatusSuccess}, Configuration: Configuration{OrchestrationDirectory: "done", IsResumed: true}},
+			{Id: "running", Result: PluginResult{Status: ResultStatusNotStarted}, Configuration: Configuration{OrchestrationDirectory: "running"}},
+			{Id: "pending", Result: PluginResult{Status: ResultStatusNotStarted}, Configuration: Configuration{OrchestrationDirectory: "pending"}},
+		},
+	}
+	started := map[string]bool{"done": true, "running": true}
+	isStepStarted := func(orchestrationDirectory string) bool {
+		return started[orchestrationDirectory]
+	}
+
+	assert.True(t, docState.HasIncompleteSteps())
+	docState.MarkInterruptedSteps(isStepStarted)
+
+	var resumed []bool
+	for _, pluginState := range docState.InstancePluginsInformation {
+		resumed = append(resumed, pluginState.Configuration.IsResumed)
+	}
+	assert.Equal(t, []bool{false, true, false}, resumed)
+	assert.True(t, docState.IsResumed())
+
+	// a step that requested a reboot continues after it
+	docState.InstancePluginsInformation[1].Result.Status = ResultStatusSuccessAndReboot
+	docState.MarkInterruptedSteps(isStepStarted)
+	assert.False(t, docState.IsResumed())
+
+	docState.InstancePluginsInformation[1].Result.Status = ResultStatusSuccess
+	docState.InstancePluginsInformation[2].Result.Status = ResultStatusFailed
+	assert.False(t, docState.HasIncompleteSteps())
+}
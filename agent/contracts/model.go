@@ -55,12 +55,28 @@ const (
 	OnFailureModifier   string = "onFailure"
 	OnSuccessModifier   string = "onSuccess"
 	FinallyStepModifier string = "finallyStep"
+	NextStepModifier    string = "nextStep"
+	IsEndModifier       string = "isEnd"
+	// ParallelGroupModifier names the group of consecutive steps that run at the same time
+	ParallelGroupModifier string = "parallelGroup"
+	// RunAlwaysModifier marks a step that runs even if an earlier step failed, exited the document or was cancelled
+	RunAlwaysModifier string = "runAlways"
+	// ResumePolicyModifier decides what happens to a step that had not completed when the agent stopped unexpectedly
+	ResumePolicyModifier string = "resumePolicy"
+)
+
+const (
+	ResumePolicyRerun string = "rerun"
+	ResumePolicySkip  string = "skip"
+	ResumePolicyFail  string = "fail"
 )
 
 const (
 	ModifierValueExit           string = "exit"
 	ModifierValueSuccessAndExit string = "successAndExit"
 	ModifierValueTrue           string = "true"
+	// ModifierValueStepPrefix prefixes the name of the step to branch to in onFailure and onSuccess, e.g. "step:rollback"
+	ModifierValueStepPrefix string = "step:"
 )
 
 // IsSuccess checks whether the result is success or not
@@ -169,6 +185,12 @@ const (
 	ParamTypeStringList = "StringList"
 	// ParamTypeStringMap represents the param type is StringMap
 	ParamTypeStringMap = "StringMap"
+	// ParamTypeInteger represents the param type is Integer
+	ParamTypeInteger = "Integer"
+	// ParamTypeBoolean represents the param type is Boolean
+	ParamTypeBoolean = "Boolean"
+	// ParamTypeMapList represents the param type is MapList
+	ParamTypeMapList = "MapList"
 )
 
 type SSMConnectionChannel string
@@ -193,6 +215,10 @@ type Parameter struct {
 	MaxChars       json.Number `json:"maxChars,omitempty" yaml:"maxChars,omitempty"`
 	MinItems       json.Number `json:"minItems,omitempty" yaml:"minItems,omitempty"`
 	MaxItems       json.Number `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinValue       json.Number `json:"minValue,omitempty" yaml:"minValue,omitempty"`
+	MaxValue       json.Number `json:"maxValue,omitempty" yaml:"maxValue,omitempty"`
+	// Schema is the JSON Schema the value of a StringMap or MapList parameter must match
+	Schema interface{} `json:"schema,omitempty" yaml:"schema,omitempty"`
 }
 
 // PluginConfig stores plugin configuration
@@ -204,24 +230,47 @@ type PluginConfig struct {
 
 // InstancePluginConfig stores plugin configuration
 type InstancePluginConfig struct {
-	Action        string              `json:"action" yaml:"action"` // plugin name
-	Inputs        interface{}         `json:"inputs" yaml:"inputs"` // Properties
-	MaxAttempts   int                 `json:"maxAttempts" yaml:"maxAttempts"`
-	Name          string              `json:"name" yaml:"name"` // unique identifier
-	OnFailure     string              `json:"onFailure" yaml:"onFailure"`
-	Settings      interface{}         `json:"settings" yaml:"settings"`
-	Timeout       int                 `json:"timeoutSeconds" yaml:"timeoutSeconds"`
-	Preconditions map[string][]string `json:"precondition" yaml:"precondition"`
+	Action        string                   `json:"action" yaml:"action"` // plugin name
+	Inputs        interface{}              `json:"inputs" yaml:"inputs"` // Properties
+	MaxAttempts   int                      `json:"maxAttempts" yaml:"maxAttempts"`
+	Name          string                   `json:"name" yaml:"name"` // unique identifier
+	OnFailure     string                   `json:"onFailure" yaml:"onFailure"`
+	Settings      interface{}              `json:"settings" yaml:"settings"`
+	Timeout       int                      `json:"timeoutSeconds" yaml:"timeoutSeconds"`
+	Preconditions map[string][]interface{} `json:"precondition" yaml:"precondition"`
+	Outputs       []StepOutput             `json:"outputs" yaml:"outputs"`
+}
+
+// StepOutput declares a named value a step extracts from its standard output.
+// Later steps reference the value as {{ steps.<step name>.outputs.<output name> }}.
+type StepOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Selector string `json:"selector" yaml:"selector"`
 }
 
+const (
+	// StepOutputTypeJsonPath selects the value from standard output parsed as JSON, e.g. $.instances[0].id
+	StepOutputTypeJsonPath = "jsonPath"
+	// StepOutputTypeRegex selects the first capture group of a regular expression, or the whole match without groups
+	StepOutputTypeRegex = "regex"
+	// StepOutputTypeKeyValue selects the value of the last <selector>=<value> line, the selector defaults to the output name
+	StepOutputTypeKeyValue = "keyValue"
+)
+
 // DocumentContent object which represents ssm document content.
 type DocumentContent struct {
 	SchemaVersion string                   `json:"schemaVersion" yaml:"schemaVersion"`
 	Description   string                   `json:"description" yaml:"description"`
 	RuntimeConfig map[string]*PluginConfig `json:"runtimeConfig" yaml:"runtimeConfig"`
 	MainSteps     []*InstancePluginConfig  `json:"mainSteps" yaml:"mainSteps"`
+	FinallySteps  []*InstancePluginConfig  `json:"finallySteps,omitempty" yaml:"finallySteps,omitempty"` // run after mainSteps, whatever their result
 	Parameters    map[string]*Parameter    `json:"parameters" yaml:"parameters"`
 
+	// DryRun is only honored for documents submitted locally, the agent writes
+	// an execution plan to the completed folder instead of running the document
+	DryRun bool `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+
 	// InvokedPlugin field is set when document is invoked from any other plugin.
 	// Currently, InvokedPlugin is set only in runDocument Plugin
 	InvokedPlugin string
@@ -277,17 +326,20 @@ type AgentInfo struct {
 
 // PluginRuntimeStatus represents plugin runtime status section in agent response
 type PluginRuntimeStatus struct {
-	Status             ResultStatus `json:"status"`
-	Code               int          `json:"code"`
-	Name               string       `json:"name"`
-	Output             string       `json:"output"`
-	StartDateTime      string       `json:"startDateTime"`
-	EndDateTime        string       `json:"endDateTime"`
-	OutputS3BucketName string       `json:"outputS3BucketName"`
-	OutputS3KeyPrefix  string       `json:"outputS3KeyPrefix"`
-	StepName           string       `json:"stepName"`
-	StandardOutput     string       `json:"standardOutput"`
-	StandardError      string       `json:"standardError"`
+	Status             ResultStatus      `json:"status"`
+	Code               int               `json:"code"`
+	Name               string            `json:"name"`
+	Output             string            `json:"output"`
+	StartDateTime      string            `json:"startDateTime"`
+	EndDateTime        string            `json:"endDateTime"`
+	OutputS3BucketName string            `json:"outputS3BucketName"`
+	OutputS3KeyPrefix  string            `json:"outputS3KeyPrefix"`
+	StepName           string            `json:"stepName"`
+	StandardOutput     string            `json:"standardOutput"`
+	StandardError      string            `json:"standardError"`
+	StepOutputs        map[string]string `json:"stepOutputs,omitempty"`
+	// StructuredOutput is the output parsed from the outputFormat of the step
+	StructuredOutput map[string]interface{} `json:"structuredOutput,omitempty"`
 }
 
 // AgentConfiguration is a struct that stores information about the agent and instance
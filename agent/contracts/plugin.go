@@ -28,19 +28,30 @@ const (
 
 // PluginResult represents a plugin execution result.
 type PluginResult struct {
-	PluginID           string       `json:"pluginID"`
-	PluginName         string       `json:"pluginName"`
-	Status             ResultStatus `json:"status"`
-	Code               int          `json:"code"`
-	Output             interface{}  `json:"output"`
-	StartDateTime      time.Time    `json:"startDateTime"`
-	EndDateTime        time.Time    `json:"endDateTime"`
-	OutputS3BucketName string       `json:"outputS3BucketName"`
-	OutputS3KeyPrefix  string       `json:"outputS3KeyPrefix"`
-	StepName           string       `json:"stepName"`
-	Error              string       `json:"error"`
-	StandardOutput     string       `json:"standardOutput"`
-	StandardError      string       `json:"standardError"`
+	PluginID           string            `json:"pluginID"`
+	PluginName         string            `json:"pluginName"`
+	Status             ResultStatus      `json:"status"`
+	Code               int               `json:"code"`
+	Output             interface{}       `json:"output"`
+	StartDateTime      time.Time         `json:"startDateTime"`
+	EndDateTime        time.Time         `json:"endDateTime"`
+	OutputS3BucketName string            `json:"outputS3BucketName"`
+	OutputS3KeyPrefix  string            `json:"outputS3KeyPrefix"`
+	StepName           string            `json:"stepName"`
+	Error              string            `json:"error"`
+	StandardOutput     string            `json:"standardOutput"`
+	StandardError      string            `json:"standardError"`
+	StepOutputs        map[string]string `json:"stepOutputs,omitempty"`
+}
+
+// PlannedStep describes what running a step would do. Dry runs report planned steps instead of plugin results.
+type PlannedStep struct {
+	StepName      string      `json:"stepName"`
+	Action        string      `json:"action"`
+	Operation     string      `json:"operation"` // execute, skip or fail
+	Reason        string      `json:"reason,omitempty"`
+	ParallelGroup string      `json:"parallelGroup,omitempty"`
+	Inputs        interface{} `json:"inputs,omitempty"`
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -78,6 +89,8 @@ type IWorkerPlugin IPlugin
 type PreconditionArgument struct {
 	InitialArgumentValue  string
 	ResolvedArgumentValue string
+	// Precondition is set instead of the argument values for the arguments of the and, or and not operators
+	Precondition map[string][]PreconditionArgument `json:",omitempty"`
 }
 
 // Configuration represents a plugin configuration as in the json format.
@@ -98,6 +111,11 @@ type Configuration struct {
 	DefaultWorkingDirectory     string
 	Preconditions               map[string][]PreconditionArgument
 	IsPreconditionEnabled       bool
+	MaxAttempts                 int
+	TimeoutSeconds              int
+	Outputs                     []StepOutput
+	RunAlways                   bool
+	IsResumed                   bool // the step was running when the document worker stopped unexpectedly
 	CurrentAssociations         []string
 	SessionId                   string
 	ClientId                    string
@@ -0,0 +1,349 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+)
+
+const (
+	// agentCgroupName is the leaf cgroup the processes of the agent are moved to,
+	// the delegated cgroup of the agent service can only enable controllers for its children once it holds no process
+	agentCgroupName = "agent"
+	// commandCgroupParent is the cgroup next to the agent under which a transient cgroup is created for each command with resource limits
+	commandCgroupParent = "commands"
+
+	// minCgroupFDKernelVersion is the first kernel whose clone3 can start a process directly in a cgroup
+	minCgroupFDKernelVersion = "5.7"
+
+	cgroupRemoveAttempts = 10
+	cgroupRemoveInterval = 100 * time.Millisecond
+	cgroupMoveAttempts   = 3
+)
+
+var cgroupMountPoint = "/sys/fs/cgroup"
+var procSelfCgroupFile = "/proc/self/cgroup"
+var kernelReleaseFile = "/proc/sys/kernel/osrelease"
+
+// delegateXattrs mark the cgroups systemd delegates to a service with Delegate=yes
+var delegateXattrs = []string{"trusted.delegate", "user.delegate"}
+
+// isDelegated checks if systemd delegated the cgroup
+var isDelegated = func(path string) bool {
+	value := make([]byte, 1)
+	for _, name := range delegateXattrs {
+		if size, err := syscall.Getxattr(path, name, value); err == nil && size == 1 && value[0] == '1' {
+			return true
+		}
+	}
+	return false
+}
+
+// commandCgroup is the transient cgroup v2 of a command with resource limits
+type commandCgroup struct {
+	path            string
+	dir             *os.File
+	startedInCgroup bool
+}
+
+// createCommandCgroup creates a cgroup enforcing the limits.
+// The cgroup is created in the subtree systemd delegates to the agent service with Delegate=yes,
+// the cgroups outside of it are managed by systemd and are never modified.
+func createCommandCgroup(limits ResourceLimits) (cgroup *commandCgroup, err error) {
+	if _, err = os.Stat(filepath.Join(cgroupMountPoint, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 is not mounted at %s", cgroupMountPoint)
+	}
+	delegated, err := getDelegatedCgroup()
+	if err != nil {
+		return nil, err
+	}
+	controllers := limits.controllers()
+	if err = enableDelegatedControllers(delegated, controllers); err != nil {
+		return nil, err
+	}
+	parent := filepath.Join(delegated, commandCgroupParent)
+	if err = os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+	if err = enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	path, err := os.MkdirTemp(parent, "command-")
+	if err != nil {
+		return nil, err
+	}
+	cgroup = &commandCgroup{path: path}
+	defer func() {
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	for file, value := range limits.cgroupFiles() {
+		if err = os.WriteFile(filepath.Join(path, file), []byte(value), 0644); err != nil {
+			return nil, fmt.Errorf("failed to set %s of cgroup %s: %v", file, path, err)
+		}
+	}
+	if cgroup.dir, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	return cgroup, nil
+}
+
+// getDelegatedCgroup returns the cgroup systemd delegates to the agent service
+func getDelegatedCgroup() (string, error) {
+	content, err := os.ReadFile(procSelfCgroupFile)
+	if err != nil {
+		return "", err
+	}
+	var path string
+	for _, line := range strings.Split(string(content), "\n") {
+		// the cgroup v2 hierarchy has the id 0 and no controller list
+		if strings.HasPrefix(line, "0::") {
+			path = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if path == "" {
+		return "", errors.New("the agent does not run in a cgroup v2")
+	}
+	if filepath.Base(path) == agentCgroupName {
+		path = filepath.Dir(path)
+	}
+	path = filepath.Join(cgroupMountPoint, path)
+	if path == cgroupMountPoint || !isDelegated(path) {
+		return "", fmt.Errorf("cgroup %s of the agent is not delegated, resource limits require Delegate=yes in the agent service", path)
+	}
+	return path, nil
+}
+
+// enableDelegatedControllers moves the processes of the agent to their leaf cgroup and enables the controllers in the delegated cgroup.
+// Processes forked in the delegated cgroup in the meantime make enabling the controllers fail, they are moved again.
+func enableDelegatedControllers(delegated string, controllers []string) (err error) {
+	for attempt := 0; attempt < cgroupMoveAttempts; attempt++ {
+		if err = moveAgentProcesses(delegated); err != nil {
+			return err
+		}
+		if err = enableControllers(delegated, controllers); !errors.Is(err, syscall.EBUSY) {
+			return err
+		}
+	}
+	return err
+}
+
+// moveAgentProcesses moves the processes of the delegated cgroup to the leaf cgroup of the agent
+func moveAgentProcesses(delegated string) error {
+	leaf := filepath.Join(delegated, agentCgroupName)
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filepath.Join(delegated, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	for _, pid := range strings.Fields(string(content)) {
+		// processes that exited in the meantime cannot be moved
+		if err = os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("failed to move process %s to cgroup %s: %v", pid, leaf, err)
+		}
+	}
+	return nil
+}
+
+// supportsCgroupFD checks if the kernel can start a process directly in a cgroup
+var supportsCgroupFD = func() bool {
+	release, err := os.ReadFile(kernelReleaseFile)
+	if err != nil {
+		return false
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(release)), ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	comp, err := versionutil.VersionCompare(parts[0]+"."+parts[1], minCgroupFDKernelVersion)
+	return err == nil && comp >= 0
+}
+
+// addToCommand starts the command directly in the cgroup, so that no descendant can escape the limits.
+// Older kernels cannot, addStartedProcess moves the command to the cgroup once started instead.
+func (cgroup *commandCgroup) addToCommand(command *exec.Cmd) {
+	if !supportsCgroupFD() {
+		return
+	}
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.UseCgroupFD = true
+	command.SysProcAttr.CgroupFD = int(cgroup.dir.Fd())
+	cgroup.startedInCgroup = true
+}
+
+// addStartedProcess moves the started command to the cgroup when it could not be started in it
+func (cgroup *commandCgroup) addStartedProcess(pid int) error {
+	if cgroup.startedInCgroup {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(cgroup.path, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return fmt.Errorf("failed to move process %d to cgroup %s: %v", pid, cgroup.path, err)
+	}
+	return nil
+}
+
+// usage reads the resources used by the processes of the cgroup
+func (cgroup *commandCgroup) usage(log log.T) *ResourceUsage {
+	usage := &ResourceUsage{}
+	if content, err := os.ReadFile(filepath.Join(cgroup.path, "memory.peak")); err == nil {
+		usage.PeakMemoryBytes, _ = strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	}
+	if usageMicroseconds, err := readKeyedValue(filepath.Join(cgroup.path, "cpu.stat"), "usage_usec"); err == nil {
+		usage.CPUTime = time.Duration(usageMicroseconds) * time.Microsecond
+	} else {
+		log.Debugf("Failed to read the CPU time of cgroup %s: %v", cgroup.path, err)
+	}
+	if oomKills, err := readKeyedValue(filepath.Join(cgroup.path, "memory.events"), "oom_kill"); err == nil {
+		usage.OOMKills = int(oomKills)
+	}
+	return usage
+}
+
+// remove kills the processes left in the cgroup, e.g. daemons started by the command, and removes the cgroup
+func (cgroup *commandCgroup) remove(log log.T) {
+	if cgroup.dir != nil {
+		cgroup.dir.Close()
+	}
+	if populated, err := readKeyedValue(filepath.Join(cgroup.path, "cgroup.events"), "populated"); err == nil && populated == 1 {
+		if err = os.WriteFile(filepath.Join(cgroup.path, "cgroup.kill"), []byte("1"), 0644); err != nil {
+			log.Warnf("Failed to kill the processes left in cgroup %s: %v", cgroup.path, err)
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt < cgroupRemoveAttempts; attempt++ {
+		// the cgroup can only be removed once its processes have exited
+		if err = syscall.Rmdir(cgroup.path); err == nil || errors.Is(err, syscall.ENOENT) {
+			return
+		}
+		time.Sleep(cgroupRemoveInterval)
+	}
+	log.Warnf("Failed to remove cgroup %s: %v", cgroup.path, err)
+}
+
+// controllers returns the cgroup controllers enforcing the limits
+func (limits ResourceLimits) controllers() []string {
+	var controllers []string
+	if limits.CPUQuotaPercent > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if limits.IOWeight > 0 {
+		controllers = append(controllers, "io")
+	}
+	// memory is always enabled to report the memory usage and the OOM kills
+	controllers = append(controllers, "memory")
+	if limits.PidsMax > 0 {
+		controllers = append(controllers, "pids")
+	}
+	return controllers
+}
+
+// cgroupFiles returns the content of the cgroup interface files enforcing the limits
+func (limits ResourceLimits) cgroupFiles() map[string]string {
+	files := make(map[string]string)
+	if limits.CPUQuotaPercent > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", limits.CPUQuotaPercent*cpuPeriodMicroseconds/100, cpuPeriodMicroseconds)
+	}
+	if limits.MemoryMaxMB > 0 {
+		files["memory.max"] = strconv.FormatInt(int64(limits.MemoryMaxMB)*1024*1024, 10)
+	}
+	if limits.PidsMax > 0 {
+		files["pids.max"] = strconv.Itoa(limits.PidsMax)
+	}
+	if limits.IOWeight > 0 {
+		files["io.weight"] = fmt.Sprintf("default %d", limits.IOWeight)
+	}
+	return files
+}
+
+// enableControllers makes the controllers available to the children of a cgroup
+func enableControllers(path string, controllers []string) error {
+	available, err := os.ReadFile(filepath.Join(path, "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	enabled, err := os.ReadFile(filepath.Join(path, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+
+	var toEnable []string
+	for _, controller := range controllers {
+		if !containsField(string(available), controller) {
+			return fmt.Errorf("cgroup controller %s is not available in %s", controller, path)
+		}
+		if !containsField(string(enabled), controller) {
+			toEnable = append(toEnable, "+"+controller)
+		}
+	}
+	if len(toEnable) == 0 {
+		return nil
+	}
+	if err = os.WriteFile(filepath.Join(path, "cgroup.subtree_control"), []byte(strings.Join(toEnable, " ")), 0644); err != nil {
+		return fmt.Errorf("failed to enable cgroup controllers %v in %s: %w", toEnable, path, err)
+	}
+	return nil
+}
+
+func containsField(text string, field string) bool {
+	for _, value := range strings.Fields(text) {
+		if value == field {
+			return true
+		}
+	}
+	return false
+}
+
+// readKeyedValue reads the value of a key from a flat keyed cgroup file like cpu.stat
+func readKeyedValue(path string, key string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			return strconv.ParseInt(fields[1], 10, 64)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%s has no %s", path, key)
+}
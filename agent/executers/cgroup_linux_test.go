@@ -0,0 +1,149 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+package executers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeServiceCgroup = "system.slice/amazon-ssm-agent.service"
+
+// setupFakeCgroupMount fakes the interface files of a cgroup v2 hierarchy whose service cgroup of the agent is delegated
+// in a temporary directory, it returns the delegated cgroup
+func setupFakeCgroupMount(t *testing.T) string {
+	mountPoint := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(mountPoint, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0644))
+	delegated := filepath.Join(mountPoint, fakeServiceCgroup)
+	assert.NoError(t, os.MkdirAll(delegated, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(delegated, "cgroup.controllers"), []byte("cpu io memory pids\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(delegated, "cgroup.subtree_control"), []byte(""), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(delegated, "cgroup.procs"), []byte("1200\n"), 0644))
+	parent := filepath.Join(delegated, commandCgroupParent)
+	assert.NoError(t, os.Mkdir(parent, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.controllers"), []byte("cpu io memory pids\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("memory\n"), 0644))
+	cgroupFile := filepath.Join(t.TempDir(), "cgroup")
+	assert.NoError(t, os.WriteFile(cgroupFile, []byte("0::/"+fakeServiceCgroup+"\n"), 0644))
+
+	defaultMountPoint, defaultCgroupFile, defaultIsDelegated, defaultSupportsCgroupFD := cgroupMountPoint, procSelfCgroupFile, isDelegated, supportsCgroupFD
+	cgroupMountPoint = mountPoint
+	procSelfCgroupFile = cgroupFile
+	isDelegated = func(path string) bool { return path == delegated }
+	supportsCgroupFD = func() bool { return true }
+	t.Cleanup(func() {
+		cgroupMountPoint, procSelfCgroupFile, isDelegated, supportsCgroupFD = defaultMountPoint, defaultCgroupFile, defaultIsDelegated, defaultSupportsCgroupFD
+	})
+	return delegated
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestCreateCommandCgroup_WritesLimits(t *testing.T) {
+	delegated := setupFakeCgroupMount(t)
+
+	cgroup, err := createCommandCgroup(ResourceLimits{CPUQuotaPercent: 50, MemoryMaxMB: 256, PidsMax: 32, IOWeight: 200})
+	assert.NoError(t, err)
+	defer cgroup.dir.Close()
+
+	assert.Equal(t, filepath.Join(delegated, commandCgroupParent), filepath.Dir(cgroup.path))
+	assert.Equal(t, "1200", readFile(t, filepath.Join(delegated, agentCgroupName, "cgroup.procs")))
+	assert.Equal(t, "+cpu +io +memory +pids", readFile(t, filepath.Join(delegated, "cgroup.subtree_control")))
+	assert.Equal(t, "+cpu +io +pids", readFile(t, filepath.Join(delegated, commandCgroupParent, "cgroup.subtree_control")))
+	assert.Equal(t, "50000 100000", readFile(t, filepath.Join(cgroup.path, "cpu.max")))
+	assert.Equal(t, "268435456", readFile(t, filepath.Join(cgroup.path, "memory.max")))
+	assert.Equal(t, "32", readFile(t, filepath.Join(cgroup.path, "pids.max")))
+	assert.Equal(t, "default 200", readFile(t, filepath.Join(cgroup.path, "io.weight")))
+
+	command := exec.Command("true")
+	cgroup.addToCommand(command)
+	assert.True(t, command.SysProcAttr.UseCgroupFD)
+	assert.Equal(t, int(cgroup.dir.Fd()), command.SysProcAttr.CgroupFD)
+	assert.NoError(t, cgroup.addStartedProcess(1300))
+	_, err = os.Stat(filepath.Join(cgroup.path, "cgroup.procs"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCreateCommandCgroup_FromAgentCgroup(t *testing.T) {
+	delegated := setupFakeCgroupMount(t)
+	assert.NoError(t, os.WriteFile(procSelfCgroupFile, []byte("0::/"+fakeServiceCgroup+"/"+agentCgroupName+"\n"), 0644))
+
+	cgroup, err := createCommandCgroup(ResourceLimits{PidsMax: 32})
+	assert.NoError(t, err)
+	defer cgroup.dir.Close()
+
+	assert.Equal(t, filepath.Join(delegated, commandCgroupParent), filepath.Dir(cgroup.path))
+}
+
+func TestCreateCommandCgroup_NotDelegated(t *testing.T) {
+	delegated := setupFakeCgroupMount(t)
+	isDelegated = func(path string) bool { return false }
+
+	_, err := createCommandCgroup(ResourceLimits{PidsMax: 32})
+	assert.EqualError(t, err, "cgroup "+delegated+" of the agent is not delegated, resource limits require Delegate=yes in the agent service")
+}
+
+func TestCommandCgroupWithoutCgroupFD(t *testing.T) {
+	setupFakeCgroupMount(t)
+	supportsCgroupFD = func() bool { return false }
+
+	cgroup, err := createCommandCgroup(ResourceLimits{PidsMax: 32})
+	assert.NoError(t, err)
+	defer cgroup.dir.Close()
+
+	command := exec.Command("true")
+	cgroup.addToCommand(command)
+	assert.Nil(t, command.SysProcAttr)
+	assert.NoError(t, cgroup.addStartedProcess(1300))
+	assert.Equal(t, "1300", readFile(t, filepath.Join(cgroup.path, "cgroup.procs")))
+}
+
+func TestCreateCommandCgroup_ControllerNotAvailable(t *testing.T) {
+	delegated := setupFakeCgroupMount(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(delegated, "cgroup.controllers"), []byte("memory\n"), 0644))
+
+	_, err := createCommandCgroup(ResourceLimits{PidsMax: 32})
+	assert.EqualError(t, err, "cgroup controller pids is not available in "+delegated)
+}
+
+func TestCreateCommandCgroup_CgroupV2NotMounted(t *testing.T) {
+	setupFakeCgroupMount(t)
+	assert.NoError(t, os.Remove(filepath.Join(cgroupMountPoint, "cgroup.controllers")))
+
+	_, err := createCommandCgroup(ResourceLimits{PidsMax: 32})
+	assert.EqualError(t, err, "cgroup v2 is not mounted at "+cgroupMountPoint)
+}
+
+func TestCommandCgroupUsage(t *testing.T) {
+	path := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(path, "memory.peak"), []byte("10485760\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(path, "cpu.stat"), []byte("usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(path, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644))
+
+	usage := (&commandCgroup{path: path}).usage(log.NewMockLog())
+	assert.Equal(t, ResourceUsage{PeakMemoryBytes: 10485760, CPUTime: 2500 * time.Millisecond, OOMKills: 1}, *usage)
+}
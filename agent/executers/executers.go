@@ -48,6 +48,7 @@ type T interface {
 	//TODO: Remove Execute and rename NewExecute to Execute.
 	Execute(context.T, string, string, string, task.CancelFlag, int, string, []string, map[string]string) (io.Reader, io.Reader, int, []error)
 	NewExecute(context.T, string, io.Writer, io.Writer, task.CancelFlag, int, string, []string, map[string]string) (int, error)
+	NewExecuteWithOptions(context.T, string, io.Writer, io.Writer, task.CancelFlag, int, string, []string, map[string]string, ExecuteOptions) (int, *ResourceUsage, error)
 	StartExe(context.T, string, io.Writer, io.Writer, task.CancelFlag, string, []string) (*os.Process, int, error)
 }
 
@@ -180,6 +181,23 @@ func (ShellCommandExecuter) NewExecute(
 	return
 }
 
+// NewExecuteWithOptions executes a list of shell commands like NewExecute with the given options.
+// The usage of resources is returned when resource limits are set.
+func (ShellCommandExecuter) NewExecuteWithOptions(
+	context context.T,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	cancelFlag task.CancelFlag,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	envVars map[string]string,
+	options ExecuteOptions,
+) (exitCode int, usage *ResourceUsage, err error) {
+	return ExecuteCommandWithOptions(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars, options)
+}
+
 // StartExe starts a list of shell commands in the given working directory.
 // Returns process started, an exit code (0 if successfully launch, 1 if error launching process), and a set of errors.
 // The errors need not be fatal - the output streams may still have data
@@ -263,8 +281,36 @@ func ExecuteCommand(
 	commandArguments []string,
 	envVars map[string]string,
 ) (exitCode int, err error) {
+	exitCode, _, err = ExecuteCommandWithOptions(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars, ExecuteOptions{})
+	return
+}
+
+// ExecuteCommandWithOptions executes the given commands like ExecuteCommand with the given options.
+// When resource limits are set the command runs in a transient cgroup, whose resource usage is returned.
+// When a sandbox is set the command runs in new namespaces, see SandboxOptions.
+func ExecuteCommandWithOptions(
+	context context.T,
+	cancelFlag task.CancelFlag,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	envVars map[string]string,
+	options ExecuteOptions,
+) (exitCode int, usage *ResourceUsage, err error) {
 	log := context.Log()
 
+	var cgroup *commandCgroup
+	if options.ResourceLimits.IsSet() {
+		if cgroup, err = createCommandCgroup(options.ResourceLimits); err != nil {
+			log.Errorf("failed to apply resource limits: %v", err)
+			return 1, nil, fmt.Errorf("failed to apply resource limits: %v", err)
+		}
+		defer cgroup.remove(log)
+	}
+
 	stdoutInterruptable, stopStdout := newWriter(stdoutWriter)
 	stderrInterruptable, stopStderr := newWriter(stderrWriter)
 
@@ -291,9 +337,29 @@ func ExecuteCommand(
 
 	// configure OS-specific process settings
 	prepareProcess(command)
+	if cgroup != nil {
+		cgroup.addToCommand(command)
+	}
+	command.ExtraFiles = options.ExtraFiles
+	if options.RunAs != nil {
+		options.RunAs.setCredentials(command)
+		envVars = options.RunAs.environment(envVars)
+		log.Debugf("Running command as user %v", options.RunAs.UserName)
+	}
+	if options.Sandbox != nil {
+		if err = options.Sandbox.apply(command, options.RunAs); err != nil {
+			log.Errorf("failed to set up the sandbox: %v", err)
+			return 1, nil, fmt.Errorf("failed to set up the sandbox: %v", err)
+		}
+		log.Debugf("Running command in a sandbox %+v", *options.Sandbox)
+	}
 
-	// configure environment variables
-	prepareEnvironment(context, command, envVars)
+	// configure environment variables, commands running as another user do not inherit the environment of the agent
+	baseEnv := os.Environ()
+	if options.RunAs != nil {
+		baseEnv = nil
+	}
+	prepareEnvironment(context, command, baseEnv, envVars)
 
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
 
@@ -303,8 +369,21 @@ func ExecuteCommand(
 		exitCode = 1
 		return
 	}
+	if cgroup != nil {
+		if err = cgroup.addStartedProcess(command.Process.Pid); err != nil {
+			log.Errorf("failed to apply resource limits: %v", err)
+			command.Process.Kill()
+			command.Wait()
+			return 1, nil, fmt.Errorf("failed to apply resource limits: %v", err)
+		}
+		defer func() {
+			usage = cgroup.usage(log)
+		}()
+	}
 
 	signal := timeoutSignal{}
+	gracePeriod := terminationGracePeriod(context)
+	killedGroup := 0
 
 	cancelled := make(chan bool, 1)
 	go func() {
@@ -325,7 +404,8 @@ func ExecuteCommand(
 	case <-time.After(time.Duration(executionTimeout) * time.Second):
 		stopStdout <- true
 		stopStderr <- true
-		if err = killProcess(command.Process, &signal); err != nil {
+		killedGroup = command.Process.Pid
+		if err = killProcess(log, command.Process, gracePeriod, &signal); err != nil {
 			exitCode = 1
 			log.Error(err)
 		} else {
@@ -339,7 +419,8 @@ func ExecuteCommand(
 		log.Debug("Process cancelled. Attempting to stop process.")
 		stopStdout <- true
 		stopStderr <- true
-		if err = killProcess(command.Process, &signal); err != nil {
+		killedGroup = command.Process.Pid
+		if err = killProcess(log, command.Process, gracePeriod, &signal); err != nil {
 			exitCode = 1
 			log.Error(err)
 		} else {
@@ -386,6 +467,11 @@ func ExecuteCommand(
 			}
 		}
 	}
+
+	// the command runs in its own session, processes left in it after a cancel or timeout escaped the killed process group
+	if killedGroup != 0 {
+		reportOrphanProcesses(log, stderrWriter, findOrphanProcesses(command.Process.Pid, killedGroup))
+	}
 	return
 }
 
@@ -410,7 +496,7 @@ func StartCommand(context context.T,
 	prepareProcess(command)
 
 	// configure environment variables
-	prepareEnvironment(context, command, make(map[string]string))
+	prepareEnvironment(context, command, os.Environ(), make(map[string]string))
 
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
 
@@ -427,16 +513,21 @@ func StartCommand(context context.T,
 	// the writer when it is a file handle and when the cancellable writer is assigned, it doesn't (by design) give
 	// a reference to the file handle to the process
 	cancelChannel := make(chan bool, 2)
-	go killProcessOnCancel(log, command, cancelChannel, cancelChannel, cancelFlag, &signal)
+	go killProcessOnCancel(log, command, cancelChannel, cancelChannel, cancelFlag, terminationGracePeriod(context), &signal)
 
 	return
 }
 
+// terminationGracePeriod returns how long the processes of a cancelled or timed out command get to exit after SIGTERM
+func terminationGracePeriod(context context.T) time.Duration {
+	return time.Duration(context.AppConfig().Ssm.CommandTerminationGracePeriodSeconds) * time.Second
+}
+
 // killProcessOnCancel waits for a cancel request.
-// If a cancel request is received, this method kills the underlying
-// process of the command. This will unblock the command.Wait() call.
+// If a cancel request is received, this method terminates the process group
+// of the command, killing it after the grace period. This will unblock the command.Wait() call.
 // If the task completed successfully this method returns with no action.
-func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, cancelStderr chan bool, cancelFlag task.CancelFlag, signal *timeoutSignal) {
+func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, cancelStderr chan bool, cancelFlag task.CancelFlag, gracePeriod time.Duration, signal *timeoutSignal) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Errorf("Kill process on cancel panic: %v", r)
@@ -452,7 +543,7 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 		runtime.Gosched()
 
 		// task has been asked to cancel, kill process
-		if err := killProcess(command.Process, signal); err != nil {
+		if err := killProcess(log, command.Process, gracePeriod, signal); err != nil {
 			log.Error(err)
 		} else {
 			log.Debug("Process stopped successfully.")
@@ -461,10 +552,11 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 	}
 }
 
-// prepareEnvironment adds ssm agent standard environment variables or environment variables defined by customer/other plugins to the command
-func prepareEnvironment(context context.T, command *exec.Cmd, envVars map[string]string) {
+// prepareEnvironment adds ssm agent standard environment variables or environment variables defined by customer/other plugins
+// to the base environment of the command
+func prepareEnvironment(context context.T, command *exec.Cmd, baseEnv []string, envVars map[string]string) {
 	log := context.Log()
-	env := os.Environ()
+	env := append([]string{}, baseEnv...)
 
 	for key, val := range envVars {
 		env = append(env, fmtEnvVariable(key, val))
@@ -184,6 +184,7 @@ func getTestContext() context.T {
 	contextMock := &contextmocks.Mock{}
 	contextMock.On("Identity").Return(identityMock)
 	contextMock.On("Log").Return(logger)
+	contextMock.On("AppConfig").Return(appconfig.SsmagentConfig{})
 
 	return contextMock
 }
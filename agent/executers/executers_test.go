@@ -57,7 +57,7 @@ func TestEnvironmentVariables_All(t *testing.T) {
 	command := getTestCommand()
 	env := make(map[string]string)
 	env["envKey"] = "envVal"
-	prepareEnvironment(context, command, env)
+	prepareEnvironment(context, command, os.Environ(), env)
 
 	assert.Equal(t, mockIdentity.MockInstanceID, getEnvVariableValue(command.Env, envVarInstanceID))
 	assert.Equal(t, mockIdentity.MockRegion, getEnvVariableValue(command.Env, envVarRegionName))
@@ -69,7 +69,7 @@ func TestEnvironmentVariables_None(t *testing.T) {
 	context := context.NewMockDefault()
 
 	command := getTestCommand()
-	prepareEnvironment(context, command, make(map[string]string))
+	prepareEnvironment(context, command, os.Environ(), make(map[string]string))
 
 	assert.Empty(t, getEnvVariableValue(command.Env, mockIdentity.MockInstanceID))
 	assert.Empty(t, getEnvVariableValue(command.Env, mockIdentity.MockRegion))
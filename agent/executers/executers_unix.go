@@ -22,14 +22,19 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
+// processGroupPollInterval is how often a terminated process group is checked for exit during the grace period
+const processGroupPollInterval = 50 * time.Millisecond
+
 func prepareProcess(command *exec.Cmd) {
-	// make the process the leader of its process group
-	// (otherwise we cannot kill it properly)
-	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// make the process the leader of a new session and of its process group
+	// (otherwise we cannot kill it properly, nor find the descendants that leave the group)
+	command.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 }
 
 func quiesce() {
@@ -39,19 +44,48 @@ func quiesce() {
 	syscall.Sync() // workaround for https://github.com/golang/go/issues/33565
 }
 
-func killProcess(process *os.Process, signal *timeoutSignal) error {
+func killProcess(log log.T, process *os.Process, gracePeriod time.Duration, signal *timeoutSignal) error {
 	//   NOTE: go only kills the process but not its sub processes.
 	//   The consequence is that command.Wait() does not return, for some reason.
 	//   As a workaround we use some (platform specific) magic:
 	//     syscall.Kill(-pid, syscall.SIGKILL)
-	//   Here '-pid' means that the KILL signal is sent to all processes
+	//   Here '-pid' means that the signal is sent to all processes
 	//   in the process group whose id is 'pid'. 'prepareProcess' makes
 	//   the shell we spawn the leader of its own process group and so
 	//   the kill here not just kills the shell but all its descendant
 	//   processes. [See manpage for kill(2)]
+	//   The processes first get SIGTERM and the grace period to exit on their own.
+	if gracePeriod > 0 {
+		err := syscall.Kill(-process.Pid, syscall.SIGTERM)
+		switch {
+		case err == syscall.ESRCH:
+			return nil
+		case err != nil:
+			log.Warnf("failed to send SIGTERM to process group %v: %v", process.Pid, err)
+		case waitForProcessGroupExit(process.Pid, gracePeriod):
+			log.Debugf("process group %v exited after SIGTERM", process.Pid)
+			return nil
+		default:
+			log.Infof("process group %v is still running %v after SIGTERM, sending SIGKILL", process.Pid, gracePeriod)
+		}
+	}
 	return syscall.Kill(-process.Pid, syscall.SIGKILL) // note the minus sign
 }
 
+// waitForProcessGroupExit returns true if all processes of the process group exit within the timeout
+func waitForProcessGroupExit(processGroupID int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := syscall.Kill(-processGroupID, 0); err == syscall.ESRCH || !processGroupHasRunningProcesses(processGroupID) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(processGroupPollInterval)
+	}
+}
+
 // Running powershell on linux erquired the HOME env variable to be set and to remove the TERM env variable
 func validateEnvironmentVariables(command *exec.Cmd) {
 
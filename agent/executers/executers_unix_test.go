@@ -0,0 +1,84 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package executers
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// startTestProcessGroup starts the script as the leader of its own process group and waits until it is ready
+func startTestProcessGroup(t *testing.T, script string) *exec.Cmd {
+	command := exec.Command("/bin/sh", "-c", script+"; echo ready; while :; do sleep 0.1; done")
+	prepareProcess(command)
+	stdout, err := command.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, command.Start())
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ready\n", line)
+	return command
+}
+
+func TestKillProcess_ProcessGroupExitsOnSIGTERM(t *testing.T) {
+	command := startTestProcessGroup(t, "trap 'exit 3' TERM")
+
+	start := time.Now()
+	assert.NoError(t, killProcess(log.NewMockLog(), command.Process, 5*time.Second, &timeoutSignal{}))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	err := command.Wait()
+	exitErr, ok := err.(*exec.ExitError)
+	assert.True(t, ok)
+	assert.Equal(t, 3, exitErr.ExitCode())
+}
+
+func TestKillProcess_KillsProcessGroupAfterGracePeriod(t *testing.T) {
+	// the ignored signal is inherited by the processes the shell starts
+	command := startTestProcessGroup(t, "trap '' TERM")
+
+	assert.NoError(t, killProcess(log.NewMockLog(), command.Process, 200*time.Millisecond, &timeoutSignal{}))
+
+	err := command.Wait()
+	exitErr, ok := err.(*exec.ExitError)
+	assert.True(t, ok)
+	assert.Equal(t, syscall.SIGKILL, exitErr.Sys().(syscall.WaitStatus).Signal())
+	assert.True(t, waitForProcessGroupExit(command.Process.Pid, time.Second))
+}
+
+func TestExecuteCommandWithOptions_TimeoutTerminatesProcessGroup(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	var stdout, stderr bytes.Buffer
+
+	start := time.Now()
+	exitCode, _, err := ExecuteCommandWithOptions(context.NewMockDefaultWithConfig(config), task.NewChanneledCancelFlag(), t.TempDir(), &stdout, &stderr, 1,
+		"/bin/sh", []string{"-c", "trap 'exit 0' TERM; while :; do sleep 0.1; done"}, nil, ExecuteOptions{})
+
+	assert.Error(t, err)
+	assert.Equal(t, appconfig.CommandStoppedPreemptivelyExitCode, exitCode)
+	assert.Less(t, time.Since(start), time.Duration(config.Ssm.CommandTerminationGracePeriodSeconds+1)*time.Second)
+}
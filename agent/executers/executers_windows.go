@@ -19,6 +19,9 @@ package executers
 import (
 	"os"
 	"os/exec"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
 const (
@@ -33,7 +36,8 @@ func quiesce() {
 	// not needed for Darwin workaround
 }
 
-func killProcess(process *os.Process, signal *timeoutSignal) error {
+func killProcess(log log.T, process *os.Process, gracePeriod time.Duration, signal *timeoutSignal) error {
+	// windows has no process groups to terminate gracefully, so the grace period is not used
 	// process kill doesn't send proper signal to the process status
 	// Setting the signal to indicate execution was interrupted
 	signal.execInterruptedOnWindows = true
@@ -0,0 +1,43 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// orphanProcess is a descendant of a command that is still running after the command ended
+type orphanProcess struct {
+	pid         int
+	commandLine string
+}
+
+// reportOrphanProcesses writes the descendants of a cancelled or timed out command that survived it to the output of the command
+func reportOrphanProcesses(log log.T, writer io.Writer, orphans []orphanProcess) {
+	if len(orphans) == 0 {
+		return
+	}
+	report := "\nOrphaned processes of the command are still running:\n"
+	for _, orphan := range orphans {
+		report += fmt.Sprintf("  pid %d: %v\n", orphan.pid, orphan.commandLine)
+	}
+	log.Warn(report)
+	if writer != nil {
+		writer.Write([]byte(report))
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procDir is where the proc file system is mounted
+var procDir = "/proc"
+
+// findOrphanProcesses returns the processes still running in the session of a command after the command ended.
+// Members of killedGroup are left out as they were already sent SIGKILL.
+func findOrphanProcesses(sessionID int, killedGroup int) (orphans []orphanProcess) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		state, processGroup, session, ok := readProcessStat(pid)
+		if !ok || session != sessionID || state == "Z" || (killedGroup != 0 && processGroup == killedGroup) {
+			continue
+		}
+		orphans = append(orphans, orphanProcess{pid: pid, commandLine: readCommandLine(pid)})
+	}
+	return orphans
+}
+
+// processGroupHasRunningProcesses returns true if a process of the process group is still running,
+// zombies are left out as they wait for a parent that may never reap them
+func processGroupHasRunningProcesses(processGroupID int) bool {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return true
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if state, processGroup, _, ok := readProcessStat(pid); ok && processGroup == processGroupID && state != "Z" {
+			return true
+		}
+	}
+	return false
+}
+
+// readProcessStat reads the state, process group and session of a process from its stat file
+func readProcessStat(pid int) (state string, processGroup int, session int, ok bool) {
+	content, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", 0, 0, false
+	}
+	// the command name in parentheses may contain spaces, the fields after it are
+	// state, parent pid, process group and session
+	stat := string(content)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 4 {
+		return "", 0, 0, false
+	}
+	if processGroup, err = strconv.Atoi(fields[2]); err != nil {
+		return "", 0, 0, false
+	}
+	if session, err = strconv.Atoi(fields[3]); err != nil {
+		return "", 0, 0, false
+	}
+	return fields[0], processGroup, session, true
+}
+
+// readCommandLine returns the command line of a process, or its name if the command line is not available
+func readCommandLine(pid int) string {
+	content, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "cmdline"))
+	if commandLine := strings.TrimSpace(strings.ReplaceAll(string(content), "\x00", " ")); err == nil && commandLine != "" {
+		return commandLine
+	}
+	content, _ = os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "comm"))
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+package executers
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestProcFile(t *testing.T, pid int, name string, content string) {
+	filePath := filepath.Join(procDir, strconv.Itoa(pid), name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+	assert.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+}
+
+func TestFindOrphanProcesses(t *testing.T) {
+	defer func(dir string) { procDir = dir }(procDir)
+	procDir = t.TempDir()
+
+	writeTestProcFile(t, 200, "stat", "200 (my (odd) cmd) S 1 200 100 0 -1")
+	writeTestProcFile(t, 200, "cmdline", "/usr/bin/daemon\x00--port\x008080\x00")
+	writeTestProcFile(t, 201, "stat", "201 (worker) S 1 201 100 0 -1")
+	writeTestProcFile(t, 201, "comm", "worker\n")
+	writeTestProcFile(t, 202, "stat", "202 (sleep) S 1 100 100 0 -1")
+	writeTestProcFile(t, 203, "stat", "203 (defunct) Z 1 203 100 0 -1")
+	writeTestProcFile(t, 204, "stat", "204 (other) S 1 204 300 0 -1")
+
+	assert.Equal(t, []orphanProcess{
+		{pid: 200, commandLine: "/usr/bin/daemon --port 8080"},
+		{pid: 201, commandLine: "worker"},
+	}, findOrphanProcesses(100, 100))
+	assert.Len(t, findOrphanProcesses(100, 0), 3)
+}
+
+func TestExecuteCommandWithOptions_ReportsOrphanProcessesOnTimeout(t *testing.T) {
+	if _, err := exec.LookPath("perl"); err != nil {
+		t.Skip("perl is required to start a process in its own process group")
+	}
+
+	// the background process moves to its own process group which the timeout does not kill
+	var stdout, stderr bytes.Buffer
+	exitCode, _, err := ExecuteCommandWithOptions(context.NewMockDefault(), task.NewChanneledCancelFlag(), t.TempDir(), &stdout, &stderr, 1,
+		"/bin/sh", []string{"-c", "perl -e 'setpgrp; exec @ARGV' sleep 30 >/dev/null 2>&1 & echo $!; sleep 30"}, nil, ExecuteOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, appconfig.CommandStoppedPreemptivelyExitCode, exitCode)
+
+	orphanPid, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	assert.NoError(t, err)
+	defer syscall.Kill(orphanPid, syscall.SIGKILL)
+	assert.Contains(t, stderr.String(), "Orphaned processes of the command are still running")
+	assert.Contains(t, stderr.String(), "pid "+strconv.Itoa(orphanPid)+": sleep 30")
+}
+
+func TestExecuteCommandWithOptions_DoesNotReportBackgroundProcessesOfCompletedCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode, _, err := ExecuteCommandWithOptions(context.NewMockDefault(), task.NewChanneledCancelFlag(), t.TempDir(), &stdout, &stderr, 10,
+		"/bin/sh", []string{"-c", "sleep 30 >/dev/null 2>&1 & echo $!"}, nil, ExecuteOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+
+	backgroundPid, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	assert.NoError(t, err)
+	defer syscall.Kill(backgroundPid, syscall.SIGKILL)
+	assert.Empty(t, stderr.String())
+}
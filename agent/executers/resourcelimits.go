@@ -0,0 +1,108 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// cpuPeriodMicroseconds is the period of the CPU quota of a command
+	cpuPeriodMicroseconds = 100000
+	minIOWeight           = 1
+	maxIOWeight           = 10000
+)
+
+// ExecuteOptions are optional settings of the process running a command.
+type ExecuteOptions struct {
+	// ResourceLimits are enforced with a transient cgroup v2, they are only supported on linux
+	ResourceLimits ResourceLimits
+	// RunAs runs the command as another user with a login like environment, it is not supported on windows
+	RunAs *RunAsCredentials
+	// ExtraFiles are inherited by the command as file descriptors 3 onwards
+	ExtraFiles []*os.File
+	// Sandbox runs the command in new namespaces when set, it is only supported on linux
+	Sandbox *SandboxOptions
+}
+
+// ResourceLimits limits the resources used by a command and all its descendants, 0 means unlimited.
+type ResourceLimits struct {
+	// CPUQuotaPercent is the share of one CPU the command can use, 200 allows two CPUs
+	CPUQuotaPercent int
+	// MemoryMaxMB is the memory the command can use before it is killed
+	MemoryMaxMB int
+	// PidsMax is the number of processes and threads the command can run at the same time
+	PidsMax int
+	// IOWeight is the weight of the command for block IO from 1 to 10000, processes have a weight of 100 by default
+	IOWeight int
+}
+
+// ResourceUsage is the usage of resources reported for a command run with resource limits.
+type ResourceUsage struct {
+	// PeakMemoryBytes is 0 when the kernel does not report the peak memory usage
+	PeakMemoryBytes uint64
+	CPUTime         time.Duration
+	OOMKills        int
+}
+
+// IsSet returns whether any limit is set
+func (limits ResourceLimits) IsSet() bool {
+	return limits != ResourceLimits{}
+}
+
+// Validate checks that the limits are in range
+func (limits ResourceLimits) Validate() error {
+	if limits.CPUQuotaPercent < 0 {
+		return fmt.Errorf("cpuQuotaPercent cannot be negative")
+	}
+	if limits.MemoryMaxMB < 0 {
+		return fmt.Errorf("memoryMaxMB cannot be negative")
+	}
+	if limits.PidsMax < 0 {
+		return fmt.Errorf("pidsMax cannot be negative")
+	}
+	if limits.IOWeight != 0 && (limits.IOWeight < minIOWeight || limits.IOWeight > maxIOWeight) {
+		return fmt.Errorf("ioWeight must be between %d and %d", minIOWeight, maxIOWeight)
+	}
+	return nil
+}
+
+// WithDefaults returns the limits, using the default of each limit that is not set
+func (limits ResourceLimits) WithDefaults(defaults ResourceLimits) ResourceLimits {
+	if limits.CPUQuotaPercent == 0 {
+		limits.CPUQuotaPercent = defaults.CPUQuotaPercent
+	}
+	if limits.MemoryMaxMB == 0 {
+		limits.MemoryMaxMB = defaults.MemoryMaxMB
+	}
+	if limits.PidsMax == 0 {
+		limits.PidsMax = defaults.PidsMax
+	}
+	if limits.IOWeight == 0 {
+		limits.IOWeight = defaults.IOWeight
+	}
+	return limits
+}
+
+// String formats the usage for the output of a command
+func (usage ResourceUsage) String() string {
+	peakMemory := "unknown"
+	if usage.PeakMemoryBytes > 0 {
+		peakMemory = fmt.Sprintf("%.1f MiB", float64(usage.PeakMemoryBytes)/(1024*1024))
+	}
+	return fmt.Sprintf("peak memory %s, CPU time %v, OOM kills %d", peakMemory, usage.CPUTime, usage.OOMKills)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceLimitsValidate(t *testing.T) {
+	assert.NoError(t, ResourceLimits{}.Validate())
+	assert.NoError(t, ResourceLimits{CPUQuotaPercent: 150, MemoryMaxMB: 512, PidsMax: 64, IOWeight: 10000}.Validate())
+	assert.EqualError(t, ResourceLimits{CPUQuotaPercent: -1}.Validate(), "cpuQuotaPercent cannot be negative")
+	assert.EqualError(t, ResourceLimits{MemoryMaxMB: -1}.Validate(), "memoryMaxMB cannot be negative")
+	assert.EqualError(t, ResourceLimits{PidsMax: -1}.Validate(), "pidsMax cannot be negative")
+	assert.EqualError(t, ResourceLimits{IOWeight: -5}.Validate(), "ioWeight must be between 1 and 10000")
+}
+
+func TestResourceLimitsWithDefaults(t *testing.T) {
+	defaults := ResourceLimits{CPUQuotaPercent: 100, MemoryMaxMB: 1024, PidsMax: 200, IOWeight: 50}
+	limits := ResourceLimits{MemoryMaxMB: 256}.WithDefaults(defaults)
+
+	assert.Equal(t, ResourceLimits{CPUQuotaPercent: 100, MemoryMaxMB: 256, PidsMax: 200, IOWeight: 50}, limits)
+	assert.True(t, limits.IsSet())
+	assert.False(t, ResourceLimits{}.WithDefaults(ResourceLimits{}).IsSet())
+}
+
+func TestResourceUsageString(t *testing.T) {
+	usage := ResourceUsage{PeakMemoryBytes: 3 * 1024 * 1024, CPUTime: 1500 * time.Millisecond, OOMKills: 1}
+	assert.Equal(t, "peak memory 3.0 MiB, CPU time 1.5s, OOM kills 1", usage.String())
+	assert.Equal(t, "peak memory unknown, CPU time 0s, OOM kills 0", ResourceUsage{}.String())
+}
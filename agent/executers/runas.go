@@ -0,0 +1,50 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+const (
+	defaultUserPath = "/usr/local/bin:/usr/bin:/bin"
+	defaultRootPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// RunAsCredentials is the user and group a command runs as instead of the user of the agent.
+type RunAsCredentials struct {
+	UserName string
+	Uid      uint32
+	// Gid is the primary group of the user or the group requested for the command
+	Gid uint32
+	// Groups are the supplementary groups of the user
+	Groups  []uint32
+	HomeDir string
+}
+
+// environment returns the environment variables of the command, a login like environment of the user
+// overridden by the environment variables of the command.
+func (credentials *RunAsCredentials) environment(envVars map[string]string) map[string]string {
+	path := defaultUserPath
+	if credentials.Uid == 0 {
+		path = defaultRootPath
+	}
+	env := map[string]string{
+		"HOME":    credentials.HomeDir,
+		"USER":    credentials.UserName,
+		"LOGNAME": credentials.UserName,
+		"PATH":    path,
+	}
+	for key, val := range envVars {
+		env[key] = val
+	}
+	return env
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// LookupRunAsCredentials returns the credentials of a user, groupName selects one of the groups of the user
+// as the group of the command instead of the primary group of the user.
+func LookupRunAsCredentials(userName string, groupName string) (*RunAsCredentials, error) {
+	runAsUser, err := user.Lookup(userName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %s: %v", userName, err)
+	}
+	uid, err := parseId(runAsUser.Uid)
+	if err != nil {
+		return nil, err
+	}
+	if uint32(os.Geteuid()) != 0 && uint32(os.Geteuid()) != uid {
+		return nil, fmt.Errorf("the agent must run as root to run commands as user %s", userName)
+	}
+	gid, err := parseId(runAsUser.Gid)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIds, err := runAsUser.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the groups of user %s: %v", userName, err)
+	}
+	groups := make([]uint32, 0, len(groupIds))
+	for _, groupId := range groupIds {
+		id, err := parseId(groupId)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, id)
+	}
+
+	if groupName != "" {
+		group, err := user.LookupGroup(groupName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find group %s: %v", groupName, err)
+		}
+		if gid, err = parseId(group.Gid); err != nil {
+			return nil, err
+		}
+		if !containsId(groups, gid) {
+			return nil, fmt.Errorf("user %s is not a member of group %s", userName, groupName)
+		}
+	}
+
+	return &RunAsCredentials{
+		UserName: runAsUser.Username,
+		Uid:      uid,
+		Gid:      gid,
+		Groups:   groups,
+		HomeDir:  runAsUser.HomeDir,
+	}, nil
+}
+
+// setCredentials makes the command run as the user
+func (credentials *RunAsCredentials) setCredentials(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    credentials.Uid,
+		Gid:    credentials.Gid,
+		Groups: credentials.Groups,
+	}
+}
+
+func parseId(id string) (uint32, error) {
+	value, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user or group id %s: %v", id, err)
+	}
+	return uint32(value), nil
+}
+
+func containsId(ids []uint32, id uint32) bool {
+	for _, value := range ids {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
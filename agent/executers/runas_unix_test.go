@@ -0,0 +1,90 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package executers
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"os/user"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupRunAsCredentials_CurrentUser(t *testing.T) {
+	currentUser, err := user.Current()
+	assert.NoError(t, err)
+	primaryGroup, err := user.LookupGroupId(currentUser.Gid)
+	assert.NoError(t, err)
+
+	credentials, err := LookupRunAsCredentials(currentUser.Username, primaryGroup.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, currentUser.Username, credentials.UserName)
+	assert.Equal(t, uint32(os.Getuid()), credentials.Uid)
+	assert.Equal(t, uint32(os.Getgid()), credentials.Gid)
+	assert.Equal(t, currentUser.HomeDir, credentials.HomeDir)
+	assert.Contains(t, credentials.Groups, credentials.Gid)
+
+	command := exec.Command("true")
+	credentials.setCredentials(command)
+	assert.Equal(t, credentials.Uid, command.SysProcAttr.Credential.Uid)
+	assert.Equal(t, credentials.Gid, command.SysProcAttr.Credential.Gid)
+	assert.Equal(t, credentials.Groups, command.SysProcAttr.Credential.Groups)
+}
+
+func TestLookupRunAsCredentials_UnknownUser(t *testing.T) {
+	_, err := LookupRunAsCredentials("ssm-agent-unknown-user", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to find user ssm-agent-unknown-user")
+}
+
+func TestRunAsCredentialsEnvironment(t *testing.T) {
+	credentials := &RunAsCredentials{UserName: "app", Uid: 1001, Gid: 1001, HomeDir: "/home/app"}
+
+	env := credentials.environment(map[string]string{"PATH": "/opt/app/bin:/usr/bin", "FOO": "bar"})
+	assert.Equal(t, map[string]string{
+		"HOME":    "/home/app",
+		"USER":    "app",
+		"LOGNAME": "app",
+		"PATH":    "/opt/app/bin:/usr/bin",
+		"FOO":     "bar",
+	}, env)
+
+	credentials.Uid = 0
+	assert.Equal(t, defaultRootPath, credentials.environment(nil)["PATH"])
+}
+
+func TestExecuteCommandWithOptions_RunAsUser(t *testing.T) {
+	currentUser, err := user.Current()
+	assert.NoError(t, err)
+	credentials, err := LookupRunAsCredentials(currentUser.Username, "")
+	assert.NoError(t, err)
+	// the environment of the agent is not inherited by the command
+	t.Setenv("AGENT_SECRET", "agent-value")
+
+	var stdout, stderr bytes.Buffer
+	exitCode, usage, err := ExecuteCommandWithOptions(context.NewMockDefault(), task.NewChanneledCancelFlag(), t.TempDir(), &stdout, &stderr, 10,
+		"/bin/sh", []string{"-c", "echo $USER $HOME $FOO $AGENT_SECRET"}, map[string]string{"FOO": "bar"}, ExecuteOptions{RunAs: credentials})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Nil(t, usage)
+	assert.Equal(t, currentUser.Username+" "+currentUser.HomeDir+" bar\n", stdout.String())
+}
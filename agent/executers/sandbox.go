@@ -0,0 +1,23 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+// SandboxOptions run a command in new user, mount and PID namespaces, where the command has no privileges
+// outside of its namespaces, sees the file systems of the host read-only, gets a private /tmp and a minimal /dev
+// and cannot see or signal the other processes of the host.
+type SandboxOptions struct {
+	// DisableNetwork runs the command in a new network namespace without network interfaces but loopback
+	DisableNetwork bool
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+// Package executers contains general purpose (shell) command executing objects.
+package executers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// sandboxShell sets up the sandbox and stays its init process while the command runs
+	sandboxShell = "/bin/sh"
+
+	// sandboxSetupScript mounts the proc file system of the new PID namespace with /proc/sys and /proc/sysrq-trigger
+	// read-only, a private /tmp and a minimal /dev with the devices of the host that commands commonly use,
+	// then remounts every other file system read-only, /sys and the cgroup file systems included.
+	// The shell stays PID 1 of the namespace, which the kernel only delivers the signals it handles to,
+	// forwards the termination signals to the command and exits with the status of the command.
+	// The command runs without capabilities, so that it cannot undo the sandbox in its namespaces.
+	// The mount table of the host is not updated, %[1]v is the quoted path of the mount command
+	// and %[2]v the one of the setpriv command.
+	sandboxSetupScript = `set -e
+%[1]v -n --make-rprivate /
+%[1]v -n -t proc -o nosuid,nodev,noexec proc /proc
+for path in /proc/sys /proc/sysrq-trigger; do
+	[ -e "$path" ] || continue
+	%[1]v -n --bind "$path" "$path"
+	%[1]v -n -o remount,bind,ro "$path"
+done
+%[1]v -n -t tmpfs -o nosuid,nodev,mode=1777 tmpfs /tmp
+dev=/tmp/.sandbox-dev
+mkdir "$dev"
+%[1]v -n -t tmpfs -o nosuid,noexec,mode=755 tmpfs "$dev"
+for device in null zero full random urandom tty; do
+	[ -e "/dev/$device" ] || continue
+	touch "$dev/$device"
+	%[1]v -n --bind "/dev/$device" "$dev/$device"
+done
+mkdir "$dev/shm"
+%[1]v -n -t tmpfs -o nosuid,nodev,noexec,mode=1777 tmpfs "$dev/shm"
+ln -s /proc/self/fd "$dev/fd"
+ln -s /proc/self/fd/0 "$dev/stdin"
+ln -s /proc/self/fd/1 "$dev/stdout"
+ln -s /proc/self/fd/2 "$dev/stderr"
+%[1]v -n --move "$dev" /dev
+%[1]v -n -o remount,bind,ro /dev
+rmdir "$dev"
+while read -r _ _ _ _ mountPoint _; do
+	case "$mountPoint" in
+	/proc | /proc/* | /dev | /dev/* | /tmp) continue ;;
+	esac
+	%[1]v -n -o remount,bind,ro "$(printf '%%b' "$mountPoint")"
+done < /proc/self/mountinfo
+set +e
+child=
+for signal in TERM INT HUP QUIT; do
+	trap "[ -n \"\$child\" ] && kill -$signal \"\$child\" 2>/dev/null" "$signal"
+done
+%[2]v --bounding-set=-all --inh-caps=-all -- "$@" <&0 &
+child=$!
+while :; do
+	wait "$child"
+	status=$?
+	kill -0 "$child" 2>/dev/null || exit "$status"
+done`
+)
+
+// sandboxCommandDirs are where the commands setting up the sandbox are looked for before the PATH,
+// which may not list them for the agent
+var sandboxCommandDirs = []string{"/usr/bin", "/bin", "/usr/sbin", "/sbin"}
+
+// findSandboxCommand returns the path of a command setting up the sandbox
+func findSandboxCommand(name string) (string, error) {
+	for _, dir := range sandboxCommandDirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("the sandbox requires the %v command: %v", name, err)
+	}
+	return path, nil
+}
+
+// apply makes the command create the namespaces of the sandbox and set it up before it runs
+func (sandbox *SandboxOptions) apply(command *exec.Cmd, runAs *RunAsCredentials) error {
+	mountPath, err := findSandboxCommand("mount")
+	if err != nil {
+		return err
+	}
+	setprivPath, err := findSandboxCommand("setpriv")
+	if err != nil {
+		return err
+	}
+
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := command.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWNS | syscall.CLONE_NEWPID
+	if sandbox.DisableNetwork {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	// the sandbox always runs as root of a user namespace, which is the user outside of it, so that it
+	// has no privileges outside of its namespaces even when the agent runs it as root
+	uid, gid := os.Geteuid(), os.Getegid()
+	if runAs != nil {
+		uid, gid = int(runAs.Uid), int(runAs.Gid)
+	}
+	attr.Cloneflags |= syscall.CLONE_NEWUSER
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}}
+	// supplementary groups cannot be set in a user namespace
+	attr.Credential = &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true}
+
+	command.Args = append([]string{sandboxShell, "-c", fmt.Sprintf(sandboxSetupScript, QuoteShString(mountPath), QuoteShString(setprivPath)), "sandbox", command.Path}, command.Args[1:]...)
+	command.Path = sandboxShell
+	return nil
+}
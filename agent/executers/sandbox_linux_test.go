@@ -0,0 +1,188 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+package executers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// skipWithoutNamespaces skips the test when the namespaces of the sandbox cannot be created
+func skipWithoutNamespaces(t *testing.T, flags uintptr) {
+	command := exec.Command("/bin/true")
+	command.SysProcAttr = &syscall.SysProcAttr{Cloneflags: flags}
+	if err := command.Run(); err != nil {
+		t.Skipf("namespaces are not available: %v", err)
+	}
+}
+
+func runInSandbox(t *testing.T, sandbox SandboxOptions, runAs *RunAsCredentials, script string) (string, string) {
+	var stdout, stderr bytes.Buffer
+	exitCode, _, err := ExecuteCommandWithOptions(context.NewMockDefault(), task.NewChanneledCancelFlag(), "/", &stdout, &stderr, 10,
+		"/bin/sh", []string{"-c", script}, nil, ExecuteOptions{Sandbox: &sandbox, RunAs: runAs})
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	return stdout.String(), stderr.String()
+}
+
+func TestSandboxApply(t *testing.T) {
+	command := exec.Command("/bin/sh", "-c", "echo hello")
+	prepareProcess(command)
+	credentials := &RunAsCredentials{UserName: "app", Uid: 1001, Gid: 1002, Groups: []uint32{1002, 27}}
+	credentials.setCredentials(command)
+
+	assert.NoError(t, (&SandboxOptions{DisableNetwork: true}).apply(command, credentials))
+
+	attr := command.SysProcAttr
+	assert.True(t, attr.Setsid)
+	assert.Equal(t, uintptr(syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWNET|syscall.CLONE_NEWUSER), attr.Cloneflags)
+	assert.Equal(t, []syscall.SysProcIDMap{{ContainerID: 0, HostID: 1001, Size: 1}}, attr.UidMappings)
+	assert.Equal(t, []syscall.SysProcIDMap{{ContainerID: 0, HostID: 1002, Size: 1}}, attr.GidMappings)
+	assert.Equal(t, &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true}, attr.Credential)
+	assert.Equal(t, sandboxShell, command.Path)
+	assert.Equal(t, []string{"sandbox", "/bin/sh", "-c", "echo hello"}, command.Args[3:])
+}
+
+func TestSandboxApply_UserNamespace(t *testing.T) {
+	command := exec.Command("/bin/sh", "-c", "echo hello")
+	prepareProcess(command)
+
+	assert.NoError(t, (&SandboxOptions{}).apply(command, nil))
+
+	attr := command.SysProcAttr
+	assert.Equal(t, uintptr(syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER), attr.Cloneflags)
+	assert.Equal(t, []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Geteuid(), Size: 1}}, attr.UidMappings)
+	assert.Equal(t, []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getegid(), Size: 1}}, attr.GidMappings)
+}
+
+func TestExecuteCommandWithOptions_Sandbox(t *testing.T) {
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER)
+
+	// the sandbox shell is PID 1 of the namespace and the command has no capabilities to undo the read-only root
+	stdout, _ := runInSandbox(t, SandboxOptions{}, nil,
+		"tr '\\0' '\\n' < /proc/1/cmdline | grep -x sandbox; grep CapEff /proc/self/status | cut -f2; "+
+			"mount -n -o remount,bind,rw / 2>/dev/null; touch /etc/ssm-sandbox-test 2>/dev/null && echo writable || echo read-only; touch /tmp/private && ls /tmp")
+	assert.Equal(t, "sandbox\n0000000000000000\nread-only\nprivate\n", stdout)
+	_, err := os.Stat("/tmp/private")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExecuteCommandWithOptions_SandboxProcAndDev(t *testing.T) {
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER)
+
+	stdout, _ := runInSandbox(t, SandboxOptions{}, nil, `for path in /proc/sys/kernel/hostname /proc/sysrq-trigger /dev/ssm-sandbox-test; do
+	[ -e "$path" ] || [ "$path" = /dev/ssm-sandbox-test ] || continue
+	(echo 1 > "$path") 2>/dev/null && echo "$path writable" || echo "$path read-only"
+done
+echo discarded > /dev/null && echo null
+ls /dev | tr '\n' ' '`)
+	assert.NotContains(t, stdout, "writable")
+	assert.Contains(t, stdout, "/dev/ssm-sandbox-test read-only")
+	assert.Contains(t, stdout, "null\n")
+	assert.Equal(t, "fd full null random shm stderr stdin stdout tty urandom zero", strings.TrimSpace(stdout[strings.LastIndex(stdout, "null\n")+5:]))
+}
+
+func TestExecuteCommandWithOptions_SandboxSysIsReadOnly(t *testing.T) {
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER)
+	if _, err := os.Stat("/sys/fs/cgroup"); err != nil {
+		t.Skipf("cgroup file system is not available: %v", err)
+	}
+
+	// root of the host, which the sandbox runs as when the agent does, owns the cgroup file systems
+	stdout, _ := runInSandbox(t, SandboxOptions{}, nil, `for dir in /sys/fs/cgroup /sys/fs/cgroup/*/; do
+	if mkdir "${dir%/}/ssm-sandbox-test" 2>/dev/null; then
+		echo "$dir writable"
+		rmdir "${dir%/}/ssm-sandbox-test"
+	fi
+done
+echo done`)
+	assert.Equal(t, "done\n", stdout)
+}
+
+// waitForSandboxCommand waits until the sandbox shell runs the command
+func waitForSandboxCommand(t *testing.T, pid int, name string) {
+	childrenFile := fmt.Sprintf("/proc/%d/task/%d/children", pid, pid)
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		children, _ := os.ReadFile(childrenFile)
+		for _, child := range strings.Fields(string(children)) {
+			if comm, _ := os.ReadFile("/proc/" + child + "/comm"); strings.TrimSpace(string(comm)) == name {
+				return
+			}
+		}
+	}
+	t.Fatalf("the sandbox did not start %v", name)
+}
+
+func TestSandbox_ForwardsTerminationSignals(t *testing.T) {
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER)
+
+	// sleep has no handler for SIGTERM, which would be ignored if it ran as PID 1 of the namespace
+	command := exec.Command("/bin/sleep", "30")
+	prepareProcess(command)
+	assert.NoError(t, (&SandboxOptions{}).apply(command, nil))
+	assert.NoError(t, command.Start())
+
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+	waitForSandboxCommand(t, command.Process.Pid, "sleep")
+	// the signal goes to the sandbox shell only, not to the process group
+	assert.NoError(t, command.Process.Signal(syscall.SIGTERM))
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+		assert.Equal(t, 128+int(syscall.SIGTERM), command.ProcessState.ExitCode())
+	case <-time.After(10 * time.Second):
+		syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+		<-done
+		t.Fatal("the sandbox did not forward SIGTERM to the command")
+	}
+}
+
+func TestExecuteCommandWithOptions_SandboxWithoutNetwork(t *testing.T) {
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWNET)
+
+	// the interfaces of the network namespace are listed after two header lines
+	stdout, _ := runInSandbox(t, SandboxOptions{DisableNetwork: true}, nil, "tail -n +3 /proc/net/dev | cut -d: -f1")
+	assert.Equal(t, "lo", strings.TrimSpace(stdout))
+}
+
+func TestExecuteCommandWithOptions_SandboxRunAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("running as another user requires root")
+	}
+	skipWithoutNamespaces(t, syscall.CLONE_NEWNS|syscall.CLONE_NEWPID|syscall.CLONE_NEWUSER)
+	credentials, err := LookupRunAsCredentials("nobody", "")
+	if err != nil {
+		t.Skipf("user nobody is not available: %v", err)
+	}
+
+	stdout, _ := runInSandbox(t, SandboxOptions{}, credentials, "id -u; cat /proc/self/uid_map")
+	fields := strings.Fields(stdout)
+	assert.Equal(t, []string{"0", "0", strconv.FormatUint(uint64(credentials.Uid), 10), "1"}, fields)
+}
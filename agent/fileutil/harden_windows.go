@@ -74,3 +74,43 @@ func mallocSID(sidSize int) (sidPtr *windows.SID, sidLen uint32) {
 	sidLen = uint32(len(sid))
 	return
 }
+
+// CheckHardened checks that the path is owned by and only grants access to LocalSystem and the Administrators,
+// like the paths hardened with Harden. A missing access control list grants everyone access and is refused.
+func CheckHardened(path string) error {
+	securityDescriptor, err := windows.GetNamedSecurityInfo(
+		path,
+		windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to get the access control list of %s: %v", path, err)
+	}
+	hardenedErr := fmt.Errorf("%s must be owned by and only be accessible by LocalSystem and the Administrators", path)
+
+	owner, _, err := securityDescriptor.Owner()
+	if err != nil || !isAdministrator(owner) {
+		return hardenedErr
+	}
+	dacl, _, err := securityDescriptor.DACL()
+	if err != nil || dacl == nil {
+		return hardenedErr
+	}
+	for index := uint32(0); index < uint32(dacl.AceCount); index++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err = windows.GetAce(dacl, index, &ace); err != nil {
+			return hardenedErr
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE {
+			continue
+		}
+		if !isAdministrator((*windows.SID)(unsafe.Pointer(&ace.SidStart))) {
+			return hardenedErr
+		}
+	}
+	return nil
+}
+
+// isAdministrator checks if the security identifier is LocalSystem or the Administrators group
+func isAdministrator(sid *windows.SID) bool {
+	return sid != nil && (sid.IsWellKnown(windows.WinLocalSystemSid) || sid.IsWellKnown(windows.WinBuiltinAdministratorsSid))
+}
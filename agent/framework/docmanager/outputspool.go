@@ -0,0 +1,110 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package docmanager helps persist documents state to disk
+package docmanager
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// outputSpoolDirName is the directory under the document root directory where the compressed output of commands is kept
+const outputSpoolDirName = "outputspool"
+
+var outputSpoolLock sync.Mutex
+
+// spooledExecution is the spooled output of one execution
+type spooledExecution struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// OutputSpoolDir returns the directory where the compressed output of commands is kept,
+// the output of each execution is under a directory named after the execution.
+func OutputSpoolDir(instanceID string) string {
+	return filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		outputSpoolDirName)
+}
+
+// OutputSpoolPath returns the spool directory of the output written to an orchestration directory,
+// it mirrors the path of the orchestration directory under the orchestration root directory.
+func OutputSpoolPath(instanceID, orchestrationRootDirName, orchestrationDirectory string) (string, bool) {
+	orchestrationRootDir := filepath.Join(appconfig.DefaultDataStorePath,
+		instanceID,
+		appconfig.DefaultDocumentRootDirName,
+		orchestrationRootDirName)
+	relativePath, err := filepath.Rel(orchestrationRootDir, orchestrationDirectory)
+	if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(OutputSpoolDir(instanceID), relativePath), true
+}
+
+// PruneOutputSpool deletes the spooled output of the oldest executions until the spool is at most maxSizeBytes
+func PruneOutputSpool(log log.T, spoolDir string, maxSizeBytes int64) {
+	outputSpoolLock.Lock()
+	defer outputSpoolLock.Unlock()
+
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		log.Debugf("Failed to read output spool %v: %v", spoolDir, err)
+		return
+	}
+
+	var executions []spooledExecution
+	var totalSize int64
+	for _, entry := range entries {
+		execution := spooledExecution{path: filepath.Join(spoolDir, entry.Name())}
+		filepath.Walk(execution.path, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			execution.size += info.Size()
+			if info.ModTime().After(execution.modTime) {
+				execution.modTime = info.ModTime()
+			}
+			return nil
+		})
+		totalSize += execution.size
+		executions = append(executions, execution)
+	}
+	if totalSize <= maxSizeBytes {
+		return
+	}
+
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].modTime.Before(executions[j].modTime)
+	})
+	for _, execution := range executions {
+		if totalSize <= maxSizeBytes {
+			break
+		}
+		log.Debugf("Deleting spooled output %v to keep the output spool under %d bytes", execution.path, maxSizeBytes)
+		if err = os.RemoveAll(execution.path); err != nil {
+			log.Warnf("Failed to delete spooled output %v: %v", execution.path, err)
+			continue
+		}
+		totalSize -= execution.size
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputSpoolPath(t *testing.T) {
+	orchestrationDir := filepath.Join(appconfig.DefaultDataStorePath, "i-123", appconfig.DefaultDocumentRootDirName, TEST_ORC_DIR, "command-1", "awsrunShellScript", "install")
+	spoolPath, ok := OutputSpoolPath("i-123", TEST_ORC_DIR, orchestrationDir)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(OutputSpoolDir("i-123"), "command-1", "awsrunShellScript", "install"), spoolPath)
+
+	for _, directory := range []string{
+		filepath.Join(appconfig.DefaultDataStorePath, "i-123", appconfig.DefaultDocumentRootDirName, TEST_ORC_DIR),
+		filepath.Join(appconfig.DefaultDataStorePath, "i-123", appconfig.DefaultDocumentRootDirName, TEST_SEC_DIR, "session-1"),
+	} {
+		_, ok = OutputSpoolPath("i-123", TEST_ORC_DIR, directory)
+		assert.False(t, ok, directory)
+	}
+}
+
+func TestPruneOutputSpool_DeletesOldestExecutions(t *testing.T) {
+	spoolDir := t.TempDir()
+	now := time.Now()
+	for i, execution := range []string{"command-1", "command-2", "command-3"} {
+		filePath := filepath.Join(spoolDir, execution, "awsrunShellScript", "stdout.gz")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		assert.NoError(t, os.WriteFile(filePath, make([]byte, 100), 0600))
+		modTime := now.Add(time.Duration(i-3) * time.Hour)
+		assert.NoError(t, os.Chtimes(filePath, modTime, modTime))
+	}
+
+	PruneOutputSpool(log.NewMockLog(), spoolDir, 250)
+
+	_, err := os.Stat(filepath.Join(spoolDir, "command-1"))
+	assert.True(t, os.IsNotExist(err))
+	assert.DirExists(t, filepath.Join(spoolDir, "command-2"))
+	assert.DirExists(t, filepath.Join(spoolDir, "command-3"))
+
+	PruneOutputSpool(log.NewMockLog(), spoolDir, 0)
+	entries, err := os.ReadDir(spoolDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
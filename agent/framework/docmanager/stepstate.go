@@ -0,0 +1,40 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package docmanager helps persist documents state to disk
+package docmanager
+
+import (
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// stepStartedFileName is the file a step leaves in its orchestration directory once it starts running
+const stepStartedFileName = ".started"
+
+// MarkStepStarted records in the orchestration directory of a step that the step started running,
+// the state file of the document only learns about a step when it completes.
+func MarkStepStarted(orchestrationDirectory string) error {
+	if err := fileutil.MakeDirs(orchestrationDirectory); err != nil {
+		return err
+	}
+	_, err := fileutil.WriteIntoFileWithPermissions(filepath.Join(orchestrationDirectory, stepStartedFileName), "", appconfig.ReadWriteAccess)
+	return err
+}
+
+// IsStepStarted returns whether the step with the given orchestration directory started running
+func IsStepStarted(orchestrationDirectory string) bool {
+	return orchestrationDirectory != "" && fileutil.Exists(filepath.Join(orchestrationDirectory, stepStartedFileName))
+}
@@ -0,0 +1,32 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docmanager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkStepStarted(t *testing.T) {
+	tempDir := t.TempDir()
+	orchestrationDirectory := filepath.Join(tempDir, "command", "step1")
+
+	assert.False(t, IsStepStarted(orchestrationDirectory))
+	assert.NoError(t, MarkStepStarted(orchestrationDirectory))
+	assert.True(t, IsStepStarted(orchestrationDirectory))
+	assert.False(t, IsStepStarted(filepath.Join(tempDir, "command", "step2")))
+	assert.False(t, IsStepStarted(""))
+}
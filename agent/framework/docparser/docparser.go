@@ -24,8 +24,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/localparameters"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameters"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameterstore"
+	paramvalidatorutils "github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/utils"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/versionutil"
@@ -44,6 +46,9 @@ type DocumentParserInfo struct {
 	DocumentId        string
 	DefaultWorkingDir string
 	CloudWatchConfig  contracts.CloudWatchConfiguration
+
+	// secretValues collects the values of the local parameters resolved while parsing, the output of the document masks them
+	secretValues *[]string
 }
 
 // InitializeDocState is a method to obtain the state of the document.
@@ -61,11 +66,14 @@ func InitializeDocState(
 	docState.DocumentInformation = docInfo
 	docState.IOConfig = docContent.GetIOConfiguration(parserInfo)
 
+	var secretValues []string
+	parserInfo.secretValues = &secretValues
 	pluginInfo, err := docContent.ParseDocument(context, docInfo, parserInfo, params)
 	if err != nil {
 		return
 	}
 	docState.InstancePluginsInformation = pluginInfo
+	docState.IOConfig.SecretValues = secretValues
 	return docState, nil
 }
 
@@ -103,9 +111,13 @@ func (docContent *DocContent) ParseDocument(context context.T,
 	if err = validateSchema(docContent.SchemaVersion); err != nil {
 		return
 	}
-	if err = getValidatedParameters(context, params, docContent); err != nil {
+	secretValues, err := getValidatedParameters(context, params, docContent)
+	if err != nil {
 		return
 	}
+	if parserInfo.secretValues != nil {
+		*parserInfo.secretValues = secretValues
+	}
 
 	return parseDocumentContent(*docContent, parserInfo, context.Log(), params)
 }
@@ -225,7 +237,8 @@ func ParseParameters(log log.T, params map[string][]*string, paramsDef map[strin
 					newParam = append(newParam, *value)
 				}
 				result[name] = newParam
-			case contracts.ParamTypeStringMap:
+			case contracts.ParamTypeStringMap, contracts.ParamTypeInteger, contracts.ParamTypeBoolean:
+				// converted to the parameter type when the document is parsed
 				result[name] = *(param[0])
 			default:
 				log.Debug("unknown parameter type ", definition.ParamType)
@@ -304,8 +317,32 @@ func parsePluginStateForV20Schema(
 	// set precondition flag based on document schema version
 	isPreconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
 
+	// step branching, parallel groups, step outputs, finally steps and resume policies are supported along with preconditions, starting with schema 2.2
+	if isPreconditionEnabled {
+		if err = validateFinallySteps(docContent.MainSteps, docContent.FinallySteps); err != nil {
+			return pluginsInfo, err
+		}
+		if err = validateResumePolicies(append(append([]*contracts.InstancePluginConfig{}, docContent.MainSteps...), docContent.FinallySteps...)); err != nil {
+			return pluginsInfo, err
+		}
+		if err = validateStepBranching(docContent.MainSteps); err != nil {
+			return pluginsInfo, err
+		}
+		if err = validateParallelGroups(docContent.MainSteps); err != nil {
+			return pluginsInfo, err
+		}
+		if err = validateStepOutputs(append(append([]*contracts.InstancePluginConfig{}, docContent.MainSteps...), docContent.FinallySteps...)); err != nil {
+			return pluginsInfo, err
+		}
+	} else if len(docContent.FinallySteps) > 0 {
+		return pluginsInfo, fmt.Errorf("finallySteps is not supported for document schema version %s", docContent.SchemaVersion)
+	}
+
+	// finally steps run after the main steps, so they are appended to them
+	steps := append(append([]*contracts.InstancePluginConfig{}, docContent.MainSteps...), docContent.FinallySteps...)
+
 	// getPluginConfigurations converts from PluginConfig (structure from the MDS message) to plugin.Configuration (structure expected by the plugin)
-	for _, instancePluginConfig := range docContent.MainSteps {
+	for index, instancePluginConfig := range steps {
 		pluginName := instancePluginConfig.Action
 		config := contracts.Configuration{
 			Settings:                instancePluginConfig.Settings,
@@ -319,6 +356,10 @@ func parsePluginStateForV20Schema(
 			PluginID:                instancePluginConfig.Name,
 			Preconditions:           parsePluginParametersInPreconditions(&docContent, instancePluginConfig.Preconditions, params, log),
 			IsPreconditionEnabled:   isPreconditionEnabled,
+			MaxAttempts:             instancePluginConfig.MaxAttempts,
+			TimeoutSeconds:          instancePluginConfig.Timeout,
+			Outputs:                 instancePluginConfig.Outputs,
+			RunAlways:               isPreconditionEnabled && isRunAlwaysStep(instancePluginConfig, index >= len(docContent.MainSteps)),
 			DefaultWorkingDirectory: defaultWorkingDir,
 		}
 
@@ -333,7 +374,7 @@ func parsePluginStateForV20Schema(
 
 // parsePluginParametersInPreconditions modifies plugin preconditions as defined in PluginConfig to match the structure
 // expected by the plugin executor (plugin.Configuration -> PreconditionArgument)
-func parsePluginParametersInPreconditions(docContent *DocContent, precondition map[string][]string, params map[string]interface{}, log log.T) map[string][]contracts.PreconditionArgument {
+func parsePluginParametersInPreconditions(docContent *DocContent, precondition map[string][]interface{}, params map[string]interface{}, log log.T) map[string][]contracts.PreconditionArgument {
 	parsedPreconditions := make(map[string][]contracts.PreconditionArgument)
 	for operator, args := range precondition {
 		parsedPreconditions[operator] = validateAndReplaceParametersInPreconditionArguments(docContent, args, params, log)
@@ -346,7 +387,7 @@ func parsePluginParametersInPreconditions(docContent *DocContent, precondition m
 // TODO: return a list of invalid parameters and expose it to the user in the document execution output
 // Idea: add a section to the DocumentState to store all warnings and errors that occur during document processing
 // and access them later in the sendReply or UpdateAssociation
-func validateAndReplaceParametersInPreconditionArguments(docContent *DocContent, args []string, params map[string]interface{}, log log.T) []contracts.PreconditionArgument {
+func validateAndReplaceParametersInPreconditionArguments(docContent *DocContent, args []interface{}, params map[string]interface{}, log log.T) []contracts.PreconditionArgument {
 
 	//ValidateParameterNames
 	validParameters := parameters.ValidParameters(log, params)
@@ -361,14 +402,49 @@ func validateAndReplaceParametersInPreconditionArguments(docContent *DocContent,
 	// replace document parameters in each of the arguments
 	parsedArguments := make([]contracts.PreconditionArgument, len(args))
 	for i, arg := range args {
+		// the arguments of the and, or and not operators are preconditions themselves
+		if nestedPrecondition, isNested := getNestedPrecondition(arg); isNested {
+			parsedArguments[i] = contracts.PreconditionArgument{
+				Precondition: parsePluginParametersInPreconditions(docContent, nestedPrecondition, params, log),
+			}
+			continue
+		}
+		argValue := fmt.Sprintf("%v", arg)
 		parsedArguments[i] = contracts.PreconditionArgument{
-			InitialArgumentValue:  arg,
-			ResolvedArgumentValue: parameters.ReplaceParameters(arg, validParameters, log).(string),
+			InitialArgumentValue:  argValue,
+			ResolvedArgumentValue: parameters.ReplaceParameters(argValue, validParameters, log).(string),
 		}
 	}
 	return parsedArguments
 }
 
+// getNestedPrecondition converts a precondition nested in the argument of an operator, as parsed from JSON or YAML,
+// to the structure of the step precondition. Operators of a nested precondition may take a single argument instead of a list.
+func getNestedPrecondition(arg interface{}) (precondition map[string][]interface{}, isNested bool) {
+	precondition = make(map[string][]interface{})
+	switch arg := arg.(type) {
+	case map[string]interface{}:
+		for operator, operatorArgs := range arg {
+			precondition[operator] = toPreconditionArgumentList(operatorArgs)
+		}
+	case map[interface{}]interface{}:
+		for operator, operatorArgs := range arg {
+			precondition[fmt.Sprintf("%v", operator)] = toPreconditionArgumentList(operatorArgs)
+		}
+	default:
+		return nil, false
+	}
+	return precondition, true
+}
+
+// toPreconditionArgumentList returns the arguments of an operator as a list
+func toPreconditionArgumentList(operatorArgs interface{}) []interface{} {
+	if argList, ok := operatorArgs.([]interface{}); ok {
+		return argList
+	}
+	return []interface{}{operatorArgs}
+}
+
 // parsePluginStateForStartSession initializes instancePluginsInfo for the docState. Used by startSession.
 func (sessionDocContent *SessionDocContent) parsePluginStateForStartSession(
 	parserInfo DocumentParserInfo,
@@ -453,7 +529,8 @@ func validateSchema(documentSchemaVersion string) error {
 }
 
 // getValidatedParameters validates the parameters and modifies the document content by replacing all ssm parameters with their actual values.
-func getValidatedParameters(context context.T, params map[string]interface{}, docContent *DocContent) error {
+// It returns the secret values of the local parameters it resolved.
+func getValidatedParameters(context context.T, params map[string]interface{}, docContent *DocContent) (secretValues []string, err error) {
 	log := context.Log()
 
 	//ValidateParameterNames
@@ -466,23 +543,61 @@ func getValidatedParameters(context context.T, params map[string]interface{}, do
 		}
 	}
 
+	log.Debug("Resolving local parameters")
+	// Resolves local parameters first so that their values are validated like supplied values
+	resolvedParameters, secretValues, err := localparameters.Resolve(context, validParameters)
+	if err != nil {
+		return nil, err
+	}
+	validParameters = resolvedParameters.(map[string]interface{})
+
 	log.Debug("Validating SSM parameters")
 	// Validates SSM parameters
 	if err := parameterstore.ValidateSSMParameters(context, docContent.Parameters, validParameters, docContent.InvokedPlugin); err != nil {
-		return err
+		return nil, err
 	}
 
-	err := replaceValidatedPluginParameters(context, docContent, validParameters)
-	return err
+	if err := coerceParameterValues(docContent.Parameters, validParameters); err != nil {
+		return nil, err
+	}
+
+	pluginSecretValues, err := replaceValidatedPluginParameters(context, docContent, validParameters)
+	if err != nil {
+		return nil, err
+	}
+	return append(secretValues, pluginSecretValues...), nil
+}
+
+// coerceParameterValues converts the parameter values to the types declared by the document before they are substituted.
+// Values resolved when the document runs are left unchanged.
+func coerceParameterValues(documentParameters map[string]*contracts.Parameter, params map[string]interface{}) error {
+	_, paramNames := sortedParameterNames(nil, params)
+	for _, paramName := range paramNames {
+		parameter, declared := documentParameters[paramName]
+		value := params[paramName]
+		if !declared || parameter == nil || value == nil {
+			continue
+		}
+		if isResolvedAtRunTime(value) {
+			continue
+		}
+		coercedValue, err := paramvalidatorutils.CoerceParameterValue(paramvalidatorutils.DocumentParamType(parameter.ParamType), value)
+		if err != nil {
+			return fmt.Errorf("invalid value for parameter %s of type %s: %v", paramName, parameter.ParamType, err)
+		}
+		params[paramName] = coercedValue
+	}
+	return nil
 }
 
 // replaceValidatedPluginParameters replaces parameters with their values, within the plugin Properties.
+// It returns the secret values of the local parameters it resolved.
 func replaceValidatedPluginParameters(
 	context context.T,
 	docContent *DocContent,
-	params map[string]interface{}) error {
+	params map[string]interface{}) (secretValues []string, err error) {
 	logger := context.Log()
-	var err error
+	var resolvedSecretValues []string
 
 	//TODO: Refactor this to not not reparse the docContent
 	runtimeConfig := docContent.RuntimeConfig
@@ -497,41 +612,82 @@ func replaceValidatedPluginParameters(
 			logger.Debug("Resolving SSM parameters")
 			// Resolves SSM parameters
 			if updatedRuntimeConfig[pluginName].Settings, err = parameterstore.Resolve(context, updatedRuntimeConfig[pluginName].Settings); err != nil {
-				return err
+				return nil, err
+			}
+
+			// Resolves local parameters
+			if updatedRuntimeConfig[pluginName].Settings, resolvedSecretValues, err = localparameters.Resolve(context, updatedRuntimeConfig[pluginName].Settings); err != nil {
+				return nil, err
 			}
+			secretValues = append(secretValues, resolvedSecretValues...)
 
 			// Resolves SSM parameters
 			if updatedRuntimeConfig[pluginName].Properties, err = parameterstore.Resolve(context, updatedRuntimeConfig[pluginName].Properties); err != nil {
-				return err
+				return nil, err
 			}
+
+			// Resolves local parameters
+			if updatedRuntimeConfig[pluginName].Properties, resolvedSecretValues, err = localparameters.Resolve(context, updatedRuntimeConfig[pluginName].Properties); err != nil {
+				return nil, err
+			}
+			secretValues = append(secretValues, resolvedSecretValues...)
 		}
 		docContent.RuntimeConfig = updatedRuntimeConfig
-		return nil
+		return secretValues, nil
 	}
 
 	mainSteps := docContent.MainSteps
 	if mainSteps != nil || len(mainSteps) != 0 {
-		updatedMainSteps := make([]*contracts.InstancePluginConfig, len(mainSteps))
-		for index, instancePluginConfig := range mainSteps {
-			updatedMainSteps[index] = instancePluginConfig
-			updatedMainSteps[index].Settings = parameters.ReplaceParameters(instancePluginConfig.Settings, params, logger)
-			updatedMainSteps[index].Inputs = parameters.ReplaceParameters(instancePluginConfig.Inputs, params, logger)
+		if docContent.MainSteps, secretValues, err = replaceValidatedStepParameters(context, mainSteps, params); err != nil {
+			return nil, err
+		}
+		if docContent.FinallySteps, resolvedSecretValues, err = replaceValidatedStepParameters(context, docContent.FinallySteps, params); err != nil {
+			return nil, err
+		}
+		return append(secretValues, resolvedSecretValues...), nil
+	}
+	return nil, nil
+}
 
-			logger.Debug("Resolving SSM parameters")
-			// Resolves SSM parameters
-			if updatedMainSteps[index].Settings, err = parameterstore.Resolve(context, updatedMainSteps[index].Settings); err != nil {
-				return err
-			}
+// replaceValidatedStepParameters replaces parameters with their values, within the inputs and settings of the steps.
+// It returns the secret values of the local parameters it resolved.
+func replaceValidatedStepParameters(
+	context context.T,
+	steps []*contracts.InstancePluginConfig,
+	params map[string]interface{}) (updatedSteps []*contracts.InstancePluginConfig, secretValues []string, err error) {
+	logger := context.Log()
+	var resolvedSecretValues []string
+
+	updatedSteps = make([]*contracts.InstancePluginConfig, len(steps))
+	for index, instancePluginConfig := range steps {
+		updatedSteps[index] = instancePluginConfig
+		updatedSteps[index].Settings = parameters.ReplaceParameters(instancePluginConfig.Settings, params, logger)
+		updatedSteps[index].Inputs = parameters.ReplaceParameters(instancePluginConfig.Inputs, params, logger)
+
+		logger.Debug("Resolving SSM parameters")
+		// Resolves SSM parameters
+		if updatedSteps[index].Settings, err = parameterstore.Resolve(context, updatedSteps[index].Settings); err != nil {
+			return nil, nil, err
+		}
 
-			// Resolves SSM parameters
-			if updatedMainSteps[index].Inputs, err = parameterstore.Resolve(context, updatedMainSteps[index].Inputs); err != nil {
-				return err
-			}
+		// Resolves local parameters
+		if updatedSteps[index].Settings, resolvedSecretValues, err = localparameters.Resolve(context, updatedSteps[index].Settings); err != nil {
+			return nil, nil, err
+		}
+		secretValues = append(secretValues, resolvedSecretValues...)
+
+		// Resolves SSM parameters
+		if updatedSteps[index].Inputs, err = parameterstore.Resolve(context, updatedSteps[index].Inputs); err != nil {
+			return nil, nil, err
 		}
-		docContent.MainSteps = updatedMainSteps
-		return nil
+
+		// Resolves local parameters
+		if updatedSteps[index].Inputs, resolvedSecretValues, err = localparameters.Resolve(context, updatedSteps[index].Inputs); err != nil {
+			return nil, nil, err
+		}
+		secretValues = append(secretValues, resolvedSecretValues...)
 	}
-	return nil
+	return updatedSteps, secretValues, nil
 }
 
 // isPreConditionEnabled checks if precondition support is enabled by checking document schema version
@@ -132,6 +132,29 @@ func TestParseDocument_ValidMainSteps(t *testing.T) {
 	assert.Equal(t, testWorkingDir, pluginInfoTest.Configuration.DefaultWorkingDirectory)
 }
 
+func TestParseDocument_MainStepsWithMaxAttemptsAndTimeout(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"flaky","maxAttempts":3,"timeoutSeconds":600,"inputs":{"runCommand":["ls"]}},{"action":"aws:runShellScript","name":"once","inputs":{"runCommand":["ls"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(pluginsInfo))
+	assert.Equal(t, 3, pluginsInfo[0].Configuration.MaxAttempts)
+	assert.Equal(t, 0, pluginsInfo[1].Configuration.MaxAttempts)
+	assert.Equal(t, 600, pluginsInfo[0].Configuration.TimeoutSeconds)
+	assert.Equal(t, 0, pluginsInfo[1].Configuration.TimeoutSeconds)
+}
+
 func TestInitializeDocState_Valid(t *testing.T) {
 	context := context.NewMockDefault()
 
@@ -553,6 +576,135 @@ func TestParseDocument_ReplaceDefaultParameters(t *testing.T) {
 	assert.NotEqual(t, parsedMessage, originalMessage)
 }
 
+func TestParseDocument_CoercesTypedParameters(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2",` +
+		`"parameters":{"timeout":{"type":"Integer","maxValue":3600},"verbose":{"type":"Boolean","default":false}},` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["echo {{ verbose }}"],"timeoutSeconds":"{{ timeout }}"}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, map[string]interface{}{"timeout": "60"})
+
+	assert.NoError(t, err)
+	inputs := pluginsInfo[0].Configuration.Properties.(map[string]interface{})
+	assert.Equal(t, 60, inputs["timeoutSeconds"])
+	assert.Equal(t, []interface{}{"echo false"}, inputs["runCommand"])
+}
+
+func TestCoerceParameterValues_LeavesValuesResolvedAtRunTime(t *testing.T) {
+	documentParameters := map[string]*contracts.Parameter{
+		"timeout": {ParamType: "Integer"},
+		"retries": {ParamType: "Integer"},
+		"debug":   {ParamType: "Boolean"},
+		"workers": {ParamType: "Integer"},
+		"verbose": {ParamType: "Boolean"},
+	}
+	params := map[string]interface{}{
+		"timeout": "{{ env:STEP_TIMEOUT }}",
+		"retries": "{{ file:/etc/app/retries }}",
+		"debug":   "{{ ssm:/app/debug }}",
+		"workers": "{{ secretsmanager:app#workers }}",
+		"verbose": "true",
+	}
+
+	err := coerceParameterValues(documentParameters, params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"timeout": "{{ env:STEP_TIMEOUT }}",
+		"retries": "{{ file:/etc/app/retries }}",
+		"debug":   "{{ ssm:/app/debug }}",
+		"workers": "{{ secretsmanager:app#workers }}",
+		"verbose": true,
+	}, params)
+}
+
+func TestParseDocument_InvalidTypedParameters(t *testing.T) {
+	document := `{"schemaVersion":"2.2",` +
+		`"parameters":{"timeout":{"type":"Integer","maxValue":3600}},` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"],"timeoutSeconds":"{{ timeout }}"}}]}`
+	for value, message := range map[string]string{
+		"abc":  "parameter value /abc/ is not a valid Integer",
+		"7200": "parameter value /7200/ is greater than the max value /3600/",
+	} {
+		var testDocContent DocContent
+		err := json.Unmarshal([]byte(document), &testDocContent)
+		assert.Nil(t, err)
+
+		_, err = testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, map[string]interface{}{"timeout": value})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestParseDocument_ResolvesLocalParameters(t *testing.T) {
+	t.Setenv("APP_MODE", "fast")
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2",` +
+		`"parameters":{"mode":{"type":"String","default":"{{ env:APP_MODE }}","allowedValues":["fast","slow"]}},` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["run --mode {{ mode }} --instance {{agent:instanceId}}"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.LocalParameterSources = []string{"env:APP_*", "agent:instanceId"}
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefaultWithConfig(config), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	inputs := pluginsInfo[0].Configuration.Properties.(map[string]interface{})
+	assert.Equal(t, []interface{}{"run --mode fast --instance i-123123123"}, inputs["runCommand"])
+}
+
+func TestInitializeDocState_KeepsLocalParameterSecretValues(t *testing.T) {
+	t.Setenv("APP_MODE", "fast")
+	t.Setenv("APP_TOKEN", "token-value")
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2",` +
+		`"parameters":{"mode":{"type":"String","default":"{{ env:APP_MODE }}"}},` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["run --mode {{ mode }} --token {{ env:APP_TOKEN }}"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.LocalParameterSources = []string{"env:APP_*"}
+	config.Ssm.LocalParameterSecretSources = []string{"env:APP_TOKEN"}
+
+	docState, err := InitializeDocState(context.NewMockDefaultWithConfig(config), contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-value"}, docState.IOConfig.SecretValues)
+}
+
+func TestParseDocument_LocalParametersNotAllowed(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2",` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["cat {{ file:/etc/shadow }}"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.LocalParameterSources = []string{"file:/etc/app/*"}
+
+	_, err = testDocContent.ParseDocument(context.NewMockDefaultWithConfig(config), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.EqualError(t, err, "local parameter file:/etc/shadow is not allowed by the LocalParameterSources of the agent configuration")
+}
+
+func TestParseDocument_LocalParametersNotConfigured(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2",` +
+		`"mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["echo '{{ env:HOME }}'"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	inputs := pluginsInfo[0].Configuration.Properties.(map[string]interface{})
+	assert.Equal(t, []interface{}{"echo '{{ env:HOME }}'"}, inputs["runCommand"])
+}
+
 func TestIsCrossPlatformEnabledForSchema20(t *testing.T) {
 	var schemaVersion = "2.0"
 	isCrossPlatformEnabled := isPreconditionEnabled(schemaVersion)
@@ -723,3 +875,33 @@ func loadPreconditionsFromFile(t *testing.T) (preconditions map[string]map[strin
 	}
 	return preconditions
 }
+
+func TestParseDocument_NestedPreconditions(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","parameters":{"path":{"type":"String","default":"/etc/os-release"}},"mainSteps":[{"action":"aws:runShellScript","name":"a","precondition":{"StringEquals":["platformType","Linux"],"or":[{"FileExists":["{{ path }}"]},{"not":{"StringEquals":["platformName","Ubuntu"]}}]},"inputs":{"runCommand":["ls"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]contracts.PreconditionArgument{
+		"StringEquals": {
+			{InitialArgumentValue: "platformType", ResolvedArgumentValue: "platformType"},
+			{InitialArgumentValue: "Linux", ResolvedArgumentValue: "Linux"},
+		},
+		"or": {
+			{Precondition: map[string][]contracts.PreconditionArgument{
+				"FileExists": {{InitialArgumentValue: "{{ path }}", ResolvedArgumentValue: "/etc/os-release"}},
+			}},
+			{Precondition: map[string][]contracts.PreconditionArgument{
+				"not": {{Precondition: map[string][]contracts.PreconditionArgument{
+					"StringEquals": {
+						{InitialArgumentValue: "platformName", ResolvedArgumentValue: "platformName"},
+						{InitialArgumentValue: "Ubuntu", ResolvedArgumentValue: "Ubuntu"},
+					},
+				}}},
+			}},
+		},
+	}, pluginsInfo[0].Configuration.Preconditions)
+}
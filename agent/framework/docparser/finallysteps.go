@@ -0,0 +1,72 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// validateFinallySteps checks the runAlways input of the steps, that step names are unique across mainSteps
+// and finallySteps, and that finallySteps neither change the order of the steps nor are branched to.
+func validateFinallySteps(mainSteps []*contracts.InstancePluginConfig, finallySteps []*contracts.InstancePluginConfig) error {
+	stepNames := make(map[string]bool, len(mainSteps)+len(finallySteps))
+	for _, step := range append(append([]*contracts.InstancePluginConfig{}, mainSteps...), finallySteps...) {
+		if stepNames[step.Name] {
+			return fmt.Errorf("step name %s is used by more than one step", step.Name)
+		}
+		stepNames[step.Name] = true
+		if runAlways := getStepInput(step.Inputs, contracts.RunAlwaysModifier); runAlways != "" {
+			if _, err := strconv.ParseBool(runAlways); err != nil {
+				return fmt.Errorf("%s of step %s must be true or false", contracts.RunAlwaysModifier, step.Name)
+			}
+		}
+	}
+
+	finallyStepNames := make(map[string]bool, len(finallySteps))
+	for _, step := range finallySteps {
+		finallyStepNames[step.Name] = true
+		for _, modifier := range []string{contracts.NextStepModifier, contracts.IsEndModifier, contracts.ParallelGroupModifier, contracts.FinallyStepModifier} {
+			if getStepInput(step.Inputs, modifier) != "" {
+				return fmt.Errorf("step %s of finallySteps cannot declare %s", step.Name, modifier)
+			}
+		}
+		for _, modifier := range []string{contracts.OnSuccessModifier, contracts.OnFailureModifier} {
+			if strings.HasPrefix(getStepInput(step.Inputs, modifier), contracts.ModifierValueStepPrefix) {
+				return fmt.Errorf("step %s of finallySteps cannot branch to another step with %s", step.Name, modifier)
+			}
+		}
+	}
+
+	for index, step := range mainSteps {
+		transitions, err := getStepTransitions(step, index, len(mainSteps))
+		if err != nil {
+			return err
+		}
+		for _, transition := range transitions {
+			if finallyStepNames[transition.name] {
+				return fmt.Errorf("%s of step %s cannot branch to step %s of finallySteps", transition.modifier, step.Name, transition.name)
+			}
+		}
+	}
+	return nil
+}
+
+// isRunAlwaysStep returns whether the step is declared in finallySteps or has the runAlways input set
+func isRunAlwaysStep(step *contracts.InstancePluginConfig, isFinallyStep bool) bool {
+	return isFinallyStep || getStepInput(step.Inputs, contracts.RunAlwaysModifier) == contracts.ModifierValueTrue
+}
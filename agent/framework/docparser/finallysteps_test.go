@@ -0,0 +1,131 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFinallySteps(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mainSteps    []*contracts.InstancePluginConfig
+		finallySteps []*contracts.InstancePluginConfig
+		err          string
+	}{
+		{
+			name: "finally steps and run always steps",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("mount", map[string]interface{}{"onFailure": "step:remount"}),
+				newBranchingStep("remount", map[string]interface{}{"runAlways": true}),
+			},
+			finallySteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("unmount", map[string]interface{}{"onFailure": "exit"}),
+			},
+		},
+		{
+			name: "duplicate step name",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{}),
+			},
+			finallySteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{}),
+			},
+			err: "step name a is used by more than one step",
+		},
+		{
+			name: "invalid runAlways",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"runAlways": "sometimes"}),
+			},
+			err: "runAlways of step a must be true or false",
+		},
+		{
+			name: "finally step with nextStep",
+			finallySteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"nextStep": "b"}),
+				newBranchingStep("b", map[string]interface{}{}),
+			},
+			err: "step a of finallySteps cannot declare nextStep",
+		},
+		{
+			name: "finally step branching on failure",
+			finallySteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"onFailure": "step:b"}),
+				newBranchingStep("b", map[string]interface{}{}),
+			},
+			err: "step a of finallySteps cannot branch to another step with onFailure",
+		},
+		{
+			name: "main step branching to finally step",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"onSuccess": "step:cleanup"}),
+			},
+			finallySteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("cleanup", map[string]interface{}{}),
+			},
+			err: "onSuccess of step a cannot branch to step cleanup of finallySteps",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateFinallySteps(testCase.mainSteps, testCase.finallySteps)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestParseDocument_FinallySteps(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[` +
+		`{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"]}},` +
+		`{"action":"aws:runShellScript","name":"b","inputs":{"runCommand":["ls"],"runAlways":true}}],` +
+		`"finallySteps":[{"action":"aws:runShellScript","name":"cleanup","inputs":{"runCommand":["ls"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	var stepNames []string
+	var runAlways []bool
+	for _, pluginState := range pluginsInfo {
+		stepNames = append(stepNames, pluginState.Id)
+		runAlways = append(runAlways, pluginState.Configuration.RunAlways)
+	}
+	assert.Equal(t, []string{"a", "b", "cleanup"}, stepNames)
+	assert.Equal(t, []bool{false, true, true}, runAlways)
+}
+
+func TestParseDocument_FinallyStepsRejectedBeforeSchema22(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.0","mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"]}}],` +
+		`"finallySteps":[{"action":"aws:runShellScript","name":"cleanup","inputs":{"runCommand":["ls"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	_, err = testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.EqualError(t, err, "finallySteps is not supported for document schema version 2.0")
+}
@@ -0,0 +1,304 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localparameters resolves document parameters whose values come from the instance the document runs on,
+// such as environment variables, files, instance metadata and the identity of the agent.
+package localparameters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// SourceEnv resolves {{ env:NAME }} to the value of an environment variable of the agent
+	SourceEnv = "env"
+	// SourceFile resolves {{ file:/path }} to the content of a file, without its trailing line break
+	SourceFile = "file"
+	// SourceIMDS resolves {{ imds:path }} to an instance metadata value, e.g. {{ imds:placement/region }}
+	SourceIMDS = "imds"
+	// SourceAgent resolves {{ agent:key }} to a value known to the agent, e.g. {{ agent:instanceId }}
+	SourceAgent = "agent"
+
+	// maxFileSize is the largest file that can be used as a parameter value
+	maxFileSize = 64 * 1024
+)
+
+var localParameterRegex = regexp.MustCompile(`\{\{\s*((?:env|file|imds|agent):[^{}\s]+)\s*\}\}`)
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var imdsPathRegex = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)*$`)
+
+var lookupEnv = os.LookupEnv
+var getInstanceMetadata = ec2InstanceMetadata
+
+// agentValues returns the values of the {{ agent:key }} parameters
+var agentValues = map[string]func(context context.T) (string, error){
+	"instanceId":         func(context context.T) (string, error) { return context.Identity().InstanceID() },
+	"region":             func(context context.T) (string, error) { return context.Identity().Region() },
+	"availabilityZone":   func(context context.T) (string, error) { return context.Identity().AvailabilityZone() },
+	"availabilityZoneId": func(context context.T) (string, error) { return context.Identity().AvailabilityZoneId() },
+	"instanceType":       func(context context.T) (string, error) { return context.Identity().InstanceType() },
+}
+
+// TextContainsLocalParameters returns whether the text references a local parameter
+func TextContainsLocalParameters(input string) bool {
+	return localParameterRegex.MatchString(input)
+}
+
+// Resolve replaces the local parameters referenced in the strings of input with their values.
+// References are only resolved when allowed by the LocalParameterSources of the agent configuration,
+// the input is left unchanged when the agent configuration allows no local parameter.
+// The values of the references allowed by the LocalParameterSecretSources of the agent configuration are returned
+// as secret values, the output of the document masks them.
+func Resolve(context context.T, input interface{}) (output interface{}, secretValues []string, err error) {
+	if len(context.AppConfig().Ssm.LocalParameterSources) == 0 {
+		return input, nil, nil
+	}
+	resolver := &resolver{
+		context:       context,
+		allowlist:     context.AppConfig().Ssm.LocalParameterSources,
+		secretSources: context.AppConfig().Ssm.LocalParameterSecretSources,
+		values:        make(map[string]string),
+	}
+	if output, err = resolver.replace(input); err != nil {
+		return nil, nil, err
+	}
+	return output, resolver.secretValues, nil
+}
+
+// resolver resolves each reference found in the input once
+type resolver struct {
+	context       context.T
+	allowlist     []string
+	secretSources []string
+	values        map[string]string
+	secretValues  []string
+}
+
+// replace walks the input the same way parameters are replaced in documents and returns a copy with the references resolved
+func (r *resolver) replace(input interface{}) (interface{}, error) {
+	switch input := input.(type) {
+	case string:
+		return r.replaceText(input)
+
+	case []string:
+		out := make([]string, len(input))
+		for i, v := range input {
+			var err error
+			if out[i], err = r.replaceText(v); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(input))
+		for i, v := range input {
+			var err error
+			if out[i], err = r.replace(v); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case []map[string]interface{}:
+		// this case is not caught by the one above because map cannot be converted to interface{}
+		out := make([]map[string]interface{}, len(input))
+		for i, v := range input {
+			temp, err := r.replace(v)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = temp.(map[string]interface{})
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(input))
+		for k, v := range input {
+			var err error
+			if out[k], err = r.replace(v); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(input))
+		for k, v := range input {
+			key, ok := k.(string)
+			if !ok {
+				return nil, errors.New("Unrecognized parameter type")
+			}
+			var err error
+			if out[key], err = r.replace(v); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	default:
+		// any other type, return as is
+		return input, nil
+	}
+}
+
+// replaceText replaces the references found in a string
+func (r *resolver) replaceText(input string) (string, error) {
+	var err error
+	output := localParameterRegex.ReplaceAllStringFunc(input, func(match string) string {
+		if err != nil {
+			return match
+		}
+		reference := localParameterRegex.FindStringSubmatch(match)[1]
+		var value string
+		value, err = r.resolve(reference)
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// resolve returns the value of a reference of the format source:name
+func (r *resolver) resolve(reference string) (string, error) {
+	if value, ok := r.values[reference]; ok {
+		return value, nil
+	}
+	source, name := splitReference(reference)
+	if source == SourceFile {
+		if !filepath.IsAbs(name) {
+			return "", fmt.Errorf("local parameter %s must reference an absolute file path", reference)
+		}
+		// the cleaned path is checked against the allowlist so that it cannot be escaped with ..
+		name = filepath.Clean(name)
+	}
+	if !isAllowed(source+":"+name, r.allowlist) {
+		return "", fmt.Errorf("local parameter %s is not allowed by the LocalParameterSources of the agent configuration", reference)
+	}
+	isSecret := isAllowed(source+":"+name, r.secretSources)
+	if source == SourceFile {
+		// the file the path resolves to is checked as well so that the allowlist cannot be escaped with a symbolic link
+		resolvedName, err := filepath.EvalSymlinks(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve local parameter %s: %v", reference, err)
+		}
+		if !isAllowed(source+":"+resolvedName, r.allowlist) {
+			return "", fmt.Errorf("local parameter %s is not allowed by the LocalParameterSources of the agent configuration", reference)
+		}
+		name = resolvedName
+		isSecret = isSecret || isAllowed(source+":"+resolvedName, r.secretSources)
+	}
+
+	var value string
+	var err error
+	switch source {
+	case SourceEnv:
+		value, err = resolveEnv(name)
+	case SourceFile:
+		value, err = resolveFile(name)
+	case SourceIMDS:
+		value, err = resolveIMDS(name)
+	case SourceAgent:
+		value, err = resolveAgent(r.context, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve local parameter %s: %v", reference, err)
+	}
+	if isSecret {
+		// the resolved inputs are persisted in the document state, the output must not reveal them either
+		r.secretValues = append(r.secretValues, value)
+	}
+	r.context.Log().Debugf("Resolved local parameter %s", reference)
+	r.values[reference] = value
+	return value, nil
+}
+
+// splitReference splits a reference into its source and the name of the value within the source
+func splitReference(reference string) (source string, name string) {
+	parts := strings.SplitN(reference, ":", 2)
+	return parts[0], parts[1]
+}
+
+// isAllowed returns whether the allowlist contains the reference, entries ending with * allow any reference with the same prefix
+func isAllowed(reference string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(reference, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		} else if reference == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveEnv(name string) (string, error) {
+	if !envNameRegex.MatchString(name) {
+		return "", fmt.Errorf("%s is not a valid environment variable name", name)
+	}
+	value, ok := lookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func resolveFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxFileSize {
+		return "", fmt.Errorf("file is larger than %d bytes", maxFileSize)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func resolveIMDS(path string) (string, error) {
+	if !imdsPathRegex.MatchString(path) {
+		return "", fmt.Errorf("%s is not a valid instance metadata path", path)
+	}
+	return getInstanceMetadata(path)
+}
+
+// ec2InstanceMetadata gets a value from the instance metadata service
+func ec2InstanceMetadata(path string) (string, error) {
+	return ec2metadata.New(session.New(aws.NewConfig().WithMaxRetries(3))).GetMetadata(path)
+}
+
+func resolveAgent(context context.T, key string) (string, error) {
+	getValue, ok := agentValues[key]
+	if !ok {
+		return "", fmt.Errorf("unknown agent value %s", key)
+	}
+	return getValue(context)
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package localparameters
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(allowlist ...string) *context.Mock {
+	return newContextWithSecretSources(nil, allowlist...)
+}
+
+func newContextWithSecretSources(secretSources []string, allowlist ...string) *context.Mock {
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.LocalParameterSources = allowlist
+	config.Ssm.LocalParameterSecretSources = secretSources
+	return context.NewMockDefaultWithConfig(config)
+}
+
+func TestTextContainsLocalParameters(t *testing.T) {
+	assert.True(t, TextContainsLocalParameters("{{ env:HOME }}"))
+	assert.True(t, TextContainsLocalParameters("version {{file:/etc/app/version}}"))
+	assert.True(t, TextContainsLocalParameters("{{ imds:placement/region }}"))
+	assert.True(t, TextContainsLocalParameters("{{ agent:instanceId }}"))
+	assert.False(t, TextContainsLocalParameters("{{ ssm:/app/version }}"))
+	assert.False(t, TextContainsLocalParameters("{{ home }}"))
+	assert.False(t, TextContainsLocalParameters("env:HOME"))
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("APP_MODE", "fast")
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	versionFile := filepath.Join(tempDir, "version")
+	assert.NoError(t, os.WriteFile(versionFile, []byte("1.2.3\n"), 0600))
+	getInstanceMetadata = func(path string) (string, error) {
+		assert.Equal(t, "placement/region", path)
+		return "us-west-2", nil
+	}
+	defer func() { getInstanceMetadata = ec2InstanceMetadata }()
+
+	input := map[string]interface{}{
+		"runCommand": []interface{}{"run --mode {{ env:APP_MODE }} --version {{file:" + versionFile + "}}"},
+		"settings": []map[string]interface{}{
+			{"region": "{{ imds:placement/region }}", "instance": "{{ agent:instanceId }}", "count": 2},
+		},
+		"names": []string{"{{ agent:region }}"},
+	}
+	output, _, err := Resolve(newContext("env:APP_*", "file:"+tempDir+"/*", "imds:placement/*", "agent:*"), input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"runCommand": []interface{}{"run --mode fast --version 1.2.3"},
+		"settings": []map[string]interface{}{
+			{"region": "us-west-2", "instance": "i-123123123", "count": 2},
+		},
+		"names": []string{"us-east-1"},
+	}, output)
+}
+
+func TestResolveWithoutLocalParameters(t *testing.T) {
+	input := map[string]interface{}{"runCommand": []interface{}{"echo {{ ssm:/app/version }}"}, "timeoutSeconds": 60}
+
+	output, _, err := Resolve(newContext(), input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input, output)
+}
+
+func TestResolveErrors(t *testing.T) {
+	getInstanceMetadata = func(path string) (string, error) {
+		return "", errors.New("metadata is not available")
+	}
+	defer func() { getInstanceMetadata = ec2InstanceMetadata }()
+	allowlist := []string{"env:*", "file:/etc/app/*", "imds:*", "agent:*"}
+
+	testCases := map[string]string{
+		"{{ env:APP_MODE }}":              "",
+		"{{ env:SSM_LOCAL_PARAM_UNSET }}": "failed to resolve local parameter env:SSM_LOCAL_PARAM_UNSET: environment variable SSM_LOCAL_PARAM_UNSET is not set",
+		"{{ env:1ABC }}":                  "failed to resolve local parameter env:1ABC: 1ABC is not a valid environment variable name",
+		"{{ file:/etc/app/../shadow }}":   "local parameter file:/etc/app/../shadow is not allowed by the LocalParameterSources of the agent configuration",
+		"{{ file:etc/app/version }}":      "local parameter file:etc/app/version must reference an absolute file path",
+		"{{ file:/etc/app/missing }}":     "failed to resolve local parameter file:/etc/app/missing: lstat /etc/app: no such file or directory",
+		"{{ imds:placement/region }}":     "failed to resolve local parameter imds:placement/region: metadata is not available",
+		"{{ agent:hostname }}":            "failed to resolve local parameter agent:hostname: unknown agent value hostname",
+	}
+	t.Setenv("APP_MODE", "fast")
+	for input, message := range testCases {
+		_, _, err := Resolve(newContext(allowlist...), input)
+		if message == "" {
+			assert.NoError(t, err, input)
+		} else {
+			assert.EqualError(t, err, message, input)
+		}
+	}
+
+	_, _, err := Resolve(newContext("env:HOME"), "{{ env:APP_MODE }}")
+	assert.EqualError(t, err, "local parameter env:APP_MODE is not allowed by the LocalParameterSources of the agent configuration")
+
+	// local parameters are not resolved unless the agent configuration allows some of them
+	output, _, err := Resolve(newContext(), "{{ env:APP_MODE }}")
+	assert.NoError(t, err)
+	assert.Equal(t, "{{ env:APP_MODE }}", output)
+}
+
+func TestResolveFileThroughSymbolicLink(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	allowedDir := filepath.Join(tempDir, "app")
+	assert.NoError(t, os.Mkdir(allowedDir, 0700))
+	secretFile := filepath.Join(tempDir, "shadow")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("root:secret"), 0600))
+	assert.NoError(t, os.Symlink(secretFile, filepath.Join(allowedDir, "version")))
+
+	_, _, err = Resolve(newContext("file:"+allowedDir+"/*"), "{{ file:"+allowedDir+"/version }}")
+
+	assert.EqualError(t, err, "local parameter file:"+allowedDir+"/version is not allowed by the LocalParameterSources of the agent configuration")
+}
+
+func TestResolveReturnsValuesOfSecretSourcesAsSecrets(t *testing.T) {
+	t.Setenv("APP_TOKEN", "token-value")
+	t.Setenv("APP_VERSION", "1.2.3")
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	passwordFile := filepath.Join(tempDir, "password")
+	assert.NoError(t, os.WriteFile(passwordFile, []byte("file-value\n"), 0600))
+	versionFile := filepath.Join(tempDir, "version")
+	assert.NoError(t, os.WriteFile(versionFile, []byte("2.0.0\n"), 0600))
+	context := newContextWithSecretSources([]string{"env:APP_TOKEN", "file:" + passwordFile}, "env:APP_*", "file:"+tempDir+"/*", "agent:*")
+
+	output, secretValues, err := Resolve(context, "{{ env:APP_TOKEN }} {{ file:"+passwordFile+" }} {{ env:APP_VERSION }} {{ file:"+versionFile+" }} {{ agent:instanceId }} {{ env:APP_TOKEN }}")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "token-value file-value 1.2.3 2.0.0 i-123123123 token-value", output)
+	assert.Equal(t, []string{"token-value", "file-value"}, secretValues)
+}
+
+func TestResolveReturnsNoSecretsWithoutSecretSources(t *testing.T) {
+	t.Setenv("APP_VERSION", "1.2.3")
+
+	output, secretValues, err := Resolve(newContext("env:APP_*"), "version {{ env:APP_VERSION }}")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "version 1.2.3", output)
+	assert.Empty(t, secretValues)
+}
+
+func TestResolveReturnsFilesLinkedToSecretSourcesAsSecrets(t *testing.T) {
+	tempDir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	secretDir := filepath.Join(tempDir, "secrets")
+	assert.NoError(t, os.Mkdir(secretDir, 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(secretDir, "password"), []byte("file-value"), 0600))
+	assert.NoError(t, os.Symlink(filepath.Join(secretDir, "password"), filepath.Join(tempDir, "password")))
+	context := newContextWithSecretSources([]string{"file:" + secretDir + "/*"}, "file:"+tempDir+"/*")
+
+	output, secretValues, err := Resolve(context, "{{ file:"+tempDir+"/password }}")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "file-value", output)
+	assert.Equal(t, []string{"file-value"}, secretValues)
+}
+
+func TestIsAllowed(t *testing.T) {
+	allowlist := []string{"env:HOME", "file:/etc/app/*"}
+
+	assert.True(t, isAllowed("env:HOME", allowlist))
+	assert.False(t, isAllowed("env:HOMEPATH", allowlist))
+	assert.True(t, isAllowed("file:/etc/app/version", allowlist))
+	assert.False(t, isAllowed("file:/etc/application", allowlist))
+	assert.False(t, isAllowed("env:HOME", nil))
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// validateParallelGroups checks that the steps sharing a parallelGroup are consecutive
+// and that none of them changes the order of the steps once the group is done.
+func validateParallelGroups(mainSteps []*contracts.InstancePluginConfig) error {
+	closedGroups := make(map[string]bool)
+	previousGroup := ""
+	for _, step := range mainSteps {
+		group := getStepInput(step.Inputs, contracts.ParallelGroupModifier)
+		if group != previousGroup && previousGroup != "" {
+			closedGroups[previousGroup] = true
+		}
+		previousGroup = group
+		if group == "" {
+			continue
+		}
+		if closedGroups[group] {
+			return fmt.Errorf("steps of parallelGroup %s must be consecutive, step %s is not", group, step.Name)
+		}
+		for _, modifier := range []string{contracts.NextStepModifier, contracts.IsEndModifier, contracts.FinallyStepModifier} {
+			if getStepInput(step.Inputs, modifier) != "" {
+				return fmt.Errorf("step %s of parallelGroup %s cannot declare %s", step.Name, group, modifier)
+			}
+		}
+		for _, modifier := range []string{contracts.OnSuccessModifier, contracts.OnFailureModifier} {
+			if strings.HasPrefix(getStepInput(step.Inputs, modifier), contracts.ModifierValueStepPrefix) {
+				return fmt.Errorf("step %s of parallelGroup %s cannot branch to another step with %s", step.Name, group, modifier)
+			}
+		}
+	}
+	return nil
+}
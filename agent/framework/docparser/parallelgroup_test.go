@@ -0,0 +1,77 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateParallelGroups(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mainSteps []*contracts.InstancePluginConfig
+		err       string
+	}{
+		{
+			name: "consecutive groups",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"parallelGroup": "g1"}),
+				newBranchingStep("b", map[string]interface{}{"parallelGroup": "g1", "onFailure": "exit"}),
+				newBranchingStep("c", map[string]interface{}{"parallelGroup": "g2"}),
+				newBranchingStep("d", map[string]interface{}{}),
+			},
+		},
+		{
+			name: "group split by another step",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"parallelGroup": "g1"}),
+				newBranchingStep("b", map[string]interface{}{}),
+				newBranchingStep("c", map[string]interface{}{"parallelGroup": "g1"}),
+			},
+			err: "steps of parallelGroup g1 must be consecutive, step c is not",
+		},
+		{
+			name: "nextStep in group",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"parallelGroup": "g1", "nextStep": "c"}),
+				newBranchingStep("b", map[string]interface{}{"parallelGroup": "g1"}),
+				newBranchingStep("c", map[string]interface{}{}),
+			},
+			err: "step a of parallelGroup g1 cannot declare nextStep",
+		},
+		{
+			name: "branch in group",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"parallelGroup": "g1"}),
+				newBranchingStep("b", map[string]interface{}{"parallelGroup": "g1", "onFailure": "step:c"}),
+				newBranchingStep("c", map[string]interface{}{}),
+			},
+			err: "step b of parallelGroup g1 cannot branch to another step with onFailure",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateParallelGroups(testCase.mainSteps)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
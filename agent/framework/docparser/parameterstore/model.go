@@ -27,8 +27,9 @@ type GetParameterResponse struct {
 
 // Parameter contains info about the parameter
 type Parameter struct {
-	Name    string
-	Type    string
-	Value   string
-	Version int64
+	Name     string
+	Type     string
+	Value    string
+	Version  int64
+	Selector string
 }
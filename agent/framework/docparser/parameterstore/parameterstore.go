@@ -28,6 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/parametercache"
 )
 
 const (
@@ -219,7 +220,7 @@ func getSSMParameterValues(context context.T, ssmParams []string) (map[string]Pa
 		}
 	}
 
-	if result, err = callParameterService(context, paramNames); err != nil {
+	if result, err = getParameters(context, paramNames); err != nil {
 		return nil, err
 	}
 
@@ -294,6 +295,38 @@ func getSSMParameterValues(context context.T, ssmParams []string) (map[string]Pa
 	return resolvedParamMap, nil
 }
 
+// getParameters returns the parameters from the parameter cache, the parameters that are not cached are requested from the service
+func getParameters(context context.T, paramNames []string) (*GetParametersResponse, error) {
+	ssmConfig := context.AppConfig().Ssm
+	cachedParameters, missingParamNames := parametercache.Get(ssmConfig, paramNames, false)
+
+	result := &GetParametersResponse{}
+	if len(missingParamNames) > 0 {
+		response, err := callParameterService(context, missingParamNames)
+		if err != nil {
+			return nil, err
+		}
+		result.Parameters = append(result.Parameters, response.Parameters...)
+		result.InvalidParameters = append(result.InvalidParameters, response.InvalidParameters...)
+
+		parametersToCache := make([]parametercache.Parameter, len(response.Parameters))
+		for i, paramObj := range response.Parameters {
+			parametersToCache[i] = parametercache.Parameter(paramObj)
+		}
+		parametercache.Put(ssmConfig, parametersToCache, false)
+	}
+
+	for _, paramName := range paramNames {
+		if paramObj, ok := cachedParameters[paramName]; ok {
+			result.Parameters = append(result.Parameters, Parameter(paramObj))
+		}
+	}
+	if len(cachedParameters) > 0 {
+		context.Log().Debugf("Resolved %d SSM parameters from the parameter cache", len(cachedParameters))
+	}
+	return result, nil
+}
+
 // callGetParameters makes a GetParameters API call to the service
 func callGetParameters(context context.T, paramNames []string) (*GetParametersResponse, error) {
 	log := context.Log()
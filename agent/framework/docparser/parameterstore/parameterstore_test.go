@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	mockcontext "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/parametercache"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -169,6 +170,45 @@ func testResolveMethodWithInvalidCase(t *testing.T, testCase StringTestCase) {
 	assert.NotNil(t, err)
 }
 
+func TestResolveWithParameterCache(t *testing.T) {
+	defer parametercache.Clear()
+	calls := [][]string{}
+	callParameterService = func(
+		context context.T,
+		paramNames []string) (*GetParametersResponse, error) {
+		calls = append(calls, paramNames)
+		result := GetParametersResponse{}
+		for _, paramName := range paramNames {
+			switch paramName {
+			case "app.version":
+				result.Parameters = append(result.Parameters, Parameter{Name: "app.version", Type: ParamTypeString, Value: "1.2.3", Version: 4})
+			case "app.version:2":
+				result.Parameters = append(result.Parameters, Parameter{Name: "app.version", Type: ParamTypeString, Value: "1.2.0", Version: 2, Selector: ":2"})
+			}
+		}
+		return &result, nil
+	}
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.ParameterCacheTTLSeconds = 60
+	mockContext := mockcontext.NewMockDefaultWithConfig(config)
+
+	for i := 0; i < 2; i++ {
+		result, err := Resolve(mockContext, "version {{ssm:app.version}}")
+		assert.NoError(t, err)
+		assert.Equal(t, "version 1.2.3", result)
+	}
+	assert.Equal(t, [][]string{{"app.version"}}, calls)
+
+	// pinning a version invalidates the cached latest value
+	result, err := Resolve(mockContext, "version {{ssm:app.version:2}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "version 1.2.0", result)
+	result, err = Resolve(mockContext, "version {{ssm:app.version}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "version 1.2.3", result)
+	assert.Equal(t, [][]string{{"app.version"}, {"app.version:2"}, {"app.version"}}, calls)
+}
+
 func testGetValidSSMParamRegexCompiler(t *testing.T) {
 	validSSMParam, _ := getValidSSMParamRegexCompiler(logger, "test.p1")
 	assert.True(t, validSSMParam.MatchString("test.p1"), "test.p1 should not match test.p1")
@@ -0,0 +1,73 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package jsonschemaparamvalidator is responsible for validating StringMap and MapList parameter values
+// with the JSON Schema given in the document.
+package jsonschemaparamvalidator
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	paramvalidatorutils "github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/utils"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+type jsonSchemaParamValidator struct {
+	applicableTypes map[paramvalidatorutils.DocumentParamType]struct{}
+}
+
+// GetJSONSchemaValidator returns the jsonSchemaParamValidator struct reference
+func GetJSONSchemaValidator() *jsonSchemaParamValidator {
+	return &jsonSchemaParamValidator{
+		applicableTypes: map[paramvalidatorutils.DocumentParamType]struct{}{
+			paramvalidatorutils.ParamTypeStringMap: {},
+			paramvalidatorutils.ParamTypeMapList:   {},
+		},
+	}
+}
+
+// Validate validates the parameter value with the JSON Schema given in the document
+func (jpv *jsonSchemaParamValidator) Validate(log log.T, parameterValue interface{}, parameter *contracts.Parameter) error {
+	if parameter.Schema == nil || parameterValue == nil {
+		return nil
+	}
+	paramType := paramvalidatorutils.DocumentParamType(parameter.ParamType)
+	if _, ok := jpv.applicableTypes[paramType]; !ok {
+		return nil
+	}
+
+	log.Debugf("Started %v validation", jpv.GetName())
+	var schema map[string]interface{}
+	if err := jsonutil.Remarshal(parameter.Schema, &schema); err != nil || schema == nil {
+		return fmt.Errorf("schema of the parameter is not a JSON object")
+	}
+	if err := checkSchemaKeywords(schema, "$"); err != nil {
+		return fmt.Errorf("schema of the parameter is not supported: %v", err)
+	}
+	value, err := paramvalidatorutils.DecodeStructuredValue(paramType, parameterValue)
+	if err != nil {
+		// the type of the value is verified by the type validator
+		return nil
+	}
+	if err = validateSchema(schema, value, "$"); err != nil {
+		return fmt.Errorf("parameter value does not match the schema: %v", err)
+	}
+	return nil
+}
+
+// GetName returns the name of param validator
+func (jpv *jsonSchemaParamValidator) GetName() string {
+	return "JSONSchemaParamValidator"
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package jsonschemaparamvalidator is responsible for validating StringMap and MapList parameter values
+// with the JSON Schema given in the document.
+package jsonschemaparamvalidator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const volumeSchema = `{
+	"type": "object",
+	"required": ["device", "size"],
+	"additionalProperties": false,
+	"properties": {
+		"device": {"type": "string", "pattern": "^/dev/"},
+		"size": {"type": "integer", "minimum": 1, "maximum": 1024},
+		"fileSystem": {"enum": ["ext4", "xfs"]},
+		"options": {"type": "array", "maxItems": 2, "items": {"type": "string", "minLength": 1}}
+	}
+}`
+
+func TestJSONSchemaParamValidator(t *testing.T) {
+	var schema interface{}
+	assert.NoError(t, json.Unmarshal([]byte(volumeSchema), &schema))
+	testCases := []struct {
+		name      string
+		paramType string
+		value     interface{}
+		err       string
+	}{
+		{"valid map", "StringMap", map[string]interface{}{"device": "/dev/xvdf", "size": float64(10), "fileSystem": "xfs"}, ""},
+		{"valid json text", "StringMap", `{"device":"/dev/xvdf","size":10,"options":["noatime"]}`, ""},
+		{"valid map list", "MapList", `[{"device":"/dev/xvdf","size":10},{"device":"/dev/xvdg","size":20}]`, ""},
+		{"missing property", "StringMap", `{"device":"/dev/xvdf"}`, "parameter value does not match the schema: $.size is required"},
+		{"additional property", "StringMap", `{"device":"/dev/xvdf","size":1,"label":"data"}`, "parameter value does not match the schema: $.label is not allowed"},
+		{"wrong type", "StringMap", `{"device":"/dev/xvdf","size":"10"}`, "parameter value does not match the schema: $.size must be of type integer"},
+		{"below minimum", "StringMap", `{"device":"/dev/xvdf","size":0}`, "parameter value does not match the schema: $.size must be greater than or equal to 1"},
+		{"pattern", "StringMap", `{"device":"xvdf","size":1}`, "parameter value does not match the schema: $.device does not match the pattern /^/dev//"},
+		{"enum", "StringMap", `{"device":"/dev/xvdf","size":1,"fileSystem":"ntfs"}`, "parameter value does not match the schema: $.fileSystem must be one of [\"ext4\",\"xfs\"]"},
+		{"too many items", "StringMap", `{"device":"/dev/xvdf","size":1,"options":["a","b","c"]}`, "parameter value does not match the schema: $.options must have at most 2 items"},
+		{"empty item", "StringMap", `{"device":"/dev/xvdf","size":1,"options":[""]}`, "parameter value does not match the schema: $.options[0] must be at least 1 characters long"},
+		{"map list item", "MapList", `[{"device":"/dev/xvdf","size":10},{"device":"/dev/xvdg"}]`, "parameter value does not match the schema: $[1].size is required"},
+		{"invalid json is left to the type validator", "StringMap", `{`, ""},
+		{"other types are ignored", "String", `{}`, ""},
+	}
+	validator := GetJSONSchemaValidator()
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			parameter := &contracts.Parameter{ParamType: testCase.paramType, Schema: schema}
+			if testCase.paramType == "MapList" {
+				parameter.Schema = map[string]interface{}{"type": "array", "items": schema}
+			}
+			err := validator.Validate(logmocks.NewMockLog(), testCase.value, parameter)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaParamValidatorRejectsUnsupportedKeywords(t *testing.T) {
+	testCases := map[string]string{
+		`{"type": "object", "oneOf": [{"required": ["device"]}, {"required": ["label"]}]}`:               "$ uses the unsupported keyword oneOf",
+		`{"type": "object", "properties": {"device": {"type": "string", "format": "uri"}}}`:              "$.device uses the unsupported keyword format",
+		`{"type": "object", "additionalProperties": {"not": {"type": "null"}}}`:                          "$.* uses the unsupported keyword not",
+		`{"type": "array", "items": {"$ref": "#/definitions/volume"}}`:                                   "$[] uses the unsupported keyword $ref",
+		`{"type": "object", "properties": {"size": {"anyOf": [{"type": "integer"}, {"type": "null"}]}}}`: "$.size uses the unsupported keyword anyOf",
+		`{"allOf": [{"type": "object"}]}`:                                                                "$ uses the unsupported keyword allOf",
+	}
+	validator := GetJSONSchemaValidator()
+	for schemaText, expectedErr := range testCases {
+		var schema interface{}
+		assert.NoError(t, json.Unmarshal([]byte(schemaText), &schema))
+		// the schema is rejected even if the value does not reach the unsupported keyword
+		err := validator.Validate(logmocks.NewMockLog(), `{}`, &contracts.Parameter{ParamType: "StringMap", Schema: schema})
+		assert.EqualError(t, err, "schema of the parameter is not supported: "+expectedErr, schemaText)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package jsonschemaparamvalidator is responsible for validating StringMap and MapList parameter values
+// with the JSON Schema given in the document.
+package jsonschemaparamvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// unsupportedKeywords are the JSON Schema keywords the validator does not implement, ignoring them
+// would accept values the author of the document meant to reject
+var unsupportedKeywords = []string{"$ref", "allOf", "anyOf", "format", "not", "oneOf"}
+
+// checkSchemaKeywords returns an error if the schema or one of its subschemas uses an unsupported keyword
+func checkSchemaKeywords(schema map[string]interface{}, path string) error {
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := schema[keyword]; ok {
+			return fmt.Errorf("%s uses the unsupported keyword %s", path, keyword)
+		}
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propertySchema, ok := properties[name].(map[string]interface{}); ok {
+				if err := checkSchemaKeywords(propertySchema, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		if err := checkSchemaKeywords(additional, path+".*"); err != nil {
+			return err
+		}
+	}
+	if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+		return checkSchemaKeywords(itemSchema, path+"[]")
+	}
+	return nil
+}
+
+// validateSchema validates a decoded JSON value against a JSON Schema. The keywords type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems, minLength, maxLength, pattern,
+// minimum and maximum are supported, checkSchemaKeywords rejects the schemas using unsupportedKeywords.
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if schemaType, ok := schema["type"]; ok {
+		if err := validateType(schemaType, value, path); err != nil {
+			return err
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		if !containsValue(enum, value) {
+			return fmt.Errorf("%s must be one of %v", path, formatValue(enum))
+		}
+	}
+	if constValue, ok := schema["const"]; ok && !reflect.DeepEqual(constValue, value) {
+		return fmt.Errorf("%s must be %v", path, formatValue(constValue))
+	}
+
+	switch input := value.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, input, path)
+	case []interface{}:
+		return validateArray(schema, input, path)
+	case string:
+		return validateString(schema, input, path)
+	case float64:
+		return validateNumber(schema, input, path)
+	}
+	return nil
+}
+
+func validateType(schemaType interface{}, value interface{}, path string) error {
+	var allowedTypes []string
+	switch typed := schemaType.(type) {
+	case string:
+		allowedTypes = []string{typed}
+	case []interface{}:
+		for _, allowedType := range typed {
+			allowedTypes = append(allowedTypes, fmt.Sprint(allowedType))
+		}
+	default:
+		return fmt.Errorf("%s has an invalid type in the schema", path)
+	}
+	for _, allowedType := range allowedTypes {
+		if isOfType(allowedType, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be of type %s", path, strings.Join(allowedTypes, " or "))
+}
+
+func isOfType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func validateObject(schema map[string]interface{}, input map[string]interface{}, path string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := input[fmt.Sprint(name)]; !present {
+				return fmt.Errorf("%s.%v is required", path, name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	// keys are sorted so that the first error is always the same one
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		propertyPath := path + "." + key
+		if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+			if err := validateSchema(propertySchema, input[key], propertyPath); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s is not allowed", propertyPath)
+			}
+		case map[string]interface{}:
+			if err := validateSchema(additional, input[key], propertyPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateArray(schema map[string]interface{}, input []interface{}, path string) error {
+	if minItems, ok := schemaNumber(schema, "minItems"); ok && float64(len(input)) < minItems {
+		return fmt.Errorf("%s must have at least %v items", path, minItems)
+	}
+	if maxItems, ok := schemaNumber(schema, "maxItems"); ok && float64(len(input)) > maxItems {
+		return fmt.Errorf("%s must have at most %v items", path, maxItems)
+	}
+	if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+		for index, item := range input {
+			if err := validateSchema(itemSchema, item, fmt.Sprintf("%s[%d]", path, index)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(schema map[string]interface{}, input string, path string) error {
+	length := float64(utf8.RuneCountInString(input))
+	if minLength, ok := schemaNumber(schema, "minLength"); ok && length < minLength {
+		return fmt.Errorf("%s must be at least %v characters long", path, minLength)
+	}
+	if maxLength, ok := schemaNumber(schema, "maxLength"); ok && length > maxLength {
+		return fmt.Errorf("%s must be at most %v characters long", path, maxLength)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		matcher, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s has an invalid pattern in the schema: %v", path, err)
+		}
+		if !matcher.MatchString(input) {
+			return fmt.Errorf("%s does not match the pattern /%v/", path, pattern)
+		}
+	}
+	return nil
+}
+
+func validateNumber(schema map[string]interface{}, input float64, path string) error {
+	if minimum, ok := schemaNumber(schema, "minimum"); ok && input < minimum {
+		return fmt.Errorf("%s must be greater than or equal to %v", path, minimum)
+	}
+	if maximum, ok := schemaNumber(schema, "maximum"); ok && input > maximum {
+		return fmt.Errorf("%s must be less than or equal to %v", path, maximum)
+	}
+	return nil
+}
+
+// schemaNumber returns the numeric value of a schema keyword
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	number, ok := schema[keyword].(float64)
+	return number, ok
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	if content, err := json.Marshal(value); err == nil {
+		return string(content)
+	}
+	return fmt.Sprint(value)
+}
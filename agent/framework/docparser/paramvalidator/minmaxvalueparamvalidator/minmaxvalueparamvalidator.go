@@ -0,0 +1,77 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package minmaxvalueparamvalidator is responsible for validating parameter value
+// with the min max value restriction given in the document for Integer parameters.
+package minmaxvalueparamvalidator
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	paramvalidatorutils "github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/utils"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+type minMaxValueParamValidator struct {
+}
+
+// GetMinMaxValueValidator returns the minMaxValueParamValidator struct reference
+func GetMinMaxValueValidator() *minMaxValueParamValidator {
+	return &minMaxValueParamValidator{}
+}
+
+// Validate validates the parameter value with min-max value restriction given in the document
+func (mpv *minMaxValueParamValidator) Validate(log log.T, parameterValue interface{}, parameter *contracts.Parameter) error {
+	// pass when min value and max value not set in the document parameter
+	if parameter.MinValue == "" && parameter.MaxValue == "" {
+		return nil
+	}
+	// min and max value restriction only applies to integer parameters
+	if paramvalidatorutils.DocumentParamType(parameter.ParamType) != paramvalidatorutils.ParamTypeInteger || parameterValue == nil {
+		return nil
+	}
+
+	log.Debugf("Started %v validation", mpv.GetName())
+	coercedValue, err := paramvalidatorutils.CoerceParameterValue(paramvalidatorutils.ParamTypeInteger, parameterValue)
+	if err != nil {
+		// the type of the value is verified by the type validator
+		return nil
+	}
+	value := coercedValue.(int)
+
+	if parameter.MinValue != "" {
+		minValue, err := parameter.MinValue.Int64()
+		if err != nil {
+			return fmt.Errorf("minValue /%v/ is not a valid Integer", parameter.MinValue)
+		}
+		if int64(value) < minValue {
+			return fmt.Errorf("parameter value /%v/ is less than the min value /%v/", value, minValue)
+		}
+	}
+	if parameter.MaxValue != "" {
+		maxValue, err := parameter.MaxValue.Int64()
+		if err != nil {
+			return fmt.Errorf("maxValue /%v/ is not a valid Integer", parameter.MaxValue)
+		}
+		if int64(value) > maxValue {
+			return fmt.Errorf("parameter value /%v/ is greater than the max value /%v/", value, maxValue)
+		}
+	}
+	return nil
+}
+
+// GetName returns the name of param validator
+func (mpv *minMaxValueParamValidator) GetName() string {
+	return "MinMaxValueParamValidator"
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package minmaxvalueparamvalidator is responsible for validating parameter value
+// with the min max value restriction given in the document for Integer parameters.
+package minmaxvalueparamvalidator
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinMaxValueParamValidator(t *testing.T) {
+	parameter := &contracts.Parameter{ParamType: "Integer", MinValue: "-10", MaxValue: "3600"}
+	testCases := []struct {
+		name  string
+		value interface{}
+		err   string
+	}{
+		{"within limits", "60", ""},
+		{"min value", float64(-10), ""},
+		{"max value", 3600, ""},
+		{"below min value", "-11", "parameter value /-11/ is less than the min value /-10/"},
+		{"above max value", float64(3601), "parameter value /3601/ is greater than the max value /3600/"},
+		{"invalid value is left to the type validator", "abc", ""},
+	}
+	validator := GetMinMaxValueValidator()
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validator.Validate(logmocks.NewMockLog(), testCase.value, parameter)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestMinMaxValueParamValidatorIgnoresOtherTypes(t *testing.T) {
+	validator := GetMinMaxValueValidator()
+
+	err := validator.Validate(logmocks.NewMockLog(), "100", &contracts.Parameter{ParamType: "String", MaxValue: "10"})
+
+	assert.NoError(t, err)
+}
+
+func TestMinMaxValueParamValidatorInvalidLimit(t *testing.T) {
+	validator := GetMinMaxValueValidator()
+
+	err := validator.Validate(logmocks.NewMockLog(), "100", &contracts.Parameter{ParamType: "Integer", MaxValue: "1.5"})
+
+	assert.EqualError(t, err, "maxValue /1.5/ is not a valid Integer")
+}
@@ -18,8 +18,11 @@ package paramvalidator
 import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/allowedregexparamvalidator"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/allowedvalueparamvalidator"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/jsonschemaparamvalidator"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/minmaxcharparamvalidator"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/minmaxitemparamvalidator"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/minmaxvalueparamvalidator"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/typeparamvalidator"
 )
 
 var mandatoryValidators []ParameterValidator
@@ -28,8 +31,12 @@ var optionalValidators []ParameterValidator
 // GetMandatoryValidators returns all the registered mandatory parameter validators
 func GetMandatoryValidators() []ParameterValidator {
 	if mandatoryValidators == nil {
-		mandatoryValidators = make([]ParameterValidator, 1)
+		mandatoryValidators = make([]ParameterValidator, 4)
 		mandatoryValidators[0] = allowedregexparamvalidator.GetAllowedRegexValidator()
+		// the service does not know the parameter types and restrictions below, the agent always verifies them
+		mandatoryValidators[1] = typeparamvalidator.GetTypeValidator()
+		mandatoryValidators[2] = minmaxvalueparamvalidator.GetMinMaxValueValidator()
+		mandatoryValidators[3] = jsonschemaparamvalidator.GetJSONSchemaValidator()
 	}
 	return mandatoryValidators
 }
@@ -32,11 +32,11 @@ func TestParamValidatorTestSuite(t *testing.T) {
 }
 
 func (suite *ParamValidatorTestSuite) TestParamValidator_InitializationCountCheck() {
-	currentValidatorCount := 4
-	uniqueValidators := 4
+	currentValidatorCount := 7
+	uniqueValidators := 7
 	uniqueValidatorMap := make(map[string]struct{})
 	allValidators := GetMandatoryValidators()
-	assert.Equal(suite.T(), len(allValidators), 1) // mandatory validator count check
+	assert.Equal(suite.T(), len(allValidators), 4) // mandatory validator count check
 	allValidators = append(allValidators, GetOptionalValidators()...)
 	for _, paramValidator := range allValidators {
 		uniqueValidatorMap[paramValidator.GetName()] = struct{}{}
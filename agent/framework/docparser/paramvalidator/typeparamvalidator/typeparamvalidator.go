@@ -0,0 +1,47 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package typeparamvalidator is responsible for validating that the parameter value
+// can be converted to the parameter type given in the document.
+package typeparamvalidator
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	paramvalidatorutils "github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator/utils"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+type typeParamValidator struct {
+}
+
+// GetTypeValidator returns the typeParamValidator struct reference
+func GetTypeValidator() *typeParamValidator {
+	return &typeParamValidator{}
+}
+
+// Validate validates that Integer, Boolean, StringMap and MapList parameter values match their type
+func (tpv *typeParamValidator) Validate(log log.T, parameterValue interface{}, parameter *contracts.Parameter) error {
+	// missing values are reported by the caller
+	if parameterValue == nil {
+		return nil
+	}
+
+	log.Debugf("Started %v validation", tpv.GetName())
+	_, err := paramvalidatorutils.CoerceParameterValue(paramvalidatorutils.DocumentParamType(parameter.ParamType), parameterValue)
+	return err
+}
+
+// GetName returns the name of param validator
+func (tpv *typeParamValidator) GetName() string {
+	return "TypeParamValidator"
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package typeparamvalidator is responsible for validating that the parameter value
+// can be converted to the parameter type given in the document.
+package typeparamvalidator
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeParamValidator(t *testing.T) {
+	validator := GetTypeValidator()
+	log := logmocks.NewMockLog()
+
+	assert.NoError(t, validator.Validate(log, "60", &contracts.Parameter{ParamType: "Integer"}))
+	assert.NoError(t, validator.Validate(log, true, &contracts.Parameter{ParamType: "Boolean"}))
+	assert.NoError(t, validator.Validate(log, nil, &contracts.Parameter{ParamType: "Integer"}))
+	assert.NoError(t, validator.Validate(log, "abc", &contracts.Parameter{ParamType: "String"}))
+	assert.EqualError(t, validator.Validate(log, "abc", &contracts.Parameter{ParamType: "Integer"}), "parameter value /abc/ is not a valid Integer")
+	assert.EqualError(t, validator.Validate(log, "on", &contracts.Parameter{ParamType: "Boolean"}), "parameter value /on/ is not a valid Boolean")
+	assert.EqualError(t, validator.Validate(log, "[1]", &contracts.Parameter{ParamType: "StringMap"}), "parameter value /[1]/ is not an object")
+}
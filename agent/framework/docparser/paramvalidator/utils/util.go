@@ -2,8 +2,12 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
 
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
@@ -17,7 +21,7 @@ const (
 	ParamTypeStringList DocumentParamType = "StringList"
 	// ParamTypeStringMap represents the param type StringMap
 	ParamTypeStringMap DocumentParamType = "StringMap"
-	// ParamTypeInteger represents the param type StringMap
+	// ParamTypeInteger represents the param type Integer
 	ParamTypeInteger DocumentParamType = "Integer"
 	// ParamTypeBoolean represents the param type Boolean
 	ParamTypeBoolean DocumentParamType = "Boolean"
@@ -36,3 +40,95 @@ func GetCleanedUpVal(log log.T, parameter json.Number, defaultVal int) int {
 		return int(itemVal)
 	}
 }
+
+// CoerceParameterValue converts the value of a parameter to the type declared by the document.
+// Integer values become int and Boolean values become bool, StringMap and MapList values are checked
+// to hold an object and a list of objects but are returned unchanged. Other values are returned unchanged.
+func CoerceParameterValue(paramType DocumentParamType, value interface{}) (interface{}, error) {
+	switch paramType {
+	case ParamTypeInteger:
+		return coerceInteger(value)
+	case ParamTypeBoolean:
+		return coerceBoolean(value)
+	case ParamTypeStringMap, ParamTypeMapList:
+		if _, err := DecodeStructuredValue(paramType, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// DecodeStructuredValue returns the value of a StringMap or MapList parameter as decoded JSON,
+// values passed as a JSON string are decoded.
+func DecodeStructuredValue(paramType DocumentParamType, value interface{}) (interface{}, error) {
+	var decoded interface{}
+	if text, ok := value.(string); ok {
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			return nil, fmt.Errorf("parameter value /%v/ is not valid JSON for type %v", text, paramType)
+		}
+	} else if err := jsonutil.Remarshal(value, &decoded); err != nil {
+		return nil, fmt.Errorf("parameter value /%v/ is not valid for type %v", value, paramType)
+	}
+
+	switch paramType {
+	case ParamTypeStringMap:
+		if _, ok := decoded.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("parameter value /%v/ is not an object", value)
+		}
+	case ParamTypeMapList:
+		items, ok := decoded.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parameter value /%v/ is not a list", value)
+		}
+		for _, item := range items {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("parameter value /%v/ is not a list of objects", value)
+			}
+		}
+	}
+	return decoded, nil
+}
+
+// coerceInteger converts a JSON number or a string holding an integer to int
+func coerceInteger(value interface{}) (int, error) {
+	invalidErr := fmt.Errorf("parameter value /%v/ is not a valid Integer", value)
+	switch input := value.(type) {
+	case int:
+		return input, nil
+	case int64:
+		if input > math.MaxInt32 || input < math.MinInt32 {
+			return 0, invalidErr
+		}
+		return int(input), nil
+	case float64:
+		if input != math.Trunc(input) || input > math.MaxInt32 || input < math.MinInt32 {
+			return 0, invalidErr
+		}
+		return int(input), nil
+	case json.Number:
+		return coerceInteger(input.String())
+	case string:
+		number, err := strconv.ParseInt(strings.TrimSpace(input), 10, 32)
+		if err != nil {
+			return 0, invalidErr
+		}
+		return int(number), nil
+	}
+	return 0, invalidErr
+}
+
+// coerceBoolean converts a bool or a string holding true or false to bool
+func coerceBoolean(value interface{}) (bool, error) {
+	switch input := value.(type) {
+	case bool:
+		return input, nil
+	case string:
+		if strings.EqualFold(strings.TrimSpace(input), "true") {
+			return true, nil
+		}
+		if strings.EqualFold(strings.TrimSpace(input), "false") {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("parameter value /%v/ is not a valid Boolean", value)
+}
@@ -1 +1,44 @@
 package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerceParameterValue(t *testing.T) {
+	testCases := []struct {
+		name      string
+		paramType DocumentParamType
+		value     interface{}
+		expected  interface{}
+		err       string
+	}{
+		{"integer from string", ParamTypeInteger, " 60 ", 60, ""},
+		{"integer from json number", ParamTypeInteger, float64(-5), -5, ""},
+		{"integer from document number", ParamTypeInteger, json.Number("42"), 42, ""},
+		{"integer from fraction", ParamTypeInteger, 1.5, nil, "parameter value /1.5/ is not a valid Integer"},
+		{"integer from text", ParamTypeInteger, "abc", nil, "parameter value /abc/ is not a valid Integer"},
+		{"boolean from string", ParamTypeBoolean, "True", true, ""},
+		{"boolean from bool", ParamTypeBoolean, false, false, ""},
+		{"boolean from text", ParamTypeBoolean, "yes", nil, "parameter value /yes/ is not a valid Boolean"},
+		{"string map from json", ParamTypeStringMap, `{"key":"value"}`, `{"key":"value"}`, ""},
+		{"string map from list", ParamTypeStringMap, []interface{}{"a"}, nil, "parameter value /[a]/ is not an object"},
+		{"map list from json", ParamTypeMapList, `[{"key":"value"}]`, `[{"key":"value"}]`, ""},
+		{"map list of strings", ParamTypeMapList, []interface{}{"a"}, nil, "parameter value /[a]/ is not a list of objects"},
+		{"map list from invalid json", ParamTypeMapList, `[{`, nil, "parameter value /[{/ is not valid JSON for type MapList"},
+		{"string unchanged", ParamTypeString, "60", "60", ""},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			value, err := CoerceParameterValue(testCase.paramType, testCase.value)
+			if testCase.err != "" {
+				assert.EqualError(t, err, testCase.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, value)
+		})
+	}
+}
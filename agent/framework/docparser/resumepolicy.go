@@ -0,0 +1,41 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// validateResumePolicy checks that the resumePolicy input of a step is rerun, skip or fail
+func validateResumePolicy(step *contracts.InstancePluginConfig) error {
+	switch getStepInput(step.Inputs, contracts.ResumePolicyModifier) {
+	case "", contracts.ResumePolicyRerun, contracts.ResumePolicySkip, contracts.ResumePolicyFail:
+		return nil
+	default:
+		return fmt.Errorf("%s of step %s must be %s, %s or %s", contracts.ResumePolicyModifier, step.Name,
+			contracts.ResumePolicyRerun, contracts.ResumePolicySkip, contracts.ResumePolicyFail)
+	}
+}
+
+// validateResumePolicies checks the resumePolicy input of every step
+func validateResumePolicies(steps []*contracts.InstancePluginConfig) error {
+	for _, step := range steps {
+		if err := validateResumePolicy(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
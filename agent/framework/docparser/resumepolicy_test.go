@@ -0,0 +1,36 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocument_ResumePolicy(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[` +
+		`{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"],"resumePolicy":"skip"}}],` +
+		`"finallySteps":[{"action":"aws:runShellScript","name":"cleanup","inputs":{"runCommand":["ls"],"resumePolicy":"later"}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	_, err = testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.EqualError(t, err, "resumePolicy of step cleanup must be rerun, skip or fail")
+}
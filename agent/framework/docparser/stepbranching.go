@@ -0,0 +1,153 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+const (
+	stepNotVisited = iota
+	stepInProgress
+	stepDone
+)
+
+// validateStepBranching checks that the nextStep, isEnd, onSuccess: step:<name> and onFailure: step:<name> inputs
+// of the steps reference existing steps and that no sequence of branches can run a step twice.
+func validateStepBranching(mainSteps []*contracts.InstancePluginConfig) error {
+	stepIndex := make(map[string]int, len(mainSteps))
+	for index, step := range mainSteps {
+		stepIndex[step.Name] = index
+	}
+
+	transitions := make([][]int, len(mainSteps))
+	for index, step := range mainSteps {
+		targets, err := getStepTransitions(step, index, len(mainSteps))
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if target.name == "" {
+				transitions[index] = append(transitions[index], target.index)
+				continue
+			}
+			targetIndex, found := stepIndex[target.name]
+			if !found {
+				return fmt.Errorf("%s of step %s references unknown step %s", target.modifier, step.Name, target.name)
+			}
+			transitions[index] = append(transitions[index], targetIndex)
+		}
+	}
+
+	states := make([]int, len(mainSteps))
+	var path []int
+	var visit func(index int) error
+	visit = func(index int) error {
+		states[index] = stepInProgress
+		path = append(path, index)
+		for _, next := range transitions[index] {
+			switch states[next] {
+			case stepInProgress:
+				return fmt.Errorf("step branching contains a cycle: %s", formatStepCycle(mainSteps, path, next))
+			case stepNotVisited:
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[index] = stepDone
+		return nil
+	}
+	for index := range mainSteps {
+		if states[index] == stepNotVisited {
+			if err := visit(index); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stepTransition is a step that can run after another one, identified either by name or by index
+type stepTransition struct {
+	modifier string
+	name     string
+	index    int
+}
+
+// getStepTransitions returns the steps that can run after the given step
+func getStepTransitions(step *contracts.InstancePluginConfig, index int, stepCount int) (transitions []stepTransition, err error) {
+	for _, modifier := range []string{contracts.OnSuccessModifier, contracts.OnFailureModifier} {
+		value := getStepInput(step.Inputs, modifier)
+		if !strings.HasPrefix(value, contracts.ModifierValueStepPrefix) {
+			continue
+		}
+		target := strings.TrimPrefix(value, contracts.ModifierValueStepPrefix)
+		if target == "" {
+			return nil, fmt.Errorf("%s of step %s must name the step to branch to", modifier, step.Name)
+		}
+		transitions = append(transitions, stepTransition{modifier: modifier, name: target})
+	}
+
+	isEnd := getStepInput(step.Inputs, contracts.IsEndModifier)
+	if isEnd != "" {
+		if _, err = strconv.ParseBool(isEnd); err != nil {
+			return nil, fmt.Errorf("%s of step %s must be true or false", contracts.IsEndModifier, step.Name)
+		}
+	}
+	if isEnd == contracts.ModifierValueTrue {
+		return transitions, nil
+	}
+
+	if nextStep := getStepInput(step.Inputs, contracts.NextStepModifier); nextStep != "" {
+		transitions = append(transitions, stepTransition{modifier: contracts.NextStepModifier, name: nextStep})
+	} else if index+1 < stepCount {
+		transitions = append(transitions, stepTransition{index: index + 1})
+	}
+	return transitions, nil
+}
+
+// getStepInput returns a string or bool input of a step as a string
+func getStepInput(inputs interface{}, name string) string {
+	inputsMap, ok := inputs.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch value := inputsMap[name].(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return ""
+	}
+}
+
+// formatStepCycle returns the names of the steps forming a cycle, e.g. "a -> b -> a"
+func formatStepCycle(mainSteps []*contracts.InstancePluginConfig, path []int, cycleStart int) string {
+	var names []string
+	for i := len(path) - 1; i >= 0; i-- {
+		names = append([]string{mainSteps[path[i]].Name}, names...)
+		if path[i] == cycleStart {
+			break
+		}
+	}
+	names = append(names, mainSteps[cycleStart].Name)
+	return strings.Join(names, " -> ")
+}
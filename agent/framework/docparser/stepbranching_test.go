@@ -0,0 +1,138 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBranchingStep(name string, inputs map[string]interface{}) *contracts.InstancePluginConfig {
+	inputs["runCommand"] = []interface{}{"echo " + name}
+	return &contracts.InstancePluginConfig{Action: "aws:runShellScript", Name: name, Inputs: inputs}
+}
+
+func TestValidateStepBranching(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mainSteps []*contracts.InstancePluginConfig
+		err       string
+	}{
+		{
+			name: "sequential steps",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{}),
+				newBranchingStep("b", map[string]interface{}{}),
+			},
+		},
+		{
+			name: "rollback on failure",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("deploy", map[string]interface{}{"onFailure": "step:rollback", "nextStep": "verify"}),
+				newBranchingStep("rollback", map[string]interface{}{"isEnd": true}),
+				newBranchingStep("verify", map[string]interface{}{}),
+			},
+		},
+		{
+			name: "backward jump without cycle",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"nextStep": "c"}),
+				newBranchingStep("b", map[string]interface{}{"isEnd": "true"}),
+				newBranchingStep("c", map[string]interface{}{"nextStep": "b"}),
+			},
+		},
+		{
+			name: "unknown next step",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"nextStep": "missing"}),
+			},
+			err: "nextStep of step a references unknown step missing",
+		},
+		{
+			name: "unknown onSuccess step",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"onSuccess": "step:missing"}),
+			},
+			err: "onSuccess of step a references unknown step missing",
+		},
+		{
+			name: "empty onFailure step",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"onFailure": "step:"}),
+			},
+			err: "onFailure of step a must name the step to branch to",
+		},
+		{
+			name: "invalid isEnd",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"isEnd": "maybe"}),
+			},
+			err: "isEnd of step a must be true or false",
+		},
+		{
+			name: "cycle through nextStep",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{}),
+				newBranchingStep("b", map[string]interface{}{}),
+				newBranchingStep("c", map[string]interface{}{"nextStep": "a"}),
+			},
+			err: "step branching contains a cycle: a -> b -> c -> a",
+		},
+		{
+			name: "cycle through onFailure",
+			mainSteps: []*contracts.InstancePluginConfig{
+				newBranchingStep("a", map[string]interface{}{"isEnd": true}),
+				newBranchingStep("b", map[string]interface{}{"onFailure": "step:b"}),
+			},
+			err: "step branching contains a cycle: b -> b",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateStepBranching(testCase.mainSteps)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestParseDocument_BranchingCycleIsRejected(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"],"nextStep":"b"}},{"action":"aws:runShellScript","name":"b","inputs":{"runCommand":["ls"],"onSuccess":"step:a"}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	_, err = testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.EqualError(t, err, "step branching contains a cycle: a -> b -> a")
+}
+
+func TestParseDocument_BranchingIgnoredBeforeSchema22(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.0","mainSteps":[{"action":"aws:runShellScript","name":"a","inputs":{"runCommand":["ls"],"nextStep":"missing"}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	_, err = testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutputs"
+)
+
+// validateStepOutputs checks the outputs declared by the steps and that every
+// {{ steps.<name>.outputs.<key> }} reference names an output declared by another step.
+func validateStepOutputs(mainSteps []*contracts.InstancePluginConfig) error {
+	declaredOutputs := make(map[string]map[string]bool, len(mainSteps))
+	for _, step := range mainSteps {
+		declaredOutputs[step.Name] = make(map[string]bool, len(step.Outputs))
+		for _, output := range step.Outputs {
+			if err := stepoutputs.Validate(output); err != nil {
+				return fmt.Errorf("invalid output of step %s: %v", step.Name, err)
+			}
+			if declaredOutputs[step.Name][output.Name] {
+				return fmt.Errorf("invalid output of step %s: output %s is declared more than once", step.Name, output.Name)
+			}
+			declaredOutputs[step.Name][output.Name] = true
+		}
+	}
+
+	for _, step := range mainSteps {
+		for _, reference := range stepoutputs.FindReferences(step.Inputs) {
+			stepName, outputName := reference[1], reference[2]
+			outputs, found := declaredOutputs[stepName]
+			if !found {
+				return fmt.Errorf("step %s references output %s of unknown step %s", step.Name, outputName, stepName)
+			}
+			if stepName == step.Name {
+				return fmt.Errorf("step %s references its own output %s", step.Name, outputName)
+			}
+			if !outputs[outputName] {
+				return fmt.Errorf("step %s references output %s which step %s does not declare", step.Name, outputName, stepName)
+			}
+		}
+	}
+	return nil
+}
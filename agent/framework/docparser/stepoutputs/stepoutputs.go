@@ -0,0 +1,269 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package stepoutputs selects the outputs declared by a document step and resolves the references to them
+package stepoutputs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+var (
+	nameRegex          = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	referenceRegex     = regexp.MustCompile(`{{\s*steps\.([^.{}\s]+)\.outputs\.([a-zA-Z0-9_-]+)\s*}}`)
+	jsonPathTokenRegex = regexp.MustCompile(`^(?:\.([^.\[\]]+)|\[(\d+)\]|\['([^']*)'\])`)
+)
+
+// Validate checks the name, type and selector of a step output
+func Validate(output contracts.StepOutput) error {
+	if !nameRegex.MatchString(output.Name) {
+		return fmt.Errorf("output name %q must only contain letters, digits, '_' and '-'", output.Name)
+	}
+	switch output.Type {
+	case contracts.StepOutputTypeJsonPath:
+		if _, err := parseJsonPath(output.Selector); err != nil {
+			return fmt.Errorf("output %s: %v", output.Name, err)
+		}
+	case contracts.StepOutputTypeRegex:
+		if output.Selector == "" {
+			return fmt.Errorf("output %s: selector is required for type %s", output.Name, output.Type)
+		}
+		if _, err := regexp.Compile(output.Selector); err != nil {
+			return fmt.Errorf("output %s: %v", output.Name, err)
+		}
+	case contracts.StepOutputTypeKeyValue:
+	default:
+		return fmt.Errorf("output %s: type must be one of %s, %s or %s",
+			output.Name, contracts.StepOutputTypeJsonPath, contracts.StepOutputTypeRegex, contracts.StepOutputTypeKeyValue)
+	}
+	return nil
+}
+
+// FindReferences returns the step output references found in the strings of the input.
+// Each reference holds the whole match, the step name and the output name.
+func FindReferences(input interface{}) (references [][]string) {
+	switch input := input.(type) {
+	case string:
+		return referenceRegex.FindAllStringSubmatch(input, -1)
+	case []interface{}:
+		for _, v := range input {
+			references = append(references, FindReferences(v)...)
+		}
+	case map[string]interface{}:
+		for _, v := range input {
+			references = append(references, FindReferences(v)...)
+		}
+	case map[interface{}]interface{}:
+		for _, v := range input {
+			references = append(references, FindReferences(v)...)
+		}
+	}
+	return references
+}
+
+// Extract selects the values of the given outputs from the standard output of a step
+func Extract(outputs []contracts.StepOutput, stdout string) (values map[string]string, err error) {
+	values = make(map[string]string, len(outputs))
+	var document interface{}
+	documentParsed := false
+	for _, output := range outputs {
+		var value string
+		switch output.Type {
+		case contracts.StepOutputTypeJsonPath:
+			if !documentParsed {
+				if err = json.Unmarshal([]byte(stdout), &document); err != nil {
+					return nil, fmt.Errorf("output %s: standard output is not valid JSON: %v", output.Name, err)
+				}
+				documentParsed = true
+			}
+			if value, err = selectJsonPath(document, output.Selector); err != nil {
+				return nil, fmt.Errorf("output %s: %v", output.Name, err)
+			}
+		case contracts.StepOutputTypeRegex:
+			if value, err = selectRegex(stdout, output.Selector); err != nil {
+				return nil, fmt.Errorf("output %s: %v", output.Name, err)
+			}
+		case contracts.StepOutputTypeKeyValue:
+			key := output.Selector
+			if key == "" {
+				key = output.Name
+			}
+			var found bool
+			if value, found = selectKeyValue(stdout, key); !found {
+				return nil, fmt.Errorf("output %s: no line %s=<value> found in standard output", output.Name, key)
+			}
+		default:
+			return nil, fmt.Errorf("output %s: unsupported type %s", output.Name, output.Type)
+		}
+		values[output.Name] = value
+	}
+	return values, nil
+}
+
+// Resolve replaces the {{ steps.<name>.outputs.<key> }} references in the input
+// with the outputs of the steps that already ran. Returns a new object with replaced references.
+//
+// Values are inserted as they were selected, without any quoting. A reference used in the text of a
+// script is therefore interpreted by the shell, and a step that passes the output of another step to a
+// command should quote it in the script itself, e.g. "deploy '{{ steps.build.outputs.version }}'".
+func Resolve(input interface{}, stepOutputs map[string]map[string]string) (interface{}, error) {
+	switch input := input.(type) {
+	case string:
+		var err error
+		resolved := referenceRegex.ReplaceAllStringFunc(input, func(reference string) string {
+			match := referenceRegex.FindStringSubmatch(reference)
+			value, found := stepOutputs[match[1]][match[2]]
+			if !found && err == nil {
+				err = fmt.Errorf("output %s of step %s is not available", match[2], match[1])
+			}
+			return value
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resolved, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(input))
+		for i, v := range input {
+			resolved, err := Resolve(v, stepOutputs)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(input))
+		for k, v := range input {
+			resolved, err := Resolve(v, stepOutputs)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+
+	case map[interface{}]interface{}:
+		// documents parsed from YAML hold nested objects as map[interface{}]interface{}
+		out := make(map[interface{}]interface{}, len(input))
+		for k, v := range input {
+			resolved, err := Resolve(v, stepOutputs)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+
+	default:
+		return input, nil
+	}
+}
+
+// selectRegex returns the first capture group of the first match, or the whole match when the expression has no group
+func selectRegex(stdout string, expression string) (string, error) {
+	regex, err := regexp.Compile(expression)
+	if err != nil {
+		return "", err
+	}
+	match := regex.FindStringSubmatch(stdout)
+	if match == nil {
+		return "", fmt.Errorf("regular expression %s does not match standard output", expression)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+// selectKeyValue returns the value of the last key=value line of the standard output
+func selectKeyValue(stdout string, key string) (value string, found bool) {
+	for _, line := range strings.Split(stdout, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, key+"=") {
+			value, found = strings.TrimPrefix(line, key+"="), true
+		}
+	}
+	return value, found
+}
+
+// jsonPathToken is a single member name or array index of a JSON path
+type jsonPathToken struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJsonPath parses the subset of JSON path supported by step outputs: $, .name, ['name'] and [index]
+func parseJsonPath(path string) (tokens []jsonPathToken, err error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSON path %q must start with $", path)
+	}
+	remaining := path[1:]
+	for remaining != "" {
+		match := jsonPathTokenRegex.FindStringSubmatch(remaining)
+		if match == nil {
+			return nil, fmt.Errorf("JSON path %q is invalid near %q", path, remaining)
+		}
+		switch {
+		case match[1] != "":
+			tokens = append(tokens, jsonPathToken{key: match[1]})
+		case match[2] != "":
+			index, _ := strconv.Atoi(match[2])
+			tokens = append(tokens, jsonPathToken{index: index, isIndex: true})
+		default:
+			tokens = append(tokens, jsonPathToken{key: match[3]})
+		}
+		remaining = remaining[len(match[0]):]
+	}
+	return tokens, nil
+}
+
+// selectJsonPath returns the value at the given path of a JSON document, values that are not strings are returned as JSON
+func selectJsonPath(document interface{}, path string) (string, error) {
+	tokens, err := parseJsonPath(path)
+	if err != nil {
+		return "", err
+	}
+	current := document
+	for _, token := range tokens {
+		if token.isIndex {
+			array, ok := current.([]interface{})
+			if !ok || token.index >= len(array) {
+				return "", fmt.Errorf("JSON path %s does not match standard output", path)
+			}
+			current = array[token.index]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("JSON path %s does not match standard output", path)
+		}
+		if current, ok = object[token.key]; !ok {
+			return "", fmt.Errorf("JSON path %s does not match standard output", path)
+		}
+	}
+	if value, ok := current.(string); ok {
+		return value, nil
+	}
+	value, err := json.Marshal(current)
+	return string(value), err
+}
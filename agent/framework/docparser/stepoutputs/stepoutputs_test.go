@@ -0,0 +1,106 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepoutputs
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	stdout := `{"instances":[{"id":"i-123","tags":{"env":"prod"}}],"count":1}`
+	outputs := []contracts.StepOutput{
+		{Name: "instanceId", Type: contracts.StepOutputTypeJsonPath, Selector: "$.instances[0].id"},
+		{Name: "env", Type: contracts.StepOutputTypeJsonPath, Selector: "$.instances[0]['tags'].env"},
+		{Name: "count", Type: contracts.StepOutputTypeJsonPath, Selector: "$.count"},
+		{Name: "tags", Type: contracts.StepOutputTypeJsonPath, Selector: "$.instances[0].tags"},
+	}
+
+	values, err := Extract(outputs, stdout)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"instanceId": "i-123", "env": "prod", "count": "1", "tags": `{"env":"prod"}`}, values)
+}
+
+func TestExtract_RegexAndKeyValue(t *testing.T) {
+	stdout := "installing\nversion: 3.2.1\nCOLOR=blue\nCOLOR=green\nstatus=ok\n"
+	outputs := []contracts.StepOutput{
+		{Name: "version", Type: contracts.StepOutputTypeRegex, Selector: `version: (\S+)`},
+		{Name: "line", Type: contracts.StepOutputTypeRegex, Selector: `inst\w+`},
+		{Name: "color", Type: contracts.StepOutputTypeKeyValue, Selector: "COLOR"},
+		{Name: "status", Type: contracts.StepOutputTypeKeyValue},
+	}
+
+	values, err := Extract(outputs, stdout)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "3.2.1", "line": "installing", "color": "green", "status": "ok"}, values)
+}
+
+func TestExtract_Errors(t *testing.T) {
+	_, err := Extract([]contracts.StepOutput{{Name: "id", Type: contracts.StepOutputTypeJsonPath, Selector: "$.id"}}, "not json")
+	assert.Contains(t, err.Error(), "output id: standard output is not valid JSON")
+
+	_, err = Extract([]contracts.StepOutput{{Name: "id", Type: contracts.StepOutputTypeJsonPath, Selector: "$.items[2]"}}, `{"items":[1]}`)
+	assert.EqualError(t, err, "output id: JSON path $.items[2] does not match standard output")
+
+	_, err = Extract([]contracts.StepOutput{{Name: "id", Type: contracts.StepOutputTypeKeyValue}}, "other=1")
+	assert.EqualError(t, err, "output id: no line id=<value> found in standard output")
+}
+
+func TestResolve(t *testing.T) {
+	stepOutputs := map[string]map[string]string{"build": {"version": "3.2.1", "path": "/tmp/out"}}
+	input := map[string]interface{}{
+		"runCommand":       []interface{}{"deploy {{ steps.build.outputs.version }} from {{steps.build.outputs.path}}"},
+		"workingDirectory": "{{ steps.build.outputs.path }}",
+		"timeoutSeconds":   60,
+		"other":            "{{ param }}",
+	}
+
+	resolved, err := Resolve(input, stepOutputs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"runCommand":       []interface{}{"deploy 3.2.1 from /tmp/out"},
+		"workingDirectory": "/tmp/out",
+		"timeoutSeconds":   60,
+		"other":            "{{ param }}",
+	}, resolved)
+
+	_, err = Resolve(input, map[string]map[string]string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "of step build is not available")
+}
+
+func TestResolve_YamlDocument(t *testing.T) {
+	stepOutputs := map[string]map[string]string{"build": {"version": "3.2.1"}}
+	input := map[interface{}]interface{}{
+		"runCommand": []interface{}{"deploy {{ steps.build.outputs.version }}"},
+		"environment": map[interface{}]interface{}{
+			"VERSION": "{{ steps.build.outputs.version }}",
+		},
+	}
+
+	resolved, err := Resolve(input, stepOutputs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[interface{}]interface{}{
+		"runCommand": []interface{}{"deploy 3.2.1"},
+		"environment": map[interface{}]interface{}{
+			"VERSION": "3.2.1",
+		},
+	}, resolved)
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStepOutputs(t *testing.T) {
+	newStep := func(name string, command string, outputs ...contracts.StepOutput) *contracts.InstancePluginConfig {
+		step := newBranchingStep(name, map[string]interface{}{})
+		step.Inputs.(map[string]interface{})["runCommand"] = []interface{}{command}
+		step.Outputs = outputs
+		return step
+	}
+	versionOutput := contracts.StepOutput{Name: "version", Type: contracts.StepOutputTypeKeyValue}
+
+	testCases := []struct {
+		name      string
+		mainSteps []*contracts.InstancePluginConfig
+		err       string
+	}{
+		{
+			name:      "valid reference",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("build", "echo version=1", versionOutput), newStep("deploy", "deploy {{ steps.build.outputs.version }}")},
+		},
+		{
+			name:      "unknown step",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("deploy", "deploy {{ steps.build.outputs.version }}")},
+			err:       "step deploy references output version of unknown step build",
+		},
+		{
+			name:      "undeclared output",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("build", "echo"), newStep("deploy", "deploy {{ steps.build.outputs.version }}")},
+			err:       "step deploy references output version which step build does not declare",
+		},
+		{
+			name:      "invalid type",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("build", "echo", contracts.StepOutput{Name: "version", Type: "xpath"})},
+			err:       "invalid output of step build: output version: type must be one of jsonPath, regex or keyValue",
+		},
+		{
+			name:      "invalid JSON path",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("build", "echo", contracts.StepOutput{Name: "version", Type: contracts.StepOutputTypeJsonPath, Selector: "version"})},
+			err:       `invalid output of step build: output version: JSON path "version" must start with $`,
+		},
+		{
+			name:      "duplicate output",
+			mainSteps: []*contracts.InstancePluginConfig{newStep("build", "echo", versionOutput, versionOutput)},
+			err:       "invalid output of step build: output version is declared more than once",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateStepOutputs(testCase.mainSteps)
+			if testCase.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestParseDocument_StepOutputs(t *testing.T) {
+	var testDocContent DocContent
+	document := `{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"build","outputs":[{"name":"version","type":"regex","selector":"v(\\d+)"}],"inputs":{"runCommand":["echo v1"]}},{"action":"aws:runShellScript","name":"deploy","inputs":{"runCommand":["deploy {{ steps.build.outputs.version }}"]}}]}`
+	err := json.Unmarshal([]byte(document), &testDocContent)
+	assert.Nil(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []contracts.StepOutput{{Name: "version", Type: contracts.StepOutputTypeRegex, Selector: `v(\d+)`}}, pluginsInfo[0].Configuration.Outputs)
+	assert.Equal(t, []interface{}{"deploy {{ steps.build.outputs.version }}"}, pluginsInfo[1].Configuration.Properties.(map[string]interface{})["runCommand"])
+}
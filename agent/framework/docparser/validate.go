@@ -0,0 +1,247 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/localparameters"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutputs"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+)
+
+// ValidationError is a problem found in a document, located by the JSON path of the offending
+// element and the name of the step it belongs to, if any.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+}
+
+// PluginSupportChecker returns whether a plugin is known to the agent and supported on the current platform
+type PluginSupportChecker func(pluginName string) (isKnown bool, isSupported bool)
+
+// Validate checks a command document and the parameters supplied for it without running it or calling any AWS service.
+// All problems found are returned, an empty list means the document is valid.
+func (docContent *DocContent) Validate(log log.T, params map[string]interface{}, isPluginSupported PluginSupportChecker) (validationErrors []ValidationError) {
+	if err := validateSchema(docContent.SchemaVersion); err != nil {
+		return []ValidationError{{Path: "$.schemaVersion", Message: err.Error()}}
+	}
+	validationErrors = append(validationErrors, validateDocumentParameters(log, docContent.Parameters, params)...)
+
+	if docContent.SchemaVersion == "1.0" || docContent.SchemaVersion == "1.2" {
+		if len(docContent.RuntimeConfig) == 0 {
+			validationErrors = append(validationErrors, ValidationError{Path: "$.runtimeConfig", Message: "runtimeConfig cannot be empty"})
+		}
+		pluginNames := make([]string, 0, len(docContent.RuntimeConfig))
+		for pluginName := range docContent.RuntimeConfig {
+			pluginNames = append(pluginNames, pluginName)
+		}
+		sort.Strings(pluginNames)
+		for _, pluginName := range pluginNames {
+			if message := validatePluginName(pluginName, isPluginSupported); message != "" {
+				validationErrors = append(validationErrors, ValidationError{Path: fmt.Sprintf("$.runtimeConfig.%s", pluginName), Message: message})
+			}
+		}
+		return validationErrors
+	}
+
+	if len(docContent.MainSteps) == 0 {
+		return append(validationErrors, ValidationError{Path: "$.mainSteps", Message: "mainSteps cannot be empty"})
+	}
+	isPreconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
+	if len(docContent.FinallySteps) > 0 && !isPreconditionEnabled {
+		validationErrors = append(validationErrors, ValidationError{
+			Path:    "$.finallySteps",
+			Message: fmt.Sprintf("finallySteps is not supported for document schema version %s", docContent.SchemaVersion),
+		})
+	}
+	stepNames := make(map[string]bool, len(docContent.MainSteps)+len(docContent.FinallySteps))
+	validationErrors = append(validationErrors, validateSteps(docContent, "$.mainSteps", docContent.MainSteps, stepNames, isPluginSupported)...)
+	validationErrors = append(validationErrors, validateSteps(docContent, "$.finallySteps", docContent.FinallySteps, stepNames, isPluginSupported)...)
+
+	// control flow is validated across steps once every step is valid on its own
+	if isPreconditionEnabled && len(validationErrors) == 0 {
+		allSteps := append(append([]*contracts.InstancePluginConfig{}, docContent.MainSteps...), docContent.FinallySteps...)
+		if err := validateFinallySteps(docContent.MainSteps, docContent.FinallySteps); err != nil {
+			validationErrors = append(validationErrors, ValidationError{Path: "$.finallySteps", Message: err.Error()})
+		}
+		for _, validateMainSteps := range []func([]*contracts.InstancePluginConfig) error{validateStepBranching, validateParallelGroups} {
+			if err := validateMainSteps(docContent.MainSteps); err != nil {
+				validationErrors = append(validationErrors, ValidationError{Path: "$.mainSteps", Message: err.Error()})
+			}
+		}
+		if err := validateStepOutputs(allSteps); err != nil {
+			validationErrors = append(validationErrors, ValidationError{Path: "$.mainSteps", Message: err.Error()})
+		}
+	}
+	return validationErrors
+}
+
+// validateSteps checks each step of mainSteps or finallySteps on its own, stepNames collects the names of the steps
+func validateSteps(
+	docContent *DocContent,
+	stepsPath string,
+	steps []*contracts.InstancePluginConfig,
+	stepNames map[string]bool,
+	isPluginSupported PluginSupportChecker) (validationErrors []ValidationError) {
+
+	isPreconditionEnabled := isPreconditionEnabled(docContent.SchemaVersion)
+	for index, step := range steps {
+		stepPath := fmt.Sprintf("%s[%d]", stepsPath, index)
+		if step == nil {
+			validationErrors = append(validationErrors, ValidationError{Path: stepPath, Message: "step cannot be empty"})
+			continue
+		}
+		addStepError := func(field string, message string) {
+			validationErrors = append(validationErrors, ValidationError{Path: stepPath + field, Step: step.Name, Message: message})
+		}
+
+		if step.Name == "" {
+			addStepError(".name", "step name cannot be empty")
+		} else if stepNames[step.Name] {
+			addStepError(".name", fmt.Sprintf("step name %s is used by more than one step", step.Name))
+		}
+		stepNames[step.Name] = true
+
+		if step.Action == "" {
+			addStepError(".action", "action cannot be empty")
+		} else if message := validatePluginName(step.Action, isPluginSupported); message != "" {
+			addStepError(".action", message)
+		}
+		if step.MaxAttempts < 0 {
+			addStepError(".maxAttempts", "maxAttempts cannot be negative")
+		}
+		if step.Timeout < 0 {
+			addStepError(".timeoutSeconds", "timeoutSeconds cannot be negative")
+		}
+		if !isPreconditionEnabled {
+			if len(step.Preconditions) > 0 {
+				addStepError(".precondition", fmt.Sprintf("precondition is not supported for document schema version %s", docContent.SchemaVersion))
+			}
+			if len(step.Outputs) > 0 {
+				addStepError(".outputs", fmt.Sprintf("outputs are not supported for document schema version %s", docContent.SchemaVersion))
+			}
+			continue
+		}
+		if err := validateResumePolicy(step); err != nil {
+			addStepError(".inputs."+contracts.ResumePolicyModifier, err.Error())
+		}
+		for outputIndex, output := range step.Outputs {
+			if err := stepoutputs.Validate(output); err != nil {
+				addStepError(fmt.Sprintf(".outputs[%d]", outputIndex), err.Error())
+			}
+		}
+	}
+	return validationErrors
+}
+
+// Validate checks a session document and the parameters supplied for it without starting a session.
+// All problems found are returned, an empty list means the document is valid.
+func (sessionDocContent *SessionDocContent) Validate(log log.T, params map[string]interface{}, isPluginSupported PluginSupportChecker) (validationErrors []ValidationError) {
+	if err := validateSessionDocumentSchema(sessionDocContent.SchemaVersion); err != nil {
+		return []ValidationError{{Path: "$.schemaVersion", Message: err.Error()}}
+	}
+	validationErrors = append(validationErrors, validateDocumentParameters(log, sessionDocContent.Parameters, params)...)
+
+	if sessionDocContent.SessionType == "" {
+		validationErrors = append(validationErrors, ValidationError{Path: "$.sessionType", Message: "sessionType cannot be empty"})
+	} else if message := validatePluginName(sessionDocContent.SessionType, isPluginSupported); message != "" {
+		validationErrors = append(validationErrors, ValidationError{Path: "$.sessionType", Message: message})
+	}
+	return validationErrors
+}
+
+// validatePluginName returns why the plugin cannot run on this instance, or an empty string if it can
+func validatePluginName(pluginName string, isPluginSupported PluginSupportChecker) string {
+	isKnown, isSupported := isPluginSupported(pluginName)
+	if !isKnown {
+		return fmt.Sprintf("plugin %s is not supported by this version of ssm agent", pluginName)
+	}
+	if !isSupported {
+		return fmt.Sprintf("plugin %s is not supported on the current platform", pluginName)
+	}
+	return ""
+}
+
+// validateDocumentParameters checks the supplied parameters against the parameters declared by the document
+// with the same validators the agent applies before running a document.
+func validateDocumentParameters(log log.T, documentParameters map[string]*contracts.Parameter, params map[string]interface{}) (validationErrors []ValidationError) {
+	declaredNames, suppliedNames := sortedParameterNames(documentParameters, params)
+	for _, paramName := range suppliedNames {
+		if _, declared := documentParameters[paramName]; !declared {
+			validationErrors = append(validationErrors, ValidationError{
+				Path:    fmt.Sprintf("$.parameters.%s", paramName),
+				Message: fmt.Sprintf("parameter %s is not declared by the document", paramName),
+			})
+		}
+	}
+
+	validators := append(append([]paramvalidator.ParameterValidator{}, paramvalidator.GetMandatoryValidators()...), paramvalidator.GetOptionalValidators()...)
+	for _, paramName := range declaredNames {
+		paramPath := fmt.Sprintf("$.parameters.%s", paramName)
+		parameter := documentParameters[paramName]
+		if parameter == nil {
+			validationErrors = append(validationErrors, ValidationError{Path: paramPath, Message: "parameter definition cannot be empty"})
+			continue
+		}
+		value, supplied := params[paramName]
+		if !supplied {
+			value = parameter.DefaultVal
+		}
+		if value == nil {
+			validationErrors = append(validationErrors, ValidationError{Path: paramPath, Message: fmt.Sprintf("parameter %s has no default value and must be supplied", paramName)})
+			continue
+		}
+		if isResolvedAtRunTime(value) {
+			continue
+		}
+		for _, validator := range validators {
+			if err := validator.Validate(log, value, parameter); err != nil {
+				validationErrors = append(validationErrors, ValidationError{
+					Path:    paramPath,
+					Message: fmt.Sprintf("error thrown in '%v' while validating parameter /%v/: %v", validator.GetName(), paramName, err),
+				})
+			}
+		}
+	}
+	return validationErrors
+}
+
+// isResolvedAtRunTime returns whether the parameter value references values stored in parameter store or a secret
+// backend, or read on the instance, they are only known when the document runs
+func isResolvedAtRunTime(value interface{}) bool {
+	valueString, ok := value.(string)
+	return ok && (ssmparameterresolver.TextContainsSsmParameters(valueString) ||
+		ssmparameterresolver.TextContainsSecureSsmParameters(valueString) ||
+		ssmparameterresolver.TextContainsSecretBackendParameters(valueString) ||
+		localparameters.TextContainsLocalParameters(valueString))
+}
+
+// sortedParameterNames returns the names of the parameters in lexical order so that errors are reported in a stable order
+func sortedParameterNames(documentParameters map[string]*contracts.Parameter, params map[string]interface{}) (declared []string, supplied []string) {
+	for name := range documentParameters {
+		declared = append(declared, name)
+	}
+	for name := range params {
+		supplied = append(supplied, name)
+	}
+	sort.Strings(declared)
+	sort.Strings(supplied)
+	return declared, supplied
+}
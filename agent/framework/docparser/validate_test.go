@@ -0,0 +1,187 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func isTestPluginSupported(pluginName string) (isKnown bool, isSupported bool) {
+	switch pluginName {
+	case "aws:runShellScript", "Standard_Stream":
+		return true, true
+	case "aws:runPowerShellScript":
+		return true, false
+	}
+	return false, false
+}
+
+func TestDocContentValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		docContent DocContent
+		params     map[string]interface{}
+		expected   []ValidationError
+	}{
+		{
+			name: "valid document",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				Parameters: map[string]*contracts.Parameter{
+					"message": {ParamType: "String", DefaultVal: "hello"},
+				},
+				MainSteps: []*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{}),
+				},
+			},
+			params: map[string]interface{}{"message": "hi"},
+		},
+		{
+			name:       "unsupported schema version",
+			docContent: DocContent{SchemaVersion: "9.9"},
+			expected: []ValidationError{
+				{Path: "$.schemaVersion", Message: "Document with schema version 9.9 is not supported by this version of ssm agent, please update to latest version"},
+			},
+		},
+		{
+			name:       "empty mainSteps",
+			docContent: DocContent{SchemaVersion: "2.2"},
+			expected:   []ValidationError{{Path: "$.mainSteps", Message: "mainSteps cannot be empty"}},
+		},
+		{
+			name: "invalid steps",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				MainSteps: []*contracts.InstancePluginConfig{
+					{Name: "a", Action: "aws:unknown"},
+					{Name: "a", Action: "aws:runPowerShellScript", MaxAttempts: -1},
+					{Action: "aws:runShellScript", Outputs: []contracts.StepOutput{{Name: "out", Type: "xml"}}},
+				},
+			},
+			expected: []ValidationError{
+				{Path: "$.mainSteps[0].action", Step: "a", Message: "plugin aws:unknown is not supported by this version of ssm agent"},
+				{Path: "$.mainSteps[1].name", Step: "a", Message: "step name a is used by more than one step"},
+				{Path: "$.mainSteps[1].action", Step: "a", Message: "plugin aws:runPowerShellScript is not supported on the current platform"},
+				{Path: "$.mainSteps[1].maxAttempts", Step: "a", Message: "maxAttempts cannot be negative"},
+				{Path: "$.mainSteps[2].name", Message: "step name cannot be empty"},
+				{Path: "$.mainSteps[2].outputs[0]", Message: "output out: type must be one of jsonPath, regex or keyValue"},
+			},
+		},
+		{
+			name: "precondition before schema 2.2",
+			docContent: DocContent{
+				SchemaVersion: "2.0",
+				MainSteps: []*contracts.InstancePluginConfig{
+					{Name: "a", Action: "aws:runShellScript", Preconditions: map[string][]interface{}{"StringEquals": {"platformType", "Linux"}}},
+				},
+			},
+			expected: []ValidationError{
+				{Path: "$.mainSteps[0].precondition", Step: "a", Message: "precondition is not supported for document schema version 2.0"},
+			},
+		},
+		{
+			name: "invalid control flow",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				MainSteps: []*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{"nextStep": "missing"}),
+				},
+			},
+			expected: []ValidationError{
+				{Path: "$.mainSteps", Message: validateStepBranching([]*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{"nextStep": "missing"}),
+				}).Error()},
+			},
+		},
+		{
+			name: "invalid typed parameters",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				Parameters: map[string]*contracts.Parameter{
+					"timeout": {ParamType: "Integer", MinValue: "1", DefaultVal: float64(0)},
+					"verbose": {ParamType: "Boolean", DefaultVal: "maybe"},
+				},
+				MainSteps: []*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{}),
+				},
+			},
+			expected: []ValidationError{
+				{Path: "$.parameters.timeout", Message: "error thrown in 'MinMaxValueParamValidator' while validating parameter /timeout/: parameter value /0/ is less than the min value /1/"},
+				{Path: "$.parameters.verbose", Message: "error thrown in 'TypeParamValidator' while validating parameter /verbose/: parameter value /maybe/ is not a valid Boolean"},
+				{Path: "$.parameters.verbose", Message: "error thrown in 'AllowedValueParamValidator' while validating parameter /verbose/: parameter value /maybe/ not equal to default for Boolean"},
+			},
+		},
+		{
+			name: "invalid resume policy",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				MainSteps: []*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{"resumePolicy": "retry"}),
+				},
+			},
+			expected: []ValidationError{
+				{Path: "$.mainSteps[0].inputs.resumePolicy", Step: "a", Message: "resumePolicy of step a must be rerun, skip or fail"},
+			},
+		},
+		{
+			name: "empty runtimeConfig",
+			docContent: DocContent{
+				SchemaVersion: "1.2",
+			},
+			expected: []ValidationError{{Path: "$.runtimeConfig", Message: "runtimeConfig cannot be empty"}},
+		},
+		{
+			name: "invalid parameters",
+			docContent: DocContent{
+				SchemaVersion: "2.2",
+				Parameters: map[string]*contracts.Parameter{
+					"mode":     {ParamType: "String", AllowedVal: []string{"fast", "slow"}},
+					"required": {ParamType: "String"},
+					"stored":   {ParamType: "String", AllowedPattern: "^[a-z]+$"},
+					"local":    {ParamType: "String", AllowedPattern: "^[a-z]+$"},
+				},
+				MainSteps: []*contracts.InstancePluginConfig{
+					newBranchingStep("a", map[string]interface{}{}),
+				},
+			},
+			params: map[string]interface{}{"mode": "medium", "stored": "{{ssm:/my/param}}", "local": "{{ env:APP_MODE }}", "undeclared": "value"},
+			expected: []ValidationError{
+				{Path: "$.parameters.undeclared", Message: "parameter undeclared is not declared by the document"},
+				{Path: "$.parameters.mode", Message: "error thrown in 'AllowedValueParamValidator' while validating parameter /mode/: parameter value /medium/ is not in the allowed list [fast slow]"},
+				{Path: "$.parameters.required", Message: "parameter required has no default value and must be supplied"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validationErrors := tc.docContent.Validate(log.NewMockLog(), tc.params, isTestPluginSupported)
+			assert.Equal(t, tc.expected, validationErrors)
+		})
+	}
+}
+
+func TestSessionDocContentValidate(t *testing.T) {
+	sessionDocContent := SessionDocContent{SchemaVersion: "1.0", SessionType: "Standard_Stream"}
+	assert.Empty(t, sessionDocContent.Validate(log.NewMockLog(), nil, isTestPluginSupported))
+
+	sessionDocContent.SessionType = "Unknown_Stream"
+	assert.Equal(t, []ValidationError{
+		{Path: "$.sessionType", Message: "plugin Unknown_Stream is not supported by this version of ssm agent"},
+	}, sessionDocContent.Validate(log.NewMockLog(), nil, isTestPluginSupported))
+}
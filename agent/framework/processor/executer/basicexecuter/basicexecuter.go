@@ -88,6 +88,8 @@ func run(context context.T,
 			}
 			resChan <- docResult
 			contracts.UpdateDocState(&docResult, state)
+			// persist every step result, a crashed agent resumes the document from the first incomplete step
+			docStore.Save(*state)
 		}
 	}(&docState)
 
@@ -101,6 +101,9 @@ func testBasicExecuter(t *testing.T, testCase TestCase) {
 	resultState.InstancePluginsInformation[0].Result = *testCase.PluginResults["plugin1"]
 	dataStoreMock.On("Load").Return(state)
 	dataStoreMock.On("Save", resultState).Return()
+	inProgressState := resultState
+	inProgressState.DocumentInformation.DocumentStatus = contracts.ResultStatusInProgress
+	dataStoreMock.On("Save", inProgressState).Return()
 	pluginRunner = func(context context.T,
 		docState contracts.DocumentState,
 		resChan chan contracts.PluginResult,
@@ -144,5 +147,7 @@ func testBasicExecuter(t *testing.T, testCase TestCase) {
 	//assert transaction has completed
 	assert.True(t, done)
 	dataStoreMock.AssertExpectations(t)
+	// the state is saved after every plugin and once more with the document result
+	dataStoreMock.AssertNumberOfCalls(t, "Save", nPlugins+1)
 
 }
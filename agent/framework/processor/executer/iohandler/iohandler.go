@@ -18,14 +18,17 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"regexp"
 	"runtime/debug"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
 const (
@@ -167,10 +170,13 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 		OrchestrationDirectory: fullPath,
 	}
 
+	// secrets are masked before the output reaches any of the listeners
+	maskingPatterns := compileMaskingPatterns(log, out.context.AppConfig().Ssm.OutputMaskingPatterns)
+
 	log.Debug("Initializing the Stdout Multi-writer with file and console listeners")
 	// Get a multi-writer for standard output
-	out.StdoutWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(out.StdoutWriter, stdoutFile, stdoutConsole)
+	out.StdoutWriter = multiwriter.NewMaskingDocumentIOMultiWriter(multiwriter.NewDocumentIOMultiWriter(), maskingPatterns)
+	out.RegisterOutputSource(out.StdoutWriter, out.withOutputSpool(fullPath, pluginConfig.StdoutFileName, stdoutFile, stdoutConsole)...)
 
 	// Initialize file error module
 	stderrFile := iomodule.File{
@@ -191,8 +197,95 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 
 	log.Debug("Initializing the Stderr Multi-writer with file and console listeners")
 	// Get a multi-writer for standard error
-	out.StderrWriter = multiwriter.NewDocumentIOMultiWriter()
-	out.RegisterOutputSource(out.StderrWriter, stderrFile, stderrConsole)
+	out.StderrWriter = multiwriter.NewMaskingDocumentIOMultiWriter(multiwriter.NewDocumentIOMultiWriter(), maskingPatterns)
+	out.RegisterOutputSource(out.StderrWriter, out.withOutputSpool(fullPath, pluginConfig.StderrFileName, stderrFile, stderrConsole)...)
+}
+
+// compileMaskingPatterns compiles the patterns of secrets masked in the output, invalid patterns are ignored
+func compileMaskingPatterns(log log.T, patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Warnf("Ignoring invalid output masking pattern %s: %v", pattern, err)
+			continue
+		}
+		compiled = append(compiled, expression)
+	}
+	return compiled
+}
+
+// MaskResult masks the secrets in the outputs of a plugin result that do not go through the masking multi-writers,
+// such as the structured output and the step outputs
+func MaskResult(context context.T, res *contracts.PluginResult) {
+	patterns := compileMaskingPatterns(context.Log(), context.AppConfig().Ssm.OutputMaskingPatterns)
+	res.Output = maskValue(res.Output, patterns)
+	res.Error = multiwriter.Mask(res.Error, patterns)
+	for name, value := range res.StepOutputs {
+		res.StepOutputs[name] = multiwriter.Mask(value, patterns)
+	}
+}
+
+// maskValue masks the strings of a plugin output, whether it is text or structured
+func maskValue(value interface{}, patterns []*regexp.Regexp) interface{} {
+	switch value := value.(type) {
+	case string:
+		return multiwriter.Mask(value, patterns)
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			masked[key] = maskValue(item, patterns)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(value))
+		for index, item := range value {
+			masked[index] = maskValue(item, patterns)
+		}
+		return masked
+	}
+	return value
+}
+
+// withOutputSpool adds the output spool module to the given modules when the output spool is enabled
+func (out *DefaultIOHandler) withOutputSpool(orchestrationDirectory, fileName string, modules ...iomodule.IOModule) []iomodule.IOModule {
+	appConfig := out.context.AppConfig()
+	if appConfig.Ssm.OutputSpoolMaxSizeMB <= 0 {
+		return modules
+	}
+
+	log := out.context.Log()
+	instanceID, err := out.context.Identity().ShortInstanceID()
+	if err != nil {
+		log.Warnf("Output is not spooled, failed to get the instance id: %v", err)
+		return modules
+	}
+	spoolDirectory, ok := docmanager.OutputSpoolPath(instanceID, appConfig.Agent.OrchestrationRootDir, orchestrationDirectory)
+	if !ok {
+		log.Debugf("Output is not spooled, %v is not under the orchestration root directory", orchestrationDirectory)
+		return modules
+	}
+
+	return append(modules, iomodule.Spool{
+		FileName:       fileName + ".gz",
+		SpoolDirectory: spoolDirectory,
+	})
+}
+
+// PruneOutputSpool deletes the spooled output of the oldest executions when the output spool is over its maximum size
+func PruneOutputSpool(context context.T) {
+	appConfig := context.AppConfig()
+	if appConfig.Ssm.OutputSpoolMaxSizeMB <= 0 {
+		return
+	}
+
+	log := context.Log()
+	instanceID, err := context.Identity().ShortInstanceID()
+	if err != nil {
+		log.Warnf("Output spool is not pruned, failed to get the instance id: %v", err)
+		return
+	}
+	docmanager.PruneOutputSpool(log, docmanager.OutputSpoolDir(instanceID), int64(appConfig.Ssm.OutputSpoolMaxSizeMB)*1024*1024)
 }
 
 // RegisterOutputSource returns a new output source by creating a multiwriter for the output modules.
@@ -203,6 +296,8 @@ func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.Docume
 
 	log := out.context.Log()
 	wg := multiWriter.GetWaitGroup()
+	// the readers get the exit code when the source is registered, the plugin sets it while they run
+	exitCode := out.ExitCode
 	// Create a Pipe for each IO Module and add it to the multi-writer.
 	for _, module := range IOModules {
 		r, w := io.Pipe()
@@ -217,7 +312,7 @@ func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.Docume
 				}
 			}()
 			defer wg.Done()
-			module.Read(out.context, r, out.ExitCode)
+			module.Read(out.context, r, exitCode)
 		}(module, r)
 	}
 
@@ -0,0 +1,75 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+const spoolBufferSize = 32 * 1024
+
+// Spool writes the output to a gzip compressed file in the output spool, which outlives the orchestration directory.
+// The compressed stream is flushed after every read so that the file can be tailed while the command runs,
+// the output of a step that runs again is appended as another gzip member.
+type Spool struct {
+	FileName       string
+	SpoolDirectory string
+}
+
+// Read reads from the stream and writes to the compressed spool file.
+func (spool Spool) Read(context context.T, reader *io.PipeReader, exitCode int) {
+	log := context.Log()
+	defer reader.Close()
+
+	if err := fileutil.MakeDirs(spool.SpoolDirectory); err != nil {
+		log.Errorf("Failed to create output spool directory %v: %v", spool.SpoolDirectory, err)
+		io.Copy(io.Discard, reader)
+		return
+	}
+	filePath := filepath.Join(spool.SpoolDirectory, spool.FileName)
+	file, err := os.OpenFile(filePath, appconfig.FileFlagsCreateOrAppend, appconfig.ReadWriteAccess)
+	if err != nil {
+		log.Errorf("Failed to open the spool file at %v: %v", filePath, err)
+		io.Copy(io.Discard, reader)
+		return
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	buffer := make([]byte, spoolBufferSize)
+	for {
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			if _, err = gzipWriter.Write(buffer[:n]); err == nil {
+				err = gzipWriter.Flush()
+			}
+			if err != nil {
+				log.Errorf("Failed to write the output to the spool file %v: %v", filePath, err)
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if err = gzipWriter.Close(); err != nil {
+		log.Errorf("Failed to complete the spool file %v: %v", filePath, err)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSpoolRead(spool Spool, input ...string) {
+	context := contextmocks.NewMockDefault()
+	r, w := io.Pipe()
+	done := make(chan bool)
+	go func() {
+		spool.Read(context, r, appconfig.SuccessExitCode)
+		close(done)
+	}()
+	for _, chunk := range input {
+		w.Write([]byte(chunk))
+	}
+	w.Close()
+	<-done
+}
+
+func readSpoolFile(t *testing.T, filePath string) string {
+	file, err := os.Open(filePath)
+	assert.NoError(t, err)
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	output, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(output)
+}
+
+func TestSpool_CompressesOutput(t *testing.T) {
+	spoolRoot := t.TempDir()
+	spool := Spool{
+		FileName:       "stdout.gz",
+		SpoolDirectory: filepath.Join(spoolRoot, "command-1", "awsrunShellScript"),
+	}
+
+	testSpoolRead(spool, "first line\n", "second line\n")
+	assert.Equal(t, "first line\nsecond line\n", readSpoolFile(t, filepath.Join(spool.SpoolDirectory, spool.FileName)))
+
+	// the output of a step that runs again is appended
+	testSpoolRead(spool, "rerun\n")
+	assert.Equal(t, "first line\nsecond line\nrerun\n", readSpoolFile(t, filepath.Join(spool.SpoolDirectory, spool.FileName)))
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package multiwriter implements a multi-writer
+package multiwriter
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
+)
+
+// maxPendingBytes is the length of an incomplete line that is masked and written without waiting for the end of the line
+const maxPendingBytes = 32 * 1024
+
+// MaskingDocumentIOMultiWriter is a multi-writer that redacts secrets before writing to the attached pipes.
+// The secret values resolved for parameter references and the matches of the patterns are replaced by
+// secretmask.MaskedValue, only the first group is replaced for patterns with groups.
+// Writes are masked line by line so that a secret split across writes is still redacted.
+type MaskingDocumentIOMultiWriter struct {
+	writer   DocumentIOMultiWriter
+	patterns []*regexp.Regexp
+	lock     sync.Mutex
+	pending  []byte
+}
+
+// NewMaskingDocumentIOMultiWriter creates a multi-writer masking secrets before writing to writer
+func NewMaskingDocumentIOMultiWriter(writer DocumentIOMultiWriter, patterns []*regexp.Regexp) *MaskingDocumentIOMultiWriter {
+	return &MaskingDocumentIOMultiWriter{writer: writer, patterns: patterns}
+}
+
+// AddWriter adds a new writer to the underlying multi-writer
+func (b *MaskingDocumentIOMultiWriter) AddWriter(writer *io.PipeWriter) {
+	b.writer.AddWriter(writer)
+}
+
+// GetWaitGroup returns the wait group of the underlying multi-writer
+func (b *MaskingDocumentIOMultiWriter) GetWaitGroup() *sync.WaitGroup {
+	return b.writer.GetWaitGroup()
+}
+
+// Write masks the complete lines written so far and writes them to all the attached pipes.
+func (b *MaskingDocumentIOMultiWriter) Write(p []byte) (n int, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.pending = append(b.pending, p...)
+	end := bytes.LastIndexByte(b.pending, '\n') + 1
+	if len(b.pending) > maxPendingBytes {
+		end = len(b.pending)
+	}
+	if end > 0 {
+		if _, err = b.writer.WriteString(b.mask(string(b.pending[:end]))); err != nil {
+			return 0, err
+		}
+		b.pending = append(b.pending[:0], b.pending[end:]...)
+	}
+	return len(p), nil
+}
+
+// WriteString masks the complete lines written so far and writes them to all the attached pipes.
+func (b *MaskingDocumentIOMultiWriter) WriteString(message string) (n int, err error) {
+	return b.Write([]byte(message))
+}
+
+// Close writes the incomplete line left and waits for all the writers to be closed.
+func (b *MaskingDocumentIOMultiWriter) Close() error {
+	b.lock.Lock()
+	if len(b.pending) > 0 {
+		b.writer.WriteString(b.mask(string(b.pending)))
+		b.pending = nil
+	}
+	b.lock.Unlock()
+	return b.writer.Close()
+}
+
+// mask replaces the secret values and the matches of the patterns in text
+func (b *MaskingDocumentIOMultiWriter) mask(text string) string {
+	return Mask(text, b.patterns)
+}
+
+// Mask replaces the secret values and the matches of the patterns in text like the masking multi-writer
+func Mask(text string, patterns []*regexp.Regexp) string {
+	text = secretmask.Mask(text)
+	for _, pattern := range patterns {
+		text = maskPattern(pattern, text)
+	}
+	return text
+}
+
+// maskPattern replaces the matches of pattern in text, or their first group if the pattern has groups
+func maskPattern(pattern *regexp.Regexp, text string) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var masked bytes.Buffer
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		if start < last {
+			continue
+		}
+		masked.WriteString(text[last:start])
+		masked.WriteString(secretmask.MaskedValue)
+		last = end
+	}
+	masked.WriteString(text[last:])
+	return masked.String()
+}
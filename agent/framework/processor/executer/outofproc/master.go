@@ -8,6 +8,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/basicexecuter"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
@@ -116,7 +117,7 @@ func (e *OutOfProcExecuter) Run(
 				log.Debug("Executer closed")
 				close(resChan)
 			}()
-			e.messaging(log, ipc, resChan, cancelFlag, stopTimer)
+			e.messaging(log, ipc, resChan, store, cancelFlag, stopTimer)
 		}(docStore)
 
 		return resChan
@@ -126,13 +127,13 @@ func (e *OutOfProcExecuter) Run(
 // Executer spins up an ipc transmission worker, it creates a Data processing backend and hands off the backend to the ipc worker
 // ipc worker and data backend act as 2 threads exchange raw json messages, and messaging protocol happened in data backend, data backend is self-contained and exit when command finishes accordingly
 // Executer however does hold a timer to the worker to forcefully termniate both of them
-func (e *OutOfProcExecuter) messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, resChan chan contracts.DocumentResult, cancelFlag task.CancelFlag, stopTimer chan bool) {
+func (e *OutOfProcExecuter) messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, resChan chan contracts.DocumentResult, docStore executer.DocumentStore, cancelFlag task.CancelFlag, stopTimer chan bool) {
 
 	// backup current time in case outofproc execution failed and cannot correctly return PluginResult
 	backupStartTime := time.Now()
 
 	//handoff reply functionalities to data backend.
-	backend := messaging.NewExecuterBackend(log, resChan, e.docState, cancelFlag)
+	backend := messaging.NewExecuterBackend(log, resChan, e.docState, docStore, cancelFlag)
 
 	//handoff the data backend to messaging worker
 	if err := messaging.Messaging(log, ipc, backend, stopTimer); err != nil {
@@ -189,6 +190,18 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc filewatcherbase
 		// the chance agent is restarted before worker updates, leaving status as SuccessAndReboot,
 		// and resulting in agent deleting an in use file channel when it comes back up
 		e.docState.DocumentInformation.DocumentStatus = contracts.ResultStatusInProgress
+		// flags of an earlier resume do not apply after the reboot
+		e.docState.MarkInterruptedSteps(docmanager.IsStepStarted)
+	} else if e.docState.DocumentInformation.ProcInfo.Pid != 0 && e.docState.HasIncompleteSteps() &&
+		!processFinder(log, e.docState.DocumentInformation.ProcInfo, e.executor) {
+		// the worker of a document interrupted by an agent crash is gone, a new worker resumes the document
+		// from the first incomplete step instead of waiting on the channel of the old worker.
+		// The steps that were running when the worker stopped follow their resume policy.
+		e.docState.MarkInterruptedSteps(docmanager.IsStepStarted)
+		log.Info("deleting channel for resumed document")
+		if channelErr := filewatcherbasedipc.RemoveFileWatcherChannel(e.ctx.Identity(), documentID); channelErr != nil {
+			log.Warnf("failed to remove channel directory: %v", channelErr)
+		}
 	}
 	ipc, err, found = channelCreator(log, e.ctx.Identity(), filewatcherbasedipc.ModeMaster, documentID)
 
@@ -2,11 +2,13 @@ package outofproc
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
 	procmock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc/mock"
@@ -327,6 +329,42 @@ func TestInitializeConnectOldOrphan(t *testing.T) {
 	channelMock.AssertExpectations(t)
 }
 
+func TestInitializeResumesDocumentOfStoppedWorker(t *testing.T) {
+	testCase := CreateTestCase()
+	orchestrationDirectory := t.TempDir()
+	for i := range testCase.docState.InstancePluginsInformation {
+		testCase.docState.InstancePluginsInformation[i].Configuration.OrchestrationDirectory = filepath.Join(orchestrationDirectory, testCase.docState.InstancePluginsInformation[i].Id)
+	}
+	// the worker stopped while running the first step
+	assert.NoError(t, docmanager.MarkStepStarted(testCase.docState.InstancePluginsInformation[0].Configuration.OrchestrationDirectory))
+	testCase.docState.DocumentInformation.ProcInfo = contracts.OSProcInfo{Pid: testPid, StartTime: testStartDateTime}
+
+	for _, isWorkerRunning := range []bool{true, false} {
+		channelMock := new(channelmock.MockedChannel)
+		channelCreator = func(log log.T, identity identity.IAgentIdentity, mode filewatcherbasedipc.Mode, documentID string) (filewatcherbasedipc.IPCChannel, error, bool) {
+			return channelMock, nil, true
+		}
+		processFinder = func(log log.T, procinfo contracts.OSProcInfo, executor executor.IExecutor) bool {
+			return isWorkerRunning
+		}
+		docState := testCase.docState
+		docState.InstancePluginsInformation = append([]contracts.PluginState{}, testCase.docState.InstancePluginsInformation...)
+		cancel := task.NewChanneledCancelFlag()
+		exe := &OutOfProcExecuter{
+			ctx:        testCase.context,
+			docState:   &docState,
+			cancelFlag: cancel,
+		}
+		_, err := exe.initialize(make(chan bool))
+		cancel.Set(task.Completed)
+		assert.NoError(t, err)
+
+		// steps are only resumed once their worker is gone, and only the step that was running follows its resume policy
+		assert.Equal(t, !isWorkerRunning, docState.InstancePluginsInformation[0].Configuration.IsResumed)
+		assert.False(t, docState.InstancePluginsInformation[1].Configuration.IsResumed)
+	}
+}
+
 //TODO add Run() unittest
 
 // this is needed, since after marshal-unmarshalling thru the data channel, the pointer value changed
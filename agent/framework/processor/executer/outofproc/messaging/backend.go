@@ -9,6 +9,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -44,19 +45,22 @@ type WorkerBackend struct {
 // Executer backend formulate the run request to the worker, and collect back the responses from worker
 type ExecuterBackend struct {
 	//the shared state object that Executer hand off to data backend
-	docState   *contracts.DocumentState
+	docState *contracts.DocumentState
+	//persists the shared state object after every step so a crashed agent resumes from the first incomplete step
+	docStore   executer.DocumentStore
 	input      chan string
 	cancelFlag task.CancelFlag
 	output     chan contracts.DocumentResult
 	stopChan   chan int
 }
 
-func NewExecuterBackend(log log.T, output chan contracts.DocumentResult, docState *contracts.DocumentState, cancelFlag task.CancelFlag) *ExecuterBackend {
+func NewExecuterBackend(log log.T, output chan contracts.DocumentResult, docState *contracts.DocumentState, docStore executer.DocumentStore, cancelFlag task.CancelFlag) *ExecuterBackend {
 	stopChan := make(chan int, defaultBackendChannelSize)
 	inputChan := make(chan string, defaultBackendChannelSize)
 	p := ExecuterBackend{
 		output:     output,
 		docState:   docState,
+		docStore:   docStore,
 		input:      inputChan,
 		cancelFlag: cancelFlag,
 		stopChan:   stopChan,
@@ -121,6 +125,9 @@ func (p *ExecuterBackend) Process(datagram string) error {
 		var docResult contracts.DocumentResult
 		jsonutil.Unmarshal(content, &docResult)
 		p.formatDocResult(&docResult)
+		if p.docStore != nil {
+			p.docStore.Save(*p.docState)
+		}
 		p.output <- docResult
 		if t == MessageTypeComplete {
 			//get document result, force termniate messaging worker
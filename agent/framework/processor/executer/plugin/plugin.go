@@ -26,6 +26,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/dockercontainer"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/externalplugin"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/lrpminvoker"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/refreshassociation"
@@ -136,6 +137,15 @@ func (r RunDocumentFactory) Create(context context.T) (runpluginutil.T, error) {
 	return rundocument.NewPlugin(context)
 }
 
+type ExternalPluginFactory struct {
+	pluginName string
+	path       string
+}
+
+func (f ExternalPluginFactory) Create(context context.T) (runpluginutil.T, error) {
+	return externalplugin.NewPlugin(context, f.pluginName, f.path)
+}
+
 type SessionPluginFactory struct {
 	newPluginFunc sessionplugin.NewPluginFunc
 }
@@ -187,6 +197,12 @@ func loadWorkers(context context.T) {
 		context.Log().Infof("Successfully loaded platform dependent plugin %v", key)
 	}
 
+	// external plugins are named custom:<name> and cannot conflict with the plugins of the agent
+	for key, value := range loadExternalPlugins(context) {
+		plugins[key] = value
+		context.Log().Infof("Successfully loaded external plugin %v", key)
+	}
+
 	registeredPlugins = &plugins
 }
 
@@ -209,6 +225,17 @@ func loadSessionPlugins() {
 	registeredPlugins = &sessionPlugins
 }
 
+// loadExternalPlugins registers the executables of the external plugin directory
+func loadExternalPlugins(context context.T) runpluginutil.PluginRegistry {
+	var workerPlugins = runpluginutil.PluginRegistry{}
+
+	for pluginName, path := range externalplugin.Discover(context.Log()) {
+		workerPlugins[pluginName] = ExternalPluginFactory{pluginName: pluginName, path: path}
+	}
+
+	return workerPlugins
+}
+
 // loadPlatformIndependentPlugins registers plugins common to all platforms
 func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegistry {
 	var workerPlugins = runpluginutil.PluginRegistry{}
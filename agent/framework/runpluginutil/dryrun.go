@@ -0,0 +1,116 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// PlanPlugins walks the steps of a document the way RunPlugins does without running any plugin.
+// Preconditions are evaluated, which only reads the state of the instance, and every step that would run
+// is assumed to succeed, so the plan follows the control flow of a successful execution. Steps are returned
+// in the order they would run, followed by the steps bypassed by branching.
+// The plan does not hold the inputs of the steps since their parameters are already resolved.
+func PlanPlugins(context context.T, plugins []contracts.PluginState, registry PluginRegistry) (plan []contracts.PlannedStep) {
+	log := context.Log()
+	pluginOutputs := make(map[string]*contracts.PluginResult)
+	path := newExecutionPath()
+	for pluginIndex := 0; pluginIndex < len(plugins); pluginIndex = getNextPluginIndex(log, plugins, pluginIndex, pluginOutputs, path) {
+		stepGroup := getParallelGroup(plugins, pluginIndex, path.visitedSteps)
+		for _, stepIndex := range stepGroup {
+			path.visitedSteps[stepIndex] = true
+			plan = append(plan, planStep(context, plugins[stepIndex], registry, pluginOutputs))
+		}
+		pluginIndex = stepGroup[len(stepGroup)-1]
+	}
+
+	for pluginIndex, pluginState := range plugins {
+		if path.visitedSteps[pluginIndex] {
+			continue
+		}
+		plan = append(plan, contracts.PlannedStep{
+			StepName:  pluginState.Id,
+			Action:    pluginState.Name,
+			Operation: skipStep,
+			Reason:    fmt.Sprintf("Step execution skipped due to branching. Step name: %s", pluginState.Id),
+		})
+	}
+	return plan
+}
+
+// planStep decides what running the step would do and records the assumed result in pluginOutputs
+func planStep(
+	context context.T,
+	pluginState contracts.PluginState,
+	registry PluginRegistry,
+	pluginOutputs map[string]*contracts.PluginResult,
+) contracts.PlannedStep {
+	log := context.Log()
+	configuration := pluginState.Configuration
+
+	_, pluginHandlerFound := registry[pluginState.Name]
+	isKnown, isSupported, _ := isSupportedPlugin(log, pluginState.Name)
+	if isExternalPlugin(registry, pluginState.Name) {
+		isKnown, isSupported = true, true
+	}
+	// the commands of CommandSucceeds preconditions are not run in a dry run, so the step is planned without its precondition
+	preconditions := configuration.Preconditions
+	hasPreconditionCommand := configuration.IsPreconditionEnabled &&
+		containsPreconditionOperator(preconditions, preconditionOperatorCommandSucceeds)
+	if hasPreconditionCommand {
+		preconditions = nil
+	}
+	operation, logMessage := getStepExecutionOperation(
+		log,
+		pluginState.Name,
+		pluginState.Id,
+		isKnown,
+		isSupported,
+		pluginHandlerFound,
+		configuration.IsPreconditionEnabled,
+		preconditions,
+		false)
+	if operation == executeStep && hasPreconditionCommand {
+		logMessage = fmt.Sprintf("Step runs only if the commands of its precondition succeed, which are not run in a dry run. Step name: %s", pluginState.Id)
+	}
+	if operation == executeStep && isRefusedBySignaturePolicy(context, pluginState.Name) {
+		operation, logMessage = failStep, signaturePolicyMessage(pluginState.Name, pluginState.Id)
+	}
+	if isPreconditionRefusedBySignaturePolicy(context, configuration) {
+		operation, logMessage = failStep, preconditionSignaturePolicyMessage(pluginState.Id)
+	}
+
+	result := &contracts.PluginResult{PluginID: pluginState.Id, PluginName: pluginState.Name}
+	switch operation {
+	case executeStep:
+		result.Status = contracts.ResultStatusSuccess
+	case skipStep:
+		result.Status = contracts.ResultStatusSkipped
+	default:
+		result.Status = contracts.ResultStatusFailed
+	}
+	pluginOutputs[pluginState.Id] = result
+
+	return contracts.PlannedStep{
+		StepName:      pluginState.Id,
+		Action:        pluginState.Name,
+		Operation:     operation,
+		Reason:        logMessage,
+		ParallelGroup: getStringPropByName(configuration.Properties, contracts.ParallelGroupModifier),
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanPlugins(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		assert.Fail(t, "a dry run must not run any plugin")
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	plugins := []contracts.PluginState{
+		newParallelTestPlugin("check", map[string]interface{}{"onSuccess": "step:install"}),
+		newParallelTestPlugin("recover", map[string]interface{}{}),
+		newParallelTestPlugin("install", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("configure", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("windowsOnly", map[string]interface{}{}),
+		newParallelTestPlugin("unknown", map[string]interface{}{}),
+	}
+	plugins[4].Configuration.Preconditions = map[string][]contracts.PreconditionArgument{
+		"StringEquals": {
+			{InitialArgumentValue: "platformType", ResolvedArgumentValue: "platformType"},
+			{InitialArgumentValue: "Windows", ResolvedArgumentValue: "Windows"},
+		},
+	}
+	plugins[5].Name = testUnknownPlugin
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+
+	plan := PlanPlugins(contextmocks.NewMockDefault(), plugins, pluginRegistry)
+
+	var stepNames, operations []string
+	for _, step := range plan {
+		stepNames = append(stepNames, step.StepName)
+		operations = append(operations, step.Operation)
+	}
+	assert.Equal(t, []string{"check", "install", "configure", "windowsOnly", "unknown", "recover"}, stepNames)
+	assert.Equal(t, []string{executeStep, executeStep, executeStep, skipStep, failStep, skipStep}, operations)
+	assert.Equal(t, "g1", plan[1].ParallelGroup)
+	assert.Equal(t, "Step execution skipped due to branching. Step name: recover", plan[5].Reason)
+}
+
+func TestPlanPluginsDoesNotRunPreconditionCommands(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	oldRunCommand := runPreconditionCommand
+	runPreconditionCommand = func(executable string, args []string) error {
+		assert.Fail(t, "a dry run must not run precondition commands")
+		return nil
+	}
+	defer func() { runPreconditionCommand = oldRunCommand }()
+
+	plugins := []contracts.PluginState{newParallelTestPlugin("check", map[string]interface{}{})}
+	plugins[0].Configuration.Preconditions = map[string][]contracts.PreconditionArgument{
+		"not": {{Precondition: map[string][]contracts.PreconditionArgument{
+			"CommandSucceeds": {{InitialArgumentValue: "/usr/bin/true", ResolvedArgumentValue: "/usr/bin/true"}},
+		}}},
+	}
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+
+	plan := PlanPlugins(contextmocks.NewMockDefault(), plugins, pluginRegistry)
+
+	assert.Len(t, plan, 1)
+	assert.Equal(t, executeStep, plan[0].Operation)
+	assert.Equal(t, "Step runs only if the commands of its precondition succeed, which are not run in a dry run. Step name: check", plan[0].Reason)
+}
+
+func TestPlanPluginsRefusesPreconditionCommandsWhenSignedScriptsAreRequired(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	plugins := []contracts.PluginState{newParallelTestPlugin("check", map[string]interface{}{})}
+	plugins[0].Configuration.Preconditions = map[string][]contracts.PreconditionArgument{
+		"CommandSucceeds": {{InitialArgumentValue: "/usr/bin/true", ResolvedArgumentValue: "/usr/bin/true"}},
+	}
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RequireSignedScripts = true
+
+	plan := PlanPlugins(contextmocks.NewMockDefaultWithConfig(appConfig), plugins, pluginRegistry)
+
+	assert.Len(t, plan, 1)
+	assert.Equal(t, failStep, plan[0].Operation)
+	assert.Equal(t, preconditionSignaturePolicyMessage("check"), plan[0].Reason)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// isRunAlwaysStep returns whether the step runs regardless of the result of the earlier steps,
+// either because it is a finally step or runAlways step of a schema 2.2 document, or the last step with finallyStep set.
+func isRunAlwaysStep(plugins []contracts.PluginState, pluginIndex int) bool {
+	pluginState := plugins[pluginIndex]
+	if pluginState.Configuration.RunAlways {
+		return true
+	}
+	return pluginIndex == len(plugins)-1 &&
+		getStringPropByName(pluginState.Configuration.Properties, contracts.FinallyStepModifier) == contracts.ModifierValueTrue
+}
+
+// runAlwaysCancelFlag is the cancel flag of a step that runs even if the document was cancelled.
+// It only follows the document cancel flag when the agent shuts down.
+type runAlwaysCancelFlag struct {
+	*task.ChanneledCancelFlag
+}
+
+// newRunAlwaysCancelFlag creates the cancel flag of a run always step from the document cancel flag
+func newRunAlwaysCancelFlag(docCancelFlag task.CancelFlag) runAlwaysCancelFlag {
+	flag := runAlwaysCancelFlag{task.NewChanneledCancelFlag()}
+	if docCancelFlag == nil {
+		return flag
+	}
+	if docCancelFlag.ShutDown() {
+		flag.Set(task.ShutDown)
+		return flag
+	}
+	docFlagSet, release := task.WaitChannel(docCancelFlag)
+	go func() {
+		defer release()
+		// a cancel of the document is not forwarded, the step has to clean up after it
+		select {
+		case <-docFlagSet:
+			if docCancelFlag.ShutDown() {
+				flag.Set(task.ShutDown)
+			}
+		case <-flag.Done():
+		}
+	}()
+	return flag
+}
+
+// complete releases the routines waiting on the flag, including the one following the document cancel flag,
+// once the step is done
+func (flag runAlwaysCancelFlag) complete() {
+	if !flag.ShutDown() {
+		flag.Set(task.Completed)
+	}
+}
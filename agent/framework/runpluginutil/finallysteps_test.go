@@ -0,0 +1,134 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunPluginsRunsFinallyStepsAfterExitCode(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "mount", properties: map[string]interface{}{}, status: contracts.ResultStatusFailed, code: contracts.ExitWithFailure},
+		{name: "work", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "unmount", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess, runAlways: true},
+		{name: "report", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "unlock", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess, runAlways: true},
+	})
+
+	assert.Equal(t, []string{"mount", "unmount", "unlock"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["work"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["report"].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["unlock"].Status)
+}
+
+func TestRunPluginsRunsFinallyStepsAfterOnFailureExit(t *testing.T) {
+	executed, _ := runBranchingDocument(t, []branchingTestStep{
+		{name: "work", properties: map[string]interface{}{"onFailure": "exit"}, status: contracts.ResultStatusFailed, code: 1},
+		{name: "report", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "cleanup", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess, runAlways: true},
+	})
+
+	assert.Equal(t, []string{"work", "cleanup"}, executed)
+}
+
+func TestRunPluginsRunsFinallyStepsBypassedByBranching(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "work", properties: map[string]interface{}{"nextStep": "report"}, status: contracts.ResultStatusSuccess},
+		{name: "cleanup", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess, runAlways: true},
+		{name: "skipped", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "report", properties: map[string]interface{}{"isEnd": true}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"work", "report", "cleanup"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["skipped"].Status)
+}
+
+func TestRunPluginsRunsFinallyStepsOfCancelledDocument(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	plugins := []contracts.PluginState{
+		newParallelTestPlugin("work", map[string]interface{}{}),
+		newParallelTestPlugin("cleanup", map[string]interface{}{}),
+	}
+	plugins[1].Configuration.RunAlways = true
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+
+	cancelled := make(map[string]bool)
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		cancelled[config.PluginID] = cancelFlag.Canceled()
+		res.Status = contracts.ResultStatusSuccess
+		if cancelFlag.Canceled() {
+			res.Status = contracts.ResultStatusCancelled
+		}
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	close(ch)
+
+	assert.Equal(t, map[string]bool{"work": true, "cleanup": false}, cancelled)
+	assert.Equal(t, contracts.ResultStatusCancelled, outputs["work"].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["cleanup"].Status)
+}
+
+func TestRunAlwaysCancelFlagFollowsShutDown(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	flag := newRunAlwaysCancelFlag(docCancelFlag)
+	docCancelFlag.Set(task.ShutDown)
+	assert.Equal(t, task.ShutDown, flag.Wait())
+
+	docCancelFlag = task.NewChanneledCancelFlag()
+	docCancelFlag.Set(task.Canceled)
+	flag = newRunAlwaysCancelFlag(docCancelFlag)
+	flag.complete()
+	assert.Equal(t, task.Completed, flag.Wait())
+}
+
+func TestRunAlwaysCancelFlagCompleteReleasesDocumentCancelFlag(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	flag := newRunAlwaysCancelFlag(docCancelFlag)
+	routines := runtime.NumGoroutine()
+	flag.complete()
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() >= routines && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Less(t, runtime.NumGoroutine(), routines)
+	docCancelFlag.Set(task.ShutDown)
+	assert.Equal(t, task.Completed, flag.State())
+}
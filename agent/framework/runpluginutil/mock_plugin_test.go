@@ -17,6 +17,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/mock"
 )
@@ -42,3 +43,31 @@ func (m *PluginFactoryMock) Create(context context.T) (T, error) {
 	args := m.Called(context)
 	return args.Get(0).(T), args.Error(1)
 }
+
+const (
+	testPlugin0           = "plugin0"
+	testPlugin1           = "plugin1"
+	testPlugin2           = "plugin2"
+	testUnknownPlugin     = "plugin3"
+	testUnsupportedPlugin = "plugin4"
+)
+
+var origIsSupported func(log log.T, pluginName string) (isKnown bool, isSupported bool, message string)
+
+func setIsSupportedMock() {
+	origIsSupported = isSupportedPlugin
+	isSupportedPlugin = func(log log.T, pluginName string) (isKnown bool, isSupported bool, message string) {
+		switch pluginName {
+		case testUnknownPlugin:
+			return false, true, ""
+		case testUnsupportedPlugin:
+			return true, false, ""
+		default:
+			return true, true, ""
+		}
+	}
+}
+
+func restoreIsSupported() {
+	isSupportedPlugin = origIsSupported
+}
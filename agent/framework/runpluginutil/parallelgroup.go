@@ -0,0 +1,77 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// getParallelGroup returns the indexes of the steps that run together with the step at pluginIndex.
+// Consecutive schema 2.2 steps that have not run yet and declare the same parallelGroup form a group,
+// every other step forms a group of its own.
+func getParallelGroup(plugins []contracts.PluginState, pluginIndex int, visitedSteps map[int]bool) []int {
+	group := []int{pluginIndex}
+	configuration := plugins[pluginIndex].Configuration
+	groupName := getStringPropByName(configuration.Properties, contracts.ParallelGroupModifier)
+	if groupName == "" || !configuration.IsPreconditionEnabled {
+		return group
+	}
+	for index := pluginIndex + 1; index < len(plugins) && !visitedSteps[index]; index++ {
+		if getStringPropByName(plugins[index].Configuration.Properties, contracts.ParallelGroupModifier) != groupName {
+			break
+		}
+		group = append(group, index)
+	}
+	return group
+}
+
+// runStepGroup runs the given steps with at most concurrencyLimit of them at the same time.
+// Results are returned in the order of stepIndexes regardless of the order in which the steps complete,
+// together with whether any of the steps requested a reboot.
+func runStepGroup(
+	stepIndexes []int,
+	run func(stepIndex int) (contracts.PluginResult, bool),
+	concurrencyLimit int,
+) (results []contracts.PluginResult, rebootRequested bool) {
+	results = make([]contracts.PluginResult, len(stepIndexes))
+	if len(stepIndexes) == 1 {
+		results[0], rebootRequested = run(stepIndexes[0])
+		return results, rebootRequested
+	}
+
+	if concurrencyLimit <= 0 || concurrencyLimit > len(stepIndexes) {
+		concurrencyLimit = len(stepIndexes)
+	}
+	reboots := make([]bool, len(stepIndexes))
+	semaphore := make(chan struct{}, concurrencyLimit)
+	var wg sync.WaitGroup
+	for i, stepIndex := range stepIndexes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, stepIndex int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i], reboots[i] = run(stepIndex)
+		}(i, stepIndex)
+	}
+	wg.Wait()
+
+	for _, reboot := range reboots {
+		rebootRequested = rebootRequested || reboot
+	}
+	return results, rebootRequested
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newParallelTestPlugin(id string, properties map[string]interface{}) contracts.PluginState {
+	return contracts.PluginState{
+		Name: testPlugin1,
+		Id:   id,
+		Configuration: contracts.Configuration{
+			PluginID:              id,
+			PluginName:            testPlugin1,
+			Properties:            properties,
+			IsPreconditionEnabled: true,
+		},
+	}
+}
+
+func TestGetParallelGroup(t *testing.T) {
+	plugins := []contracts.PluginState{
+		newParallelTestPlugin("a", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("b", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("c", map[string]interface{}{"parallelGroup": "g2"}),
+		newParallelTestPlugin("d", map[string]interface{}{}),
+	}
+
+	assert.Equal(t, []int{0, 1}, getParallelGroup(plugins, 0, map[int]bool{}))
+	assert.Equal(t, []int{0}, getParallelGroup(plugins, 0, map[int]bool{1: true}))
+	assert.Equal(t, []int{2}, getParallelGroup(plugins, 2, map[int]bool{}))
+	assert.Equal(t, []int{3}, getParallelGroup(plugins, 3, map[int]bool{}))
+
+	plugins[0].Configuration.IsPreconditionEnabled = false
+	assert.Equal(t, []int{0}, getParallelGroup(plugins, 0, map[int]bool{}))
+}
+
+func TestRunPluginsRunsParallelGroupConcurrently(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	plugins := []contracts.PluginState{
+		newParallelTestPlugin("a", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("b", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("c", map[string]interface{}{"parallelGroup": "g1"}),
+		newParallelTestPlugin("d", map[string]interface{}{}),
+	}
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+
+	// steps complete in reverse document order, the slowest one first in the document
+	durations := map[string]time.Duration{"a": 60 * time.Millisecond, "b": 30 * time.Millisecond, "c": 0, "d": 0}
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	var executed []string
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(durations[config.PluginID])
+
+		mutex.Lock()
+		running--
+		executed = append(executed, config.PluginID)
+		mutex.Unlock()
+		res.Status = contracts.ResultStatusSuccess
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	config := appconfig.DefaultConfig()
+	config.Ssm.ParallelStepsLimit = 2
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefaultWithConfig(config), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	var reported []string
+	for result := range ch {
+		reported = append(reported, result.PluginID)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, reported, "results must be reported in document order")
+	assert.Equal(t, "d", executed[len(executed)-1], "the step after the group must wait for the group")
+	assert.NotEqual(t, "a", executed[0], "steps of the group must run at the same time")
+	assert.Equal(t, 2, maxRunning, "the group must be bounded by ParallelStepsLimit")
+	assert.Len(t, outputs, 4)
+	for _, output := range outputs {
+		assert.Equal(t, contracts.ResultStatusSuccess, output.Status)
+	}
+}
+
+func TestRunPluginsParallelGroupHonorsPriorExit(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "a", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess, code: contracts.ExitWithSuccess},
+		{name: "b", properties: map[string]interface{}{"parallelGroup": "g1"}, status: contracts.ResultStatusSuccess},
+		{name: "c", properties: map[string]interface{}{"parallelGroup": "g1"}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"a"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["b"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["c"].Status)
+}
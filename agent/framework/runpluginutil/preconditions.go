@@ -0,0 +1,385 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+)
+
+const (
+	preconditionOperatorAnd                       = "and"
+	preconditionOperatorOr                        = "or"
+	preconditionOperatorNot                       = "not"
+	preconditionOperatorStringEquals              = "StringEquals"
+	preconditionOperatorStringNotEquals           = "StringNotEquals"
+	preconditionOperatorStringLike                = "StringLike"
+	preconditionOperatorVersionGreaterThan        = "VersionGreaterThan"
+	preconditionOperatorFileExists                = "FileExists"
+	preconditionOperatorEnvironmentVariableEquals = "EnvironmentVariableEquals"
+	preconditionOperatorCommandSucceeds           = "CommandSucceeds"
+
+	// preconditionCommandTimeout bounds how long the command of a CommandSucceeds precondition may run
+	preconditionCommandTimeout = 30 * time.Second
+)
+
+// preconditionVariables resolves the built-in variables that can be compared in preconditions.
+// Assign to a variable to allow unittest to override
+var preconditionVariables = map[string]func(log log.T) (string, error){
+	"platformType": platform.PlatformType,
+	"platformName": platform.PlatformName,
+	"osVersion":    platform.PlatformVersion,
+	"architecture": func(log.T) (string, error) { return runtime.GOARCH, nil },
+	"agentVersion": func(log.T) (string, error) { return version.Version, nil },
+}
+
+// fileExists is a variable to allow unittest to override
+var fileExists = fileutil.Exists
+
+// runPreconditionCommand runs the executable with the arguments directly, without a shell, so document parameters
+// in the arguments are never expanded or interpreted. The command is killed when it runs longer than the timeout.
+// Assign to a variable to allow unittest to override
+var runPreconditionCommand = func(executable string, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preconditionCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("command timed out after %v", preconditionCommandTimeout)
+		}
+		return err
+	}
+	return nil
+}
+
+// Evaluate precondition and return precondition result and unrecognized preconditions (if any)
+func evaluatePreconditions(
+	log log.T,
+	preconditions map[string][]contracts.PreconditionArgument,
+) (bool, []string) {
+	isAllowed, unsatisfiedPreconditionList, unrecognizedPreconditionList := evaluatePreconditionSet(log, preconditions)
+	if isAllowed {
+		return true, unrecognizedPreconditionList
+	}
+	return false, append(unsatisfiedPreconditionList, unrecognizedPreconditionList...)
+}
+
+// evaluatePreconditionSet evaluates all operators of a precondition, which are all required to be satisfied.
+// Returns the descriptions of the unsatisfied operators separately from the unrecognized ones.
+func evaluatePreconditionSet(
+	log log.T,
+	preconditions map[string][]contracts.PreconditionArgument,
+) (isAllowed bool, unsatisfiedPreconditionList []string, unrecognizedPreconditionList []string) {
+	isAllowed = true
+	for key, value := range preconditions {
+		operatorAllowed, unsatisfied, unrecognized := evaluatePreconditionOperator(log, key, value)
+		if !operatorAllowed {
+			isAllowed = false
+			unsatisfiedPreconditionList = append(unsatisfiedPreconditionList, unsatisfied...)
+		}
+		unrecognizedPreconditionList = append(unrecognizedPreconditionList, unrecognized...)
+	}
+	return isAllowed, unsatisfiedPreconditionList, unrecognizedPreconditionList
+}
+
+// evaluatePreconditionOperator evaluates a single operator of a precondition with its arguments
+func evaluatePreconditionOperator(
+	log log.T,
+	key string,
+	value []contracts.PreconditionArgument,
+) (isAllowed bool, unsatisfied []string, unrecognized []string) {
+	switch key {
+	case preconditionOperatorAnd, preconditionOperatorOr, preconditionOperatorNot:
+		return evaluatePreconditionComposition(log, key, value)
+	case preconditionOperatorStringEquals, preconditionOperatorStringNotEquals, preconditionOperatorStringLike, preconditionOperatorVersionGreaterThan:
+		if problem := validatePreconditionArguments(key, value, 2); problem != "" {
+			return true, nil, []string{problem}
+		}
+		left, right, variableIndex, problem := resolveComparisonArguments(log, key, value)
+		if problem != "" {
+			return true, nil, []string{problem}
+		}
+		isAllowed, err := compareResolvedArguments(key, left, right, variableIndex)
+		if err != nil {
+			return true, nil, []string{fmt.Sprintf("\"%s\": %v", key, err)}
+		}
+		if !isAllowed {
+			return false, []string{fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue)}, nil
+		}
+		return true, nil, nil
+	case preconditionOperatorFileExists:
+		if problem := validatePreconditionArguments(key, value, 1); problem != "" {
+			return true, nil, []string{problem}
+		}
+		if !fileExists(value[0].ResolvedArgumentValue) {
+			return false, []string{fmt.Sprintf("\"%s\": [%v]", key, value[0].InitialArgumentValue)}, nil
+		}
+		return true, nil, nil
+	case preconditionOperatorEnvironmentVariableEquals:
+		if problem := validatePreconditionArguments(key, value, 2); problem != "" {
+			return true, nil, []string{problem}
+		}
+		if envValue, found := os.LookupEnv(value[0].ResolvedArgumentValue); !found || envValue != value[1].ResolvedArgumentValue {
+			return false, []string{fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue)}, nil
+		}
+		return true, nil, nil
+	case preconditionOperatorCommandSucceeds:
+		if problem := validateCommandArguments(key, value); problem != "" {
+			return true, nil, []string{problem}
+		}
+		var args []string
+		for _, arg := range value[1:] {
+			args = append(args, arg.ResolvedArgumentValue)
+		}
+		if err := runPreconditionCommand(value[0].ResolvedArgumentValue, args); err != nil {
+			log.Debugf("Precondition command %s did not succeed: %v", value[0].ResolvedArgumentValue, err)
+			var initialArgs []string
+			for _, arg := range value {
+				initialArgs = append(initialArgs, arg.InitialArgumentValue)
+			}
+			return false, []string{fmt.Sprintf("\"%s\": [%s]", key, strings.Join(initialArgs, ", "))}, nil
+		}
+		return true, nil, nil
+	default:
+		// mark for unrecognizedPrecondition (which is a form of failure)
+		return true, nil, []string{fmt.Sprintf("unrecognized operator: \"%s\"", key)}
+	}
+}
+
+// evaluatePreconditionComposition evaluates the and, or and not operators whose arguments are preconditions themselves
+func evaluatePreconditionComposition(
+	log log.T,
+	key string,
+	value []contracts.PreconditionArgument,
+) (isAllowed bool, unsatisfied []string, unrecognized []string) {
+	if len(value) == 0 || (key == preconditionOperatorNot && len(value) != 1) {
+		expected := "at least 1 argument"
+		if key == preconditionOperatorNot {
+			expected = "exactly 1 argument"
+		}
+		return true, nil, []string{fmt.Sprintf("\"%s\": operator accepts %s", key, expected)}
+	}
+	for _, arg := range value {
+		if arg.Precondition == nil {
+			return true, nil, []string{fmt.Sprintf("\"%s\": operator accepts only preconditions as arguments", key)}
+		}
+	}
+
+	satisfiedCount := 0
+	var nestedUnsatisfied []string
+	for _, arg := range value {
+		nestedAllowed, nestedUnsatisfiedList, nestedUnrecognizedList := evaluatePreconditionSet(log, arg.Precondition)
+		unrecognized = append(unrecognized, nestedUnrecognizedList...)
+		if nestedAllowed {
+			satisfiedCount++
+		} else {
+			nestedUnsatisfied = append(nestedUnsatisfied, nestedUnsatisfiedList...)
+		}
+	}
+
+	switch key {
+	case preconditionOperatorAnd:
+		return satisfiedCount == len(value), nestedUnsatisfied, unrecognized
+	case preconditionOperatorOr:
+		if satisfiedCount > 0 {
+			return true, nil, unrecognized
+		}
+		return false, []string{fmt.Sprintf("\"%s\": [%s]", key, strings.Join(nestedUnsatisfied, ", "))}, unrecognized
+	default:
+		if satisfiedCount == 0 {
+			return true, nil, unrecognized
+		}
+		return false, []string{fmt.Sprintf("\"%s\": [%s]", key, formatPreconditionSet(value[0].Precondition))}, unrecognized
+	}
+}
+
+// validatePreconditionArguments checks the number of arguments of an operator and that they contain no SSM parameters
+func validatePreconditionArguments(key string, value []contracts.PreconditionArgument, argumentCount int) string {
+	if len(value) != argumentCount {
+		if argumentCount == 1 {
+			return fmt.Sprintf("\"%s\": operator accepts exactly 1 argument", key)
+		}
+		return fmt.Sprintf("\"%s\": operator accepts exactly %d arguments", key, argumentCount)
+	}
+	return validateStringArguments(key, value)
+}
+
+// validateCommandArguments checks the arguments of CommandSucceeds: the first one is the absolute path of the executable,
+// which can't contain document parameters, and the others are passed to it unchanged
+func validateCommandArguments(key string, value []contracts.PreconditionArgument) string {
+	if len(value) == 0 {
+		return fmt.Sprintf("\"%s\": operator accepts at least 1 argument", key)
+	}
+	if problem := validateStringArguments(key, value); problem != "" {
+		return problem
+	}
+	if value[0].InitialArgumentValue != value[0].ResolvedArgumentValue {
+		return fmt.Sprintf("\"%s\": the executable can't contain document parameters", key)
+	}
+	if !filepath.IsAbs(value[0].InitialArgumentValue) {
+		return fmt.Sprintf("\"%s\": the executable must be an absolute path", key)
+	}
+	return ""
+}
+
+// validateStringArguments checks that the arguments of an operator are strings that contain no SSM parameters
+func validateStringArguments(key string, value []contracts.PreconditionArgument) string {
+	for _, arg := range value {
+		if arg.Precondition != nil {
+			return fmt.Sprintf("\"%s\": operator accepts only string arguments", key)
+		}
+	}
+	for _, arg := range value {
+		if ssmparameterresolver.TextContainsSsmParameters(arg.InitialArgumentValue) {
+			return fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", key)
+		}
+	}
+	for _, arg := range value {
+		if ssmparameterresolver.TextContainsSecureSsmParameters(arg.InitialArgumentValue) {
+			return fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", key)
+		}
+	}
+	return ""
+}
+
+// resolveComparisonArguments returns the values compared by a two argument operator and the index of the argument
+// that is a built-in variable, -1 if there is none. When an argument is a variable the other argument must be a constant,
+// otherwise at least one of the arguments must contain a document parameter.
+func resolveComparisonArguments(
+	log log.T,
+	key string,
+	value []contracts.PreconditionArgument,
+) (left string, right string, variableIndex int, problem string) {
+	if strings.Compare(value[0].InitialArgumentValue, value[1].InitialArgumentValue) == 0 {
+		// preconditions with identical arguments are not allowed
+		if _, isVariable := preconditionVariables[value[0].InitialArgumentValue]; isVariable {
+			return "", "", -1, fmt.Sprintf("\"%s\": [%v %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue)
+		}
+		// hide customer's parameters and constants
+		return "", "", -1, fmt.Sprintf("\"%s\": operator's arguments can't be identical", key)
+	}
+
+	for variableIndex, variableArg := range value {
+		resolveVariable, isVariable := preconditionVariables[variableArg.InitialArgumentValue]
+		if !isVariable {
+			continue
+		}
+		// Variable and value can be in any order, i.e. both "StringEquals": ["platformType", "Windows"]
+		// and "StringEquals": ["Windows", "platformType"] are valid
+		constantArg := value[1-variableIndex]
+		if strings.Compare(strings.ToLower(constantArg.InitialArgumentValue), strings.ToLower(constantArg.ResolvedArgumentValue)) != 0 {
+			return "", "", -1, fmt.Sprintf("\"%s\": the second argument for the %s variable can't contain document parameters", key, variableArg.InitialArgumentValue)
+		}
+		variableValue, err := resolveVariable(log)
+		if err != nil {
+			return "", "", -1, fmt.Sprintf("\"%s\": the %s variable can't be resolved on this instance", key, variableArg.InitialArgumentValue)
+		}
+		log.Debugf("Precondition variable %s of this instance = %s", variableArg.InitialArgumentValue, variableValue)
+		if variableIndex == 0 {
+			return variableValue, constantArg.InitialArgumentValue, variableIndex, ""
+		}
+		return constantArg.InitialArgumentValue, variableValue, variableIndex, ""
+	}
+
+	if strings.Compare(value[0].InitialArgumentValue, value[0].ResolvedArgumentValue) == 0 && strings.Compare(value[1].InitialArgumentValue, value[1].ResolvedArgumentValue) == 0 {
+		return "", "", -1, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter", key)
+	}
+	return value[0].ResolvedArgumentValue, value[1].ResolvedArgumentValue, -1, ""
+}
+
+// compareResolvedArguments returns whether the values satisfy the comparison operator. Variables are compared case-insensitively.
+// StringLike matches the variable against the pattern in the other argument, or the first argument against the second one.
+func compareResolvedArguments(key string, left string, right string, variableIndex int) (bool, error) {
+	isCaseInsensitive := variableIndex >= 0
+	switch key {
+	case preconditionOperatorStringEquals, preconditionOperatorStringNotEquals:
+		isEqual := left == right || (isCaseInsensitive && strings.EqualFold(left, right))
+		return isEqual == (key == preconditionOperatorStringEquals), nil
+	case preconditionOperatorStringLike:
+		if variableIndex == 1 {
+			return matchesWildcardPattern(right, left, isCaseInsensitive), nil
+		}
+		return matchesWildcardPattern(left, right, isCaseInsensitive), nil
+	default:
+		result, err := versionutil.VersionCompare(left, right)
+		return result > 0, err
+	}
+}
+
+// matchesWildcardPattern returns whether the value matches a pattern where * matches any sequence of characters and ? any single character
+func matchesWildcardPattern(value string, pattern string, isCaseInsensitive bool) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	if isCaseInsensitive {
+		expression = "(?i)" + expression
+	}
+	return regexp.MustCompile("^" + expression + "$").MatchString(value)
+}
+
+// containsPreconditionOperator returns whether the precondition or any nested precondition uses the operator
+func containsPreconditionOperator(preconditions map[string][]contracts.PreconditionArgument, operator string) bool {
+	for key, value := range preconditions {
+		if key == operator {
+			return true
+		}
+		for _, arg := range value {
+			if arg.Precondition != nil && containsPreconditionOperator(arg.Precondition, operator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatPreconditionSet describes a precondition for the step output, e.g. {"FileExists": [/etc/hosts]}
+func formatPreconditionSet(preconditions map[string][]contracts.PreconditionArgument) string {
+	var operators []string
+	for key := range preconditions {
+		operators = append(operators, key)
+	}
+	sort.Strings(operators)
+
+	var descriptions []string
+	for _, key := range operators {
+		var args []string
+		for _, arg := range preconditions[key] {
+			if arg.Precondition != nil {
+				args = append(args, formatPreconditionSet(arg.Precondition))
+			} else {
+				args = append(args, arg.InitialArgumentValue)
+			}
+		}
+		descriptions = append(descriptions, fmt.Sprintf("\"%s\": [%s]", key, strings.Join(args, ", ")))
+	}
+	return "{" + strings.Join(descriptions, ", ") + "}"
+}
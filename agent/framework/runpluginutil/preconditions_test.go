@@ -0,0 +1,150 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func setPreconditionMocks() func() {
+	oldVariables, oldFileExists, oldRunCommand := preconditionVariables, fileExists, runPreconditionCommand
+	preconditionVariables = map[string]func(log log.T) (string, error){
+		"platformType": func(log.T) (string, error) { return "linux", nil },
+		"platformName": func(log.T) (string, error) { return "Ubuntu", nil },
+		"osVersion":    func(log.T) (string, error) { return "22.04", nil },
+		"architecture": func(log.T) (string, error) { return "arm64", nil },
+		"agentVersion": func(log.T) (string, error) { return "3.3.40.0", nil },
+	}
+	fileExists = func(path string) bool { return path == "/etc/os-release" }
+	runPreconditionCommand = func(executable string, args []string) error {
+		if executable == "/usr/bin/true" || (executable == "/usr/bin/test" && len(args) == 2 && args[1] == "/var/lib/app") {
+			return nil
+		}
+		return fmt.Errorf("exit status 1")
+	}
+	return func() {
+		preconditionVariables, fileExists, runPreconditionCommand = oldVariables, oldFileExists, oldRunCommand
+	}
+}
+
+func constantArgs(values ...string) []contracts.PreconditionArgument {
+	args := make([]contracts.PreconditionArgument, len(values))
+	for i, value := range values {
+		args[i] = contracts.PreconditionArgument{InitialArgumentValue: value, ResolvedArgumentValue: value}
+	}
+	return args
+}
+
+func nestedArgs(preconditions ...map[string][]contracts.PreconditionArgument) []contracts.PreconditionArgument {
+	args := make([]contracts.PreconditionArgument, len(preconditions))
+	for i, precondition := range preconditions {
+		args[i] = contracts.PreconditionArgument{Precondition: precondition}
+	}
+	return args
+}
+
+func TestEvaluatePreconditionOperators(t *testing.T) {
+	defer setPreconditionMocks()()
+	os.Setenv("SSM_PRECONDITION_TEST", "enabled")
+	defer os.Unsetenv("SSM_PRECONDITION_TEST")
+
+	testCases := []struct {
+		name          string
+		preconditions map[string][]contracts.PreconditionArgument
+		isAllowed     bool
+		messages      []string
+	}{
+		{"platform name equals", map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformName", "ubuntu")}, true, nil},
+		{"architecture not equals", map[string][]contracts.PreconditionArgument{"StringNotEquals": constantArgs("architecture", "amd64")}, true, nil},
+		{"architecture not equals unsatisfied", map[string][]contracts.PreconditionArgument{"StringNotEquals": constantArgs("arm64", "architecture")}, false, []string{"\"StringNotEquals\": [arm64, architecture]"}},
+		{"platform name like", map[string][]contracts.PreconditionArgument{"StringLike": constantArgs("platformName", "Ubu*")}, true, nil},
+		{"platform name like with variable second", map[string][]contracts.PreconditionArgument{"StringLike": constantArgs("Red?Hat*", "platformName")}, false, []string{"\"StringLike\": [Red?Hat*, platformName]"}},
+		{"os version greater than", map[string][]contracts.PreconditionArgument{"VersionGreaterThan": constantArgs("osVersion", "20.04")}, true, nil},
+		{"agent version not greater than", map[string][]contracts.PreconditionArgument{"VersionGreaterThan": constantArgs("agentVersion", "3.3.40.0")}, false, []string{"\"VersionGreaterThan\": [agentVersion, 3.3.40.0]"}},
+		{"invalid version", map[string][]contracts.PreconditionArgument{"VersionGreaterThan": constantArgs("osVersion", "latest")}, true, []string{"\"VersionGreaterThan\": Invalid version string latest"}},
+		{"file exists", map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/os-release")}, true, nil},
+		{"file does not exist", map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/redhat-release")}, false, []string{"\"FileExists\": [/etc/redhat-release]"}},
+		{"file exists with two arguments", map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/a", "/b")}, true, []string{"\"FileExists\": operator accepts exactly 1 argument"}},
+		{"environment variable equals", map[string][]contracts.PreconditionArgument{"EnvironmentVariableEquals": constantArgs("SSM_PRECONDITION_TEST", "enabled")}, true, nil},
+		{"environment variable not set", map[string][]contracts.PreconditionArgument{"EnvironmentVariableEquals": constantArgs("SSM_PRECONDITION_UNSET", "")}, false, []string{"\"EnvironmentVariableEquals\": [SSM_PRECONDITION_UNSET, ]"}},
+		{"command succeeds", map[string][]contracts.PreconditionArgument{"CommandSucceeds": constantArgs("/usr/bin/true")}, true, nil},
+		{"command fails", map[string][]contracts.PreconditionArgument{"CommandSucceeds": constantArgs("/usr/bin/false")}, false, []string{"\"CommandSucceeds\": [/usr/bin/false]"}},
+		{"command with document parameter argument", map[string][]contracts.PreconditionArgument{"CommandSucceeds": {
+			{InitialArgumentValue: "/usr/bin/test", ResolvedArgumentValue: "/usr/bin/test"},
+			{InitialArgumentValue: "-d", ResolvedArgumentValue: "-d"},
+			{InitialArgumentValue: "{{ directory }}", ResolvedArgumentValue: "/var/lib/app"},
+		}}, true, nil},
+		{"command with document parameter executable", map[string][]contracts.PreconditionArgument{"CommandSucceeds": {
+			{InitialArgumentValue: "{{ executable }}", ResolvedArgumentValue: "/usr/bin/true"},
+		}}, true, []string{"\"CommandSucceeds\": the executable can't contain document parameters"}},
+		{"command with relative executable", map[string][]contracts.PreconditionArgument{"CommandSucceeds": constantArgs("true")}, true, []string{"\"CommandSucceeds\": the executable must be an absolute path"}},
+		{"command without arguments", map[string][]contracts.PreconditionArgument{"CommandSucceeds": {}}, true, []string{"\"CommandSucceeds\": operator accepts at least 1 argument"}},
+		{"command with SSM parameter", map[string][]contracts.PreconditionArgument{"CommandSucceeds": constantArgs("/usr/bin/test", "{{ssm:command}}")}, true, []string{"\"CommandSucceeds\": operator's arguments can't contain SSM parameters"}},
+		{"or with one satisfied branch", map[string][]contracts.PreconditionArgument{"or": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformName", "Amazon Linux")},
+			map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformName", "Ubuntu")},
+		)}, true, nil},
+		{"or without satisfied branch", map[string][]contracts.PreconditionArgument{"or": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformName", "Amazon Linux")},
+			map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/redhat-release")},
+		)}, false, []string{"\"or\": [\"StringEquals\": [platformName, Amazon Linux], \"FileExists\": [/etc/redhat-release]]"}},
+		{"and with unsatisfied branch", map[string][]contracts.PreconditionArgument{"and": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformType", "Linux")},
+			map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/redhat-release")},
+		)}, false, []string{"\"FileExists\": [/etc/redhat-release]"}},
+		{"not", map[string][]contracts.PreconditionArgument{"not": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/redhat-release")},
+		)}, true, nil},
+		{"not unsatisfied", map[string][]contracts.PreconditionArgument{"not": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"FileExists": constantArgs("/etc/os-release")},
+		)}, false, []string{"\"not\": [{\"FileExists\": [/etc/os-release]}]"}},
+		{"not with string argument", map[string][]contracts.PreconditionArgument{"not": constantArgs("platformType")}, true, []string{"\"not\": operator accepts only preconditions as arguments"}},
+		{"unrecognized operator in satisfied or", map[string][]contracts.PreconditionArgument{"or": nestedArgs(
+			map[string][]contracts.PreconditionArgument{"StringEquals": constantArgs("platformName", "Ubuntu")},
+			map[string][]contracts.PreconditionArgument{"foo": constantArgs("bar")},
+		)}, true, []string{"unrecognized operator: \"foo\""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			isAllowed, messages := evaluatePreconditions(logmocks.NewMockLog(), testCase.preconditions)
+			assert.Equal(t, testCase.isAllowed, isAllowed)
+			assert.Equal(t, testCase.messages, messages)
+		})
+	}
+}
+
+func TestEvaluatePreconditionsResolvesDocumentParameters(t *testing.T) {
+	defer setPreconditionMocks()()
+
+	preconditions := map[string][]contracts.PreconditionArgument{
+		"StringLike": {
+			{InitialArgumentValue: "{{ environment }}", ResolvedArgumentValue: "production-eu"},
+			{InitialArgumentValue: "production-*", ResolvedArgumentValue: "production-*"},
+		},
+	}
+
+	isAllowed, messages := evaluatePreconditions(logmocks.NewMockLog(), preconditions)
+
+	assert.True(t, isAllowed)
+	assert.Empty(t, messages)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// getResumePolicy returns the resumePolicy input of a step, steps of documents before schema 2.2 are rerun
+func getResumePolicy(pluginState contracts.PluginState) string {
+	if !pluginState.Configuration.IsPreconditionEnabled {
+		return contracts.ResumePolicyRerun
+	}
+	if policy := getStringPropByName(pluginState.Configuration.Properties, contracts.ResumePolicyModifier); policy != "" {
+		return policy
+	}
+	return contracts.ResumePolicyRerun
+}
+
+// resumeStep applies the resume policy of a step that had not completed when the agent stopped unexpectedly.
+// Returns whether the step still has to run, otherwise pluginOutput holds the result of the step.
+func resumeStep(log log.T, pluginState contracts.PluginState, pluginOutput *contracts.PluginResult) bool {
+	policy := getResumePolicy(pluginState)
+	switch policy {
+	case contracts.ResumePolicySkip:
+		message := fmt.Sprintf("Step execution skipped because the agent stopped unexpectedly while running the document. Step name: %s", pluginState.Id)
+		log.Info(message)
+		pluginOutput.Status = contracts.ResultStatusSkipped
+		pluginOutput.Code = 0
+		pluginOutput.Output = message
+	case contracts.ResumePolicyFail:
+		message := fmt.Sprintf("Step failed because the agent stopped unexpectedly while running the document. Step name: %s", pluginState.Id)
+		log.Error(message)
+		pluginOutput.Status = contracts.ResultStatusFailed
+		pluginOutput.Code = 1
+		pluginOutput.Output = message
+		pluginOutput.Error = message
+	default:
+		log.Infof("Step %s had not completed when the agent stopped unexpectedly, running it again", pluginState.Id)
+		return true
+	}
+	pluginOutput.EndDateTime = time.Now()
+	return false
+}
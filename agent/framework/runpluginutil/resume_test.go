@@ -0,0 +1,165 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type resumeTestStep struct {
+	name       string
+	properties map[string]interface{}
+	// previous is the result of the step in the state file when the document worker stopped
+	previous contracts.ResultStatus
+	// started marks a step that had started running when the document worker stopped
+	started bool
+	status  contracts.ResultStatus
+}
+
+// runResumedDocument resumes the given schema 2.2 steps after their document worker stopped unexpectedly,
+// it returns the names of the steps run again in order together with the step outputs.
+func runResumedDocument(t *testing.T, steps []resumeTestStep) ([]string, map[string]*contracts.PluginResult) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	orchestrationDirectory := t.TempDir()
+	stepResults := make(map[string]resumeTestStep)
+	docState := contracts.DocumentState{}
+	for _, step := range steps {
+		stepResults[step.name] = step
+		stepDirectory := filepath.Join(orchestrationDirectory, step.name)
+		if step.started {
+			assert.NoError(t, docmanager.MarkStepStarted(stepDirectory))
+		}
+		docState.InstancePluginsInformation = append(docState.InstancePluginsInformation, contracts.PluginState{
+			Name: testPlugin1,
+			Id:   step.name,
+			Configuration: contracts.Configuration{
+				PluginID:               step.name,
+				PluginName:             testPlugin1,
+				Properties:             step.properties,
+				OrchestrationDirectory: stepDirectory,
+				IsPreconditionEnabled:  true,
+			},
+			Result: contracts.PluginResult{Status: step.previous},
+		})
+	}
+	docState.MarkInterruptedSteps(docmanager.IsStepStarted)
+
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+
+	var executed []string
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		executed = append(executed, config.PluginID)
+		res.Status = stepResults[config.PluginID].status
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	ch := make(chan contracts.PluginResult, len(steps))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), docState.InstancePluginsInformation, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	for _, name := range executed {
+		// a later resume tells the steps that started running apart from the ones that did not
+		assert.True(t, docmanager.IsStepStarted(filepath.Join(orchestrationDirectory, name)), name)
+	}
+	return executed, outputs
+}
+
+func TestRunPluginsResumesInterruptedStepByDefault(t *testing.T) {
+	executed, outputs := runResumedDocument(t, []resumeTestStep{
+		{name: "prepare", properties: map[string]interface{}{}, previous: contracts.ResultStatusSuccess, started: true},
+		{name: "install", properties: map[string]interface{}{}, started: true, status: contracts.ResultStatusSuccess},
+		{name: "configure", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"install", "configure"}, executed)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["prepare"].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["install"].Status)
+}
+
+func TestRunPluginsSkipsInterruptedStepWithSkipPolicy(t *testing.T) {
+	executed, outputs := runResumedDocument(t, []resumeTestStep{
+		{name: "prepare", properties: map[string]interface{}{}, previous: contracts.ResultStatusSuccess, started: true},
+		{name: "install", properties: map[string]interface{}{"resumePolicy": "skip"}, started: true, status: contracts.ResultStatusSuccess},
+		// the step had not started when the worker stopped, it runs as usual
+		{name: "configure", properties: map[string]interface{}{"resumePolicy": "skip"}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"configure"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["install"].Status)
+	assert.Contains(t, outputs["install"].Output, "agent stopped unexpectedly")
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["configure"].Status)
+}
+
+func TestRunPluginsFailsInterruptedStepWithFailPolicy(t *testing.T) {
+	executed, outputs := runResumedDocument(t, []resumeTestStep{
+		{name: "install", properties: map[string]interface{}{"resumePolicy": "fail", "onFailure": "step:rollback"}, started: true, status: contracts.ResultStatusSuccess},
+		{name: "configure", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "rollback", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"rollback"}, executed)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["install"].Status)
+	assert.Equal(t, 1, outputs["install"].Code)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["configure"].Status)
+}
+
+func TestRunPluginsResumesEveryInterruptedStepOfParallelGroup(t *testing.T) {
+	executed, outputs := runResumedDocument(t, []resumeTestStep{
+		{name: "fetch", properties: map[string]interface{}{"parallelGroup": "download", "resumePolicy": "skip"}, started: true, status: contracts.ResultStatusSuccess},
+		{name: "mirror", properties: map[string]interface{}{"parallelGroup": "download", "resumePolicy": "fail"}, started: true, status: contracts.ResultStatusSuccess},
+		{name: "verify", properties: map[string]interface{}{"resumePolicy": "skip"}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"verify"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["fetch"].Status)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["mirror"].Status)
+}
+
+func TestGetResumePolicy(t *testing.T) {
+	pluginState := contracts.PluginState{
+		Configuration: contracts.Configuration{
+			Properties:            map[string]interface{}{"resumePolicy": "skip"},
+			IsPreconditionEnabled: true,
+		},
+	}
+	assert.Equal(t, contracts.ResumePolicySkip, getResumePolicy(pluginState))
+
+	pluginState.Configuration.IsPreconditionEnabled = false
+	assert.Equal(t, contracts.ResumePolicyRerun, getResumePolicy(pluginState))
+
+	pluginState.Configuration = contracts.Configuration{Properties: map[string]interface{}{}, IsPreconditionEnabled: true}
+	assert.Equal(t, contracts.ResumePolicyRerun, getResumePolicy(pluginState))
+}
@@ -25,12 +25,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutputs"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
-	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
-	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -80,6 +81,63 @@ var allPlugins = map[string]struct{}{
 	appconfig.PluginRunDocument:                {},
 }
 
+// isExternalPlugin returns whether the plugin is an external plugin, the plugin factory registers the ones
+// it discovered on the instance
+func isExternalPlugin(registry PluginRegistry, pluginName string) bool {
+	_, found := registry[pluginName]
+	return found && strings.HasPrefix(pluginName, appconfig.PluginNameCustomPrefix)
+}
+
+// signedScriptsPlugins are the plugins that run when the agent only runs signed scripts, they either verify the
+// signature of the code of the document they run or run no code of the document at all
+var signedScriptsPlugins = map[string]struct{}{
+	appconfig.PluginNameAwsAgentUpdate:       {},
+	appconfig.PluginNameAwsRunShellScript:    {},
+	appconfig.PluginNameAwsSoftwareInventory: {},
+	appconfig.PluginNameCloudWatch:           {},
+	appconfig.PluginNameConfigureDocker:      {},
+	appconfig.PluginNameDomainJoin:           {},
+	appconfig.PluginEC2ConfigUpdate:          {},
+	appconfig.PluginNameRefreshAssociation:   {},
+	appconfig.PluginDownloadContent:          {},
+	appconfig.PluginRunDocument:              {},
+	appconfig.PluginNameStandardStream:       {},
+	appconfig.PluginNamePort:                 {},
+}
+
+// isRefusedBySignaturePolicy returns whether the step cannot run because the agent only runs signed scripts and
+// the plugin is not known to verify the code it runs, which external plugins never are
+func isRefusedBySignaturePolicy(context context.T, pluginName string) bool {
+	if !context.AppConfig().Ssm.RequireSignedScripts {
+		return false
+	}
+	_, found := signedScriptsPlugins[pluginName]
+	return !found
+}
+
+// signaturePolicyMessage is the reason of the steps refused by the signature policy of the agent
+func signaturePolicyMessage(pluginName, pluginID string) string {
+	return fmt.Sprintf(
+		"Plugin with name %s runs commands that cannot be signed and the agent only runs signed scripts. Step name: %s",
+		pluginName,
+		pluginID)
+}
+
+// isPreconditionRefusedBySignaturePolicy returns whether the precondition of the step cannot be evaluated because the
+// agent only runs signed scripts and the precondition runs a command, which cannot carry a signature
+func isPreconditionRefusedBySignaturePolicy(context context.T, configuration contracts.Configuration) bool {
+	return context.AppConfig().Ssm.RequireSignedScripts &&
+		configuration.IsPreconditionEnabled &&
+		containsPreconditionOperator(configuration.Preconditions, preconditionOperatorCommandSucceeds)
+}
+
+// preconditionSignaturePolicyMessage is the reason of the steps whose precondition is refused by the signature policy
+func preconditionSignaturePolicyMessage(pluginID string) string {
+	return fmt.Sprintf(
+		"Precondition of the step runs a command that cannot be signed and the agent only runs signed scripts. Step name: %s",
+		pluginID)
+}
+
 // allSessionPlugins is the list of all known session plugins.
 var allSessionPlugins = map[string]struct{}{
 	appconfig.PluginNameStandardStream:         {},
@@ -107,6 +165,10 @@ func RunPlugins(
 
 	pluginOutputs = make(map[string]*contracts.PluginResult)
 
+	// the output masks the secret values of the document and the ones resolved by its steps until it completes
+	endSecretMasking := secretmask.Begin(ioConfig.SecretValues...)
+	defer endSecretMasking()
+
 	//Contains the logStreamPrefix without the pluginID
 	logStreamPrefix := ioConfig.CloudWatchConfig.LogStreamPrefix
 	log := context.Log()
@@ -118,83 +180,203 @@ func RunPlugins(
 		}
 	}()
 
-	for pluginIndex, pluginState := range plugins {
-		pluginID := pluginState.Id     // the identifier of the plugin
-		pluginName := pluginState.Name // the name of the plugin
-		pluginOutput := pluginState.Result
-		pluginOutput.PluginID = pluginID
-		pluginOutput.PluginName = pluginName
-		pluginOutputs[pluginID] = &pluginOutput
-		log.Debugf("Checking Status for plugin %s - %s", pluginName, pluginOutput.Status)
-		switch pluginOutput.Status {
-		//TODO properly initialize the plugin status
-		case "":
-			log.Debugf("plugin - %v has empty state, initialize as NotStarted",
-				pluginName)
-			pluginOutput.StartDateTime = time.Now()
-			pluginOutput.Status = contracts.ResultStatusNotStarted
-
-		case contracts.ResultStatusNotStarted, contracts.ResultStatusInProgress:
-			log.Debugf("plugin - %v status %v",
-				pluginName,
-				pluginOutput.Status)
-			pluginOutput.StartDateTime = time.Now()
-
-		case contracts.ResultStatusSuccessAndReboot:
-			log.Debugf("plugin - %v just experienced reboot, reset to InProgress...",
-				pluginName)
-			pluginOutput.Status = contracts.ResultStatusInProgress
-		case contracts.ResultStatusFailed:
-			log.Debugf("plugin - %v already executed with failed status, skipping...",
-				pluginName)
-			resChan <- *pluginOutputs[pluginID]
-			continue
-		default:
-			log.Debugf("plugin - %v already executed, skipping...",
-				pluginName)
+	// steps run in document order unless a schema 2.2 step branches to another step
+	// or consecutive steps share a parallelGroup, in which case they run at the same time
+	path := newExecutionPath()
+	isRebooting := false
+	for pluginIndex := 0; pluginIndex < len(plugins); pluginIndex = getNextPluginIndex(log, plugins, pluginIndex, pluginOutputs, path) {
+		stepGroup := getParallelGroup(plugins, pluginIndex, path.visitedSteps)
+		var stepsToRun []int
+		for _, stepIndex := range stepGroup {
+			path.visitedSteps[stepIndex] = true
+			if initializePluginOutput(log, plugins[stepIndex], pluginOutputs, resChan) {
+				stepsToRun = append(stepsToRun, stepIndex)
+			}
+		}
+		// the last step of the group decides where the document continues
+		pluginIndex = stepGroup[len(stepGroup)-1]
+		if len(stepsToRun) == 0 {
 			continue
 		}
 
-		log.Debugf("Executing plugin - %v", pluginName)
+		// outputs of the steps that already ran, steps of a group cannot reference each other
+		stepOutputs := getStepOutputs(pluginOutputs)
 
-		// populate plugin start time, status, and upstream service name
-		configuration := pluginState.Configuration
-		configuration.UpstreamServiceName = upstreamServiceName
+		// checking if a prior step returned exit codes 168 or 169 to exit document.
+		// If so we need to skip every other step. Steps of a group are checked before any of them starts.
+		skipDueToPriorFailedStep := make(map[int]bool, len(stepsToRun))
+		for _, stepIndex := range stepsToRun {
+			skipDueToPriorFailedStep[stepIndex] = getShouldPluginSkipBasedOnControlFlow(
+				context,
+				plugins,
+				stepIndex,
+				pluginOutputs,
+			)
+		}
 
-		if ioConfig.OutputS3BucketName != "" {
-			pluginOutputs[pluginID].OutputS3BucketName = ioConfig.OutputS3BucketName
-			if ioConfig.OutputS3KeyPrefix != "" {
-				pluginOutputs[pluginID].OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, pluginName)
+		// steps that were running when the document worker stopped unexpectedly follow their resume policy
+		resumedWithoutRun := make(map[int]bool)
+		for _, stepIndex := range stepsToRun {
+			pluginState := plugins[stepIndex]
+			if pluginState.Configuration.IsResumed && !skipDueToPriorFailedStep[stepIndex] {
+				resumedWithoutRun[stepIndex] = !resumeStep(log, pluginState, pluginOutputs[pluginState.Id])
+			}
+		}
 
+		results, rebootRequested := runStepGroup(stepsToRun, func(stepIndex int) (result contracts.PluginResult, rebootRequested bool) {
+			pluginState := plugins[stepIndex]
+			if resumedWithoutRun[stepIndex] {
+				return *pluginOutputs[pluginState.Id], false
 			}
+			defer func() {
+				// steps of a parallel group run on their own goroutine which the panic handler above does not cover
+				if r := recover(); r != nil {
+					log.Errorf("Run plugin %s panic: \n%v", pluginState.Id, r)
+					log.Errorf("Stacktrace:\n%s", debug.Stack())
+					pluginOutputs[pluginState.Id].Status = contracts.ResultStatusFailed
+					pluginOutputs[pluginState.Id].Error = fmt.Sprintf("Plugin %s panicked: %v", pluginState.Id, r)
+					pluginOutputs[pluginState.Id].EndDateTime = time.Now()
+					result, rebootRequested = *pluginOutputs[pluginState.Id], false
+				}
+			}()
+			return runStep(
+				context,
+				pluginState,
+				pluginOutputs[pluginState.Id],
+				ioConfig,
+				logStreamPrefix,
+				upstreamServiceName,
+				registry,
+				cancelFlag,
+				stepOutputs,
+				skipDueToPriorFailedStep[stepIndex])
+		}, context.AppConfig().Ssm.ParallelStepsLimit)
+
+		// send to buffer channel in document order, guaranteed to not block since buffer size is plugin number
+		for _, result := range results {
+			resChan <- result
 		}
-		//Append pluginID to logStreamPrefix. Replace ':' or '*' with '-' since LogStreamNames cannot have those characters
-		if ioConfig.CloudWatchConfig.LogGroupName != "" {
-			ioConfig.CloudWatchConfig.LogStreamPrefix = fmt.Sprintf("%s/%s", logStreamPrefix, pluginID)
-			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, ":", "-", -1)
-			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, "*", "-", -1)
+
+		//TODO handle cancelFlag here
+		if rebootRequested {
+			// do not execute the the next plugin
+			isRebooting = true
+			break
 		}
+	}
+	if !isRebooting {
+		skipStepsNotOnPath(log, plugins, path.visitedSteps, pluginOutputs, resChan)
+	}
+	// this will clean the orchestration folder for the successful and failed document executions only when the agent is configured
+	orchestrationDirCleanup(context, len(plugins), pluginOutputs, ioConfig.OrchestrationDirectory)
+	// the output spool is pruned once the streams of every step of the document are closed
+	iohandler.PruneOutputSpool(context)
+	return
+}
 
-		var (
-			r                  contracts.PluginResult
-			pluginFactory      PluginFactory
-			pluginHandlerFound bool
-			isKnown            bool
-			isSupported        bool
-		)
+// initializePluginOutput adds the output of a step to pluginOutputs and returns whether the step still has to run
+func initializePluginOutput(
+	log log.T,
+	pluginState contracts.PluginState,
+	pluginOutputs map[string]*contracts.PluginResult,
+	resChan chan contracts.PluginResult,
+) bool {
+	pluginID := pluginState.Id     // the identifier of the plugin
+	pluginName := pluginState.Name // the name of the plugin
+	pluginOutput := pluginState.Result
+	pluginOutput.PluginID = pluginID
+	pluginOutput.PluginName = pluginName
+	pluginOutputs[pluginID] = &pluginOutput
+	log.Debugf("Checking Status for plugin %s - %s", pluginName, pluginOutput.Status)
+	switch pluginOutput.Status {
+	//TODO properly initialize the plugin status
+	case "":
+		log.Debugf("plugin - %v has empty state, initialize as NotStarted",
+			pluginName)
+		pluginOutput.StartDateTime = time.Now()
+		pluginOutput.Status = contracts.ResultStatusNotStarted
+
+	case contracts.ResultStatusNotStarted, contracts.ResultStatusInProgress:
+		log.Debugf("plugin - %v status %v",
+			pluginName,
+			pluginOutput.Status)
+		pluginOutput.StartDateTime = time.Now()
+
+	case contracts.ResultStatusSuccessAndReboot:
+		log.Debugf("plugin - %v just experienced reboot, reset to InProgress...",
+			pluginName)
+		pluginOutput.Status = contracts.ResultStatusInProgress
+	case contracts.ResultStatusFailed:
+		log.Debugf("plugin - %v already executed with failed status, skipping...",
+			pluginName)
+		resChan <- *pluginOutputs[pluginID]
+		return false
+	default:
+		log.Debugf("plugin - %v already executed, skipping...",
+			pluginName)
+		return false
+	}
+	return true
+}
 
-		pluginFactory, pluginHandlerFound = registry[pluginName]
-		isKnown, isSupported, _ = isSupportedPlugin(log, pluginName)
-		// checking if a prior step returned exit codes 168 or 169 to exit document.
-		// If so we need to skip every other step
-		shouldSkipStepDueToPriorFailedStep := getShouldPluginSkipBasedOnControlFlow(
-			context,
-			plugins,
-			pluginIndex,
-			pluginOutputs,
-		)
+// runStep executes a single step and records its result in pluginOutput.
+// Returns the truncated result to report and whether the step requested a reboot.
+func runStep(
+	context context.T,
+	pluginState contracts.PluginState,
+	pluginOutput *contracts.PluginResult,
+	ioConfig contracts.IOConfiguration,
+	logStreamPrefix string,
+	upstreamServiceName contracts.UpstreamServiceName,
+	registry PluginRegistry,
+	cancelFlag task.CancelFlag,
+	stepOutputs map[string]map[string]string,
+	shouldSkipStepDueToPriorFailedStep bool,
+) (result contracts.PluginResult, rebootRequested bool) {
+	log := context.Log()
+	pluginID := pluginState.Id
+	pluginName := pluginState.Name
+	log.Debugf("Executing plugin - %v", pluginName)
+
+	// populate plugin start time, status, and upstream service name
+	configuration := pluginState.Configuration
+	configuration.UpstreamServiceName = upstreamServiceName
+
+	if ioConfig.OutputS3BucketName != "" {
+		pluginOutput.OutputS3BucketName = ioConfig.OutputS3BucketName
+		if ioConfig.OutputS3KeyPrefix != "" {
+			pluginOutput.OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, pluginName)
+
+		}
+	}
+	//Append pluginID to logStreamPrefix. Replace ':' or '*' with '-' since LogStreamNames cannot have those characters
+	if ioConfig.CloudWatchConfig.LogGroupName != "" {
+		ioConfig.CloudWatchConfig.LogStreamPrefix = fmt.Sprintf("%s/%s", logStreamPrefix, pluginID)
+		ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, ":", "-", -1)
+		ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, "*", "-", -1)
+	}
+
+	var (
+		r                  contracts.PluginResult
+		pluginFactory      PluginFactory
+		pluginHandlerFound bool
+		isKnown            bool
+		isSupported        bool
+	)
+
+	pluginFactory, pluginHandlerFound = registry[pluginName]
+	isKnown, isSupported, _ = isSupportedPlugin(log, pluginName)
+	if isExternalPlugin(registry, pluginName) {
+		isKnown, isSupported = true, true
+	}
 
-		operation, logMessage := getStepExecutionOperation(
+	var operation string
+	var logMessage string
+	// the command of the precondition must not run, so the step fails before its precondition is evaluated
+	if isPreconditionRefusedBySignaturePolicy(context, configuration) {
+		operation, logMessage = failStep, preconditionSignaturePolicyMessage(pluginID)
+	} else {
+		operation, logMessage = getStepExecutionOperation(
 			log,
 			pluginName,
 			pluginID,
@@ -204,80 +386,101 @@ func RunPlugins(
 			configuration.IsPreconditionEnabled,
 			configuration.Preconditions,
 			shouldSkipStepDueToPriorFailedStep)
+	}
+	if operation == executeStep && isRefusedBySignaturePolicy(context, pluginName) {
+		operation, logMessage = failStep, signaturePolicyMessage(pluginName, pluginID)
+	}
 
-		switch operation {
-		case executeStep:
-			log.Infof("Running plugin %s %s", pluginName, pluginID)
-			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
-			pluginOutputs[pluginID].Code = r.Code
-			pluginOutputs[pluginID].Status = r.Status
-			pluginOutputs[pluginID].Error = r.Error
-			pluginOutputs[pluginID].StandardError = r.StandardError
-			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
-			pluginOutputs[pluginID].Output = r.Output
-			pluginOutputs[pluginID].StepName = r.StepName
-
-			onFailureProp := getStringPropByName(pluginState.Configuration.Properties, contracts.OnFailureModifier)
-			hasOnFailureProp := onFailureProp == contracts.ModifierValueExit || onFailureProp == contracts.ModifierValueSuccessAndExit
-			outputAddition := ""
-			if pluginOutputs[pluginID].Code == contracts.ExitWithSuccess {
-				outputAddition = "\nStep exited with code 168. Therefore, marking step as succeeded. Further document steps will be skipped."
-				pluginOutputs[pluginID].Status = contracts.ResultStatusSuccess
-				pluginOutputs[pluginID].Error = ""
-				pluginOutputs[pluginID].StandardError = ""
-				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
-			} else if pluginOutputs[pluginID].Code == contracts.ExitWithFailure {
-				outputAddition = "\nStep exited with code 169. Therefore, marking step as Failed. Further document steps will be skipped."
-				pluginOutputs[pluginID].StandardError = r.StandardError + outputAddition
-				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
-			} else if pluginOutputs[pluginID].Status == contracts.ResultStatusFailed && hasOnFailureProp {
-				outputAddition = "\nStep was found to have onFailure property. Further document steps will be skipped."
-				pluginOutputs[pluginID].StandardError = r.StandardError + outputAddition
-				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
-				if onFailureProp == contracts.ModifierValueSuccessAndExit {
-					pluginOutputs[pluginID].Status = contracts.ResultStatusSuccess
-					pluginOutputs[pluginID].Code = contracts.ExitWithSuccess
-				}
+	switch operation {
+	case executeStep:
+		if configuration.IsPreconditionEnabled {
+			// references to the outputs of earlier steps are resolved when the step starts
+			resolvedProperties, err := stepoutputs.Resolve(configuration.Properties, stepOutputs)
+			if err != nil {
+				err = fmt.Errorf("Failed to resolve step outputs for step %s: %v", pluginID, err)
+				pluginOutput.Status = contracts.ResultStatusFailed
+				pluginOutput.Code = 1
+				pluginOutput.Error = err.Error()
+				log.Error(err)
+				break
 			}
-
-		case skipStep:
-			log.Info(logMessage)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusSkipped
-			pluginOutputs[pluginID].Code = 0
-			pluginOutputs[pluginID].Output = logMessage
-		case failStep:
-			err := fmt.Errorf(logMessage)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
-			pluginOutputs[pluginID].Error = err.Error()
-			log.Error(err)
-		default:
-			err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
-			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
-			pluginOutputs[pluginID].Error = err.Error()
-			log.Error(err)
+			configuration.Properties = resolvedProperties
 		}
+		stepCancelFlag := cancelFlag
+		if configuration.RunAlways {
+			// finally steps run to completion even if the document was cancelled
+			runAlwaysCancelFlag := newRunAlwaysCancelFlag(cancelFlag)
+			defer runAlwaysCancelFlag.complete()
+			stepCancelFlag = runAlwaysCancelFlag
+		}
+		if configuration.OrchestrationDirectory != "" {
+			// a step that started running follows its resume policy if the document worker stops unexpectedly
+			if err := docmanager.MarkStepStarted(configuration.OrchestrationDirectory); err != nil {
+				log.Warnf("Failed to record that step %s started: %v", pluginID, err)
+			}
+		}
+		log.Infof("Running plugin %s %s", pluginName, pluginID)
+		r = runPluginWithRetry(context, pluginFactory, pluginName, configuration, stepCancelFlag, ioConfig)
+		pluginOutput.Code = r.Code
+		pluginOutput.Status = r.Status
+		pluginOutput.Error = r.Error
+		pluginOutput.StandardError = r.StandardError
+		pluginOutput.StandardOutput = r.StandardOutput
+		pluginOutput.Output = r.Output
+		pluginOutput.StepName = r.StepName
+		pluginOutput.StepOutputs = r.StepOutputs
+
+		onFailureProp := getStringPropByName(pluginState.Configuration.Properties, contracts.OnFailureModifier)
+		hasOnFailureProp := onFailureProp == contracts.ModifierValueExit || onFailureProp == contracts.ModifierValueSuccessAndExit
+		outputAddition := ""
+		if pluginOutput.Code == contracts.ExitWithSuccess {
+			outputAddition = "\nStep exited with code 168. Therefore, marking step as succeeded. Further document steps will be skipped."
+			pluginOutput.Status = contracts.ResultStatusSuccess
+			pluginOutput.Error = ""
+			pluginOutput.StandardError = ""
+			pluginOutput.StandardOutput = r.StandardOutput + outputAddition
+		} else if pluginOutput.Code == contracts.ExitWithFailure {
+			outputAddition = "\nStep exited with code 169. Therefore, marking step as Failed. Further document steps will be skipped."
+			pluginOutput.StandardError = r.StandardError + outputAddition
+			pluginOutput.StandardOutput = r.StandardOutput + outputAddition
+		} else if pluginOutput.Status == contracts.ResultStatusFailed && hasOnFailureProp {
+			outputAddition = "\nStep was found to have onFailure property. Further document steps will be skipped."
+			pluginOutput.StandardError = r.StandardError + outputAddition
+			pluginOutput.StandardOutput = r.StandardOutput + outputAddition
+			if onFailureProp == contracts.ModifierValueSuccessAndExit {
+				pluginOutput.Status = contracts.ResultStatusSuccess
+				pluginOutput.Code = contracts.ExitWithSuccess
+			}
+		}
+
+	case skipStep:
+		log.Info(logMessage)
+		pluginOutput.Status = contracts.ResultStatusSkipped
+		pluginOutput.Code = 0
+		pluginOutput.Output = logMessage
+	case failStep:
+		err := fmt.Errorf(logMessage)
+		pluginOutput.Status = contracts.ResultStatusFailed
+		pluginOutput.Error = err.Error()
+		log.Error(err)
+	default:
+		err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
+		pluginOutput.Status = contracts.ResultStatusFailed
+		pluginOutput.Error = err.Error()
+		log.Error(err)
+	}
 
-		// set end time.
-		pluginOutputs[pluginID].EndDateTime = time.Now()
-		log.Infof("Sending plugin %v completion message", pluginID)
+	// set end time.
+	pluginOutput.EndDateTime = time.Now()
+	log.Infof("Sending plugin %v completion message", pluginID)
 
-		// truncate the result and send it back to buffer channel.
-		result := *pluginOutputs[pluginID]
-		pluginConfig := iohandler.DefaultOutputConfig()
-		result.StandardOutput = pluginutil.StringPrefix(result.StandardOutput, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
-		result.StandardError = pluginutil.StringPrefix(result.StandardError, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
-		// send to buffer channel, guaranteed to not block since buffer size is plugin number
-		resChan <- result
+	// truncate the result before it is sent back to buffer channel.
+	result = *pluginOutput
+	pluginConfig := iohandler.DefaultOutputConfig()
+	result.StandardOutput = pluginutil.StringPrefix(result.StandardOutput, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
+	result.StandardError = pluginutil.StringPrefix(result.StandardError, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
 
-		//TODO handle cancelFlag here
-		if pluginHandlerFound && r.Status == contracts.ResultStatusSuccessAndReboot {
-			// do not execute the the next plugin
-			break
-		}
-	}
-	// this will clean the orchestration folder for the successful and failed document executions only when the agent is configured
-	orchestrationDirCleanup(context, len(plugins), pluginOutputs, ioConfig.OrchestrationDirectory)
-	return
+	return result, pluginHandlerFound && r.Status == contracts.ResultStatusSuccessAndReboot
 }
 
 // orchestrationDirCleanup will clean orchestration folder for the successful and failed document executions. Cleaned only when the agent is configured to do so
@@ -344,6 +547,15 @@ var runPlugin = func(
 	res.StartDateTime = time.Now()
 	defer func() { res.EndDateTime = time.Now() }()
 
+	// enforce the step timeout for every plugin by cancelling only this step once it elapses
+	stepCancelFlag := cancelFlag
+	var timer *stepTimer
+	if config.TimeoutSeconds > 0 {
+		timer = startStepTimer(cancelFlag, config.TimeoutSeconds)
+		defer timer.stop()
+		stepCancelFlag = timer.cancelFlag
+	}
+
 	output := iohandler.NewDefaultIOHandler(context, ioConfig)
 	//check if properties is a list. If true, then unroll
 	switch config.Properties.(type) {
@@ -361,7 +573,7 @@ var runPlugin = func(
 				errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
 				output.MarkAsFailed(errorString)
 			} else {
-				executePlugin(plugin, pluginName, stepName, config, cancelFlag, propOutput)
+				executePlugin(plugin, pluginName, stepName, config, stepCancelFlag, propOutput)
 			}
 
 			output.Merge(propOutput)
@@ -373,7 +585,7 @@ var runPlugin = func(
 			errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
 			output.MarkAsFailed(errorString)
 		} else {
-			executePlugin(plugin, pluginName, stepName, config, cancelFlag, output)
+			executePlugin(plugin, pluginName, stepName, config, stepCancelFlag, output)
 		}
 	}
 
@@ -385,6 +597,10 @@ var runPlugin = func(
 
 		res.StepName = stepName
 	}
+	if timer != nil && timer.timedOut() && !output.GetStatus().IsSuccess() {
+		log.Infof("Step %s timed out after %d seconds", config.PluginID, config.TimeoutSeconds)
+		markStepAsTimedOut(output, config.TimeoutSeconds)
+	}
 	res.Code = output.GetExitCode()
 	res.Status = output.GetStatus()
 	res.Output = output.GetOutput()
@@ -516,79 +732,6 @@ func getStepExecutionOperation(
 	}
 }
 
-// Evaluate precondition and return precondition result and unrecognized preconditions (if any)
-func evaluatePreconditions(
-	log log.T,
-	preconditions map[string][]contracts.PreconditionArgument,
-) (bool, []string) {
-
-	var isAllowed = true
-	var unrecognizedPreconditionList []string
-
-	// For current release, we only support "StringEquals" operator and "platformType"
-	// operand, so explicitly checking for those and number of operands must be 2
-	for key, value := range preconditions {
-		switch key {
-		case "StringEquals":
-			if len(value) != 2 {
-				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
-			} else {
-				if strings.Compare(value[0].InitialArgumentValue, value[1].InitialArgumentValue) == 0 {
-					// StringEquals preconditions with identical arguments are not allowed
-					if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 {
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
-					} else {
-						// hide customer's parameters and constants
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't be identical", key))
-					}
-				} else if ssmparameterresolver.TextContainsSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", key))
-				} else if ssmparameterresolver.TextContainsSecureSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSecureSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", key))
-				} else if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 || strings.Compare(value[1].InitialArgumentValue, "platformType") == 0 {
-					// keep original logic for platformType variable
-					// Platform type of OS on the instance
-					instancePlatformType, _ := platform.PlatformType(log)
-					log.Debugf("OS platform type of this instance = %s", instancePlatformType)
-
-					// Variable and value can be in any order, i.e. both "StringEquals": ["platformType", "Windows"]
-					// and "StringEquals": ["Windows", "platformType"] are valid
-					var initialPlatformTypeValue string
-					var resolvedPlatformTypeValue string
-					if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 {
-						initialPlatformTypeValue = value[1].InitialArgumentValue
-						resolvedPlatformTypeValue = value[1].ResolvedArgumentValue
-					} else {
-						initialPlatformTypeValue = value[0].InitialArgumentValue
-						resolvedPlatformTypeValue = value[0].ResolvedArgumentValue
-					}
-
-					if strings.Compare(strings.ToLower(initialPlatformTypeValue), strings.ToLower(resolvedPlatformTypeValue)) != 0 {
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": the second argument for the platformType variable can't contain document parameters", key))
-					} else if strings.Compare(instancePlatformType, strings.ToLower(initialPlatformTypeValue)) != 0 {
-						// if precondition doesn't match for platformType, mark step for skip
-						isAllowed = false
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
-					}
-				} else if strings.Compare(value[0].InitialArgumentValue, value[0].ResolvedArgumentValue) == 0 && strings.Compare(value[1].InitialArgumentValue, value[1].ResolvedArgumentValue) == 0 {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter", key))
-				} else {
-					if strings.Compare(value[0].ResolvedArgumentValue, value[1].ResolvedArgumentValue) != 0 {
-						// if arbitrary StringEquals precondition is not satisfied, mark step for skip
-						isAllowed = false
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
-					}
-				}
-			}
-		default:
-			// mark for unrecognizedPrecondition (which is a form of failure)
-			unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("unrecognized operator: \"%s\"", key))
-		}
-	}
-
-	return isAllowed, unrecognizedPreconditionList
-}
-
 // Returns the Property's ID field from v1.2 documents or the Name field of a Step in v2.x documents.
 // This is required to generate the correct stdout/stderr s3 url
 func getStepName(pluginName string, config contracts.Configuration) (stepName string, err error) {
@@ -641,7 +784,7 @@ func getShouldPluginSkipBasedOnControlFlow(
 	log := context.Log()
 	pluginState := plugins[pluginIndex]
 	finallyProp := getStringPropByName(pluginState.Configuration.Properties, contracts.FinallyStepModifier)
-	if finallyProp == contracts.ModifierValueTrue && pluginIndex == len(plugins)-1 {
+	if isRunAlwaysStep(plugins, pluginIndex) {
 		log.Infof(
 			"Finally step detected for plugin %v",
 			pluginState.Id,
@@ -657,6 +800,10 @@ func getShouldPluginSkipBasedOnControlFlow(
 	}
 	for prvPluginStateIdx := 0; prvPluginStateIdx < pluginIndex; prvPluginStateIdx++ {
 		prevPluginId := plugins[prvPluginStateIdx].Id
+		if _, found := pluginOutputs[prevPluginId]; !found {
+			// step was bypassed by branching
+			continue
+		}
 		prvPluginResultCode := pluginOutputs[prevPluginId].Code
 		onFailureProp := getStringPropByName(plugins[prvPluginStateIdx].Configuration.Properties, contracts.OnFailureModifier)
 		isFailedStep := pluginOutputs[prevPluginId].Status == contracts.ResultStatusFailed
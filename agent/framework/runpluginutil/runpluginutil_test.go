@@ -25,41 +25,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
-	"github.com/aws/amazon-ssm-agent/agent/log"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-const (
-	testPlugin0           = "plugin0"
-	testPlugin1           = "plugin1"
-	testPlugin2           = "plugin2"
-	testUnknownPlugin     = "plugin3"
-	testUnsupportedPlugin = "plugin4"
-)
-
-var origIsSupported func(log log.T, pluginName string) (isKnown bool, isSupported bool, message string)
-
-func setIsSupportedMock() {
-	origIsSupported = isSupportedPlugin
-	isSupportedPlugin = func(log log.T, pluginName string) (isKnown bool, isSupported bool, message string) {
-		switch pluginName {
-		case testUnknownPlugin:
-			return false, true, ""
-		case testUnsupportedPlugin:
-			return true, false, ""
-		default:
-			return true, true, ""
-		}
-	}
-}
-
-func restoreIsSupported() {
-	isSupportedPlugin = origIsSupported
-}
-
 // TestRunPlugins tests that RunPluginsWithRegistry calls all the expected plugins.
 func TestRunPluginsWithNewDocument(t *testing.T) {
 	setIsSupportedMock()
@@ -3737,3 +3709,127 @@ func TestRunPluginWithOnFailureProperty1(t *testing.T) {
 	ctx.AssertCalled(t, "Log")
 	assert.Equal(t, pluginResults[testPlugin1], outputs[testPlugin1])
 }
+
+func TestIsExternalPlugin(t *testing.T) {
+	registry := PluginRegistry{
+		"custom:mount-volume":                 new(PluginFactoryMock),
+		appconfig.PluginNameAwsRunShellScript: new(PluginFactoryMock),
+	}
+
+	assert.True(t, isExternalPlugin(registry, "custom:mount-volume"))
+	// external plugins the plugin factory did not discover are unknown
+	assert.False(t, isExternalPlugin(registry, "custom:missing"))
+	assert.False(t, isExternalPlugin(registry, appconfig.PluginNameAwsRunShellScript))
+}
+
+func TestRunPluginsMasksSecretValuesUntilDocumentCompletes(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	plugins := []contracts.PluginState{newParallelTestPlugin("work", map[string]interface{}{})}
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+
+	var maskedOutput string
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		secretmask.Add("step-secret")
+		maskedOutput = secretmask.Mask("document-secret step-secret")
+		res.Status = contracts.ResultStatusSuccess
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	ioConfig := contracts.IOConfiguration{SecretValues: []string{"document-secret"}}
+	ch := make(chan contracts.PluginResult, len(plugins))
+	RunPlugins(contextmocks.NewMockDefault(), plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, "**** ****", maskedOutput)
+	assert.Empty(t, secretmask.Values())
+}
+
+func TestRunPluginsRefusesUnsignedScriptPluginsWhenSignedScriptsAreRequired(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	newStep := func(name, id string) contracts.PluginState {
+		plugin := newParallelTestPlugin(id, map[string]interface{}{})
+		plugin.Name, plugin.Configuration.PluginName = name, name
+		return plugin
+	}
+	plugins := []contracts.PluginState{
+		newStep(appconfig.PluginNameAwsRunShellScript, "shell"),
+		newStep(appconfig.PluginNameAwsRunPowerShellScript, "powershell"),
+		newStep(appconfig.PluginNameDockerContainer, "docker"),
+		newStep(appconfig.PluginNameAwsPowerShellModule, "psmodule"),
+		newStep(appconfig.PluginNameAwsConfigurePackage, "package"),
+		newStep(appconfig.PluginNameAwsApplications, "applications"),
+		newStep(appconfig.PluginNameAwsConfigureDaemon, "daemon"),
+		newStep("custom:mount-volume", "external"),
+		newStep(appconfig.PluginNameAwsRunShellScript, "precondition"),
+	}
+	plugins[len(plugins)-1].Configuration.Preconditions = map[string][]contracts.PreconditionArgument{
+		"CommandSucceeds": {{InitialArgumentValue: "/usr/bin/true", ResolvedArgumentValue: "/usr/bin/true"}},
+	}
+	var preconditionCommands []string
+	oldRunCommand := runPreconditionCommand
+	runPreconditionCommand = func(executable string, args []string) error {
+		preconditionCommands = append(preconditionCommands, executable)
+		return nil
+	}
+	defer func() { runPreconditionCommand = oldRunCommand }()
+	pluginFactory := new(PluginFactoryMock)
+	pluginRegistry := PluginRegistry{}
+	for _, plugin := range plugins {
+		pluginRegistry[plugin.Name] = pluginFactory
+	}
+
+	var executed []string
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		executed = append(executed, config.PluginID)
+		res.Status = contracts.ResultStatusSuccess
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RequireSignedScripts = true
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefaultWithConfig(appConfig), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	// aws:runShellScript verifies the signature of its commands itself
+	assert.Equal(t, []string{"shell"}, executed)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["shell"].Status)
+	for _, plugin := range plugins[1 : len(plugins)-1] {
+		assert.Equal(t, contracts.ResultStatusFailed, outputs[plugin.Id].Status)
+		assert.Equal(t, signaturePolicyMessage(plugin.Name, plugin.Id), outputs[plugin.Id].Error)
+	}
+	// the command of a precondition cannot be signed and does not run
+	assert.Empty(t, preconditionCommands)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["precondition"].Status)
+	assert.Equal(t, preconditionSignaturePolicyMessage("precondition"), outputs["precondition"].Error)
+
+	// the plugins run when the agent does not require signed scripts
+	executed = nil
+	ch = make(chan contracts.PluginResult, len(plugins))
+	RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	assert.Equal(t, []string{"shell", "powershell", "docker", "psmodule", "package", "applications", "daemon", "external", "precondition"}, executed)
+	assert.Equal(t, []string{"/usr/bin/true"}, preconditionCommands)
+}
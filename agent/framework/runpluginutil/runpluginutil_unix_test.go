@@ -18,6 +18,7 @@
 package runpluginutil
 
 import (
+	"os/exec"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -46,3 +47,16 @@ func TestUnknown(t *testing.T) {
 	assert.False(t, isKnown)
 	assert.True(t, isSupported)
 }
+
+func TestRunPreconditionCommandDoesNotUseShell(t *testing.T) {
+	testPath, err := exec.LookPath("test")
+	if err != nil {
+		t.Skip("test command is not available")
+	}
+	directory := t.TempDir()
+
+	assert.NoError(t, runPreconditionCommand(testPath, []string{"-d", directory}))
+	assert.Error(t, runPreconditionCommand(testPath, []string{"-d", directory + "/missing"}))
+	// a shell would expand the variable to an existing directory
+	assert.Error(t, runPreconditionCommand(testPath, []string{"-d", "$HOME"}))
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// executionPath records the steps of a document that already ran while the steps are walked
+type executionPath struct {
+	visitedSteps map[int]bool
+	// ended is set once the document reached its end, after which only the finally steps that did not run yet remain
+	ended bool
+}
+
+// newExecutionPath returns the path of a document before any step ran
+func newExecutionPath() *executionPath {
+	return &executionPath{visitedSteps: make(map[int]bool)}
+}
+
+// getNextPluginIndex returns the index of the step to run after the step at pluginIndex.
+// Schema 2.2 documents can branch with the nextStep, isEnd, onSuccess: step:<name> and onFailure: step:<name> inputs,
+// otherwise the next step that has not run yet is returned. Once the end of the document is reached, the finally
+// steps that have not run yet are returned. Returns len(plugins) when the document is done.
+func getNextPluginIndex(
+	log log.T,
+	plugins []contracts.PluginState,
+	pluginIndex int,
+	pluginOutputs map[string]*contracts.PluginResult,
+	path *executionPath,
+) int {
+	if path.ended {
+		// the document ended, only the finally steps that still have to run remain
+		return getEndPluginIndex(plugins, path)
+	}
+	visitedSteps := path.visitedSteps
+
+	nextIndex := pluginIndex + 1
+	for nextIndex < len(plugins) && visitedSteps[nextIndex] {
+		nextIndex++
+	}
+	if nextIndex == len(plugins) {
+		nextIndex = getEndPluginIndex(plugins, path)
+	}
+
+	pluginState := plugins[pluginIndex]
+	result, found := pluginOutputs[pluginState.Id]
+	if !found || !pluginState.Configuration.IsPreconditionEnabled {
+		return nextIndex
+	}
+
+	target, isEnd := getBranchTarget(pluginState, result)
+	if target == "" {
+		if isEnd {
+			log.Infof("Step %s is marked with isEnd, ending document", pluginState.Id)
+			return getEndPluginIndex(plugins, path)
+		}
+		return nextIndex
+	}
+	// the step branches, the document did not end
+	path.ended = false
+
+	for index, plugin := range plugins {
+		if plugin.Id != target {
+			continue
+		}
+		if visitedSteps[index] {
+			// cycles are rejected when the document is parsed, this protects documents persisted by older agents
+			log.Errorf("Step %s branches to step %s which already ran, ending document", pluginState.Id, target)
+			return getEndPluginIndex(plugins, path)
+		}
+		log.Infof("Step %s branches to step %s", pluginState.Id, target)
+		return index
+	}
+
+	log.Errorf("Step %s branches to unknown step %s, continuing with the next step", pluginState.Id, target)
+	return nextIndex
+}
+
+// getBranchTarget returns the name of the step to branch to after a step completed with the given result,
+// or an empty name and whether the document should end after the step.
+func getBranchTarget(pluginState contracts.PluginState, result *contracts.PluginResult) (target string, isEnd bool) {
+	// exit codes 168 and 169 take precedence, the remaining steps are skipped by the exit control flow
+	if result.Code == contracts.ExitWithSuccess || result.Code == contracts.ExitWithFailure {
+		return "", false
+	}
+
+	properties := pluginState.Configuration.Properties
+	var branchModifier string
+	switch {
+	case result.Status == contracts.ResultStatusSkipped:
+		return "", false
+	case result.Status.IsSuccess():
+		branchModifier = contracts.OnSuccessModifier
+	default:
+		branchModifier = contracts.OnFailureModifier
+	}
+
+	if branch := getStringPropByName(properties, branchModifier); strings.HasPrefix(branch, contracts.ModifierValueStepPrefix) {
+		return strings.TrimPrefix(branch, contracts.ModifierValueStepPrefix), false
+	}
+	return getStringPropByName(properties, contracts.NextStepModifier),
+		getStringPropByName(properties, contracts.IsEndModifier) == contracts.ModifierValueTrue
+}
+
+// getEndPluginIndex returns the index of the first finally step that still has to run, len(plugins) otherwise.
+// It records in the path that the document ended so no other step runs after the finally steps.
+func getEndPluginIndex(plugins []contracts.PluginState, path *executionPath) int {
+	path.ended = true
+	for index := range plugins {
+		if !path.visitedSteps[index] && isRunAlwaysStep(plugins, index) {
+			return index
+		}
+	}
+	return len(plugins)
+}
+
+// skipStepsNotOnPath marks the steps bypassed by branching as skipped and sends their results
+func skipStepsNotOnPath(
+	log log.T,
+	plugins []contracts.PluginState,
+	visitedSteps map[int]bool,
+	pluginOutputs map[string]*contracts.PluginResult,
+	resChan chan contracts.PluginResult,
+) {
+	for pluginIndex, pluginState := range plugins {
+		if visitedSteps[pluginIndex] {
+			continue
+		}
+		message := fmt.Sprintf("Step execution skipped due to branching. Step name: %s", pluginState.Id)
+		log.Info(message)
+
+		pluginOutput := pluginState.Result
+		pluginOutput.PluginID = pluginState.Id
+		pluginOutput.PluginName = pluginState.Name
+		pluginOutput.Status = contracts.ResultStatusSkipped
+		pluginOutput.Code = 0
+		pluginOutput.Output = message
+		pluginOutput.StartDateTime = time.Now()
+		pluginOutput.EndDateTime = pluginOutput.StartDateTime
+		pluginOutputs[pluginState.Id] = &pluginOutput
+		resChan <- pluginOutput
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type branchingTestStep struct {
+	name       string
+	properties map[string]interface{}
+	status     contracts.ResultStatus
+	code       int
+	runAlways  bool
+}
+
+// runBranchingDocument runs the given schema 2.2 steps with a mocked runPlugin and returns
+// the names of the executed steps in order together with the step outputs.
+func runBranchingDocument(t *testing.T, steps []branchingTestStep) ([]string, map[string]*contracts.PluginResult) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	stepResults := make(map[string]branchingTestStep)
+	plugins := make([]contracts.PluginState, len(steps))
+	pluginRegistry := PluginRegistry{}
+	for index, step := range steps {
+		stepResults[step.name] = step
+		plugins[index] = contracts.PluginState{
+			Name: testPlugin1,
+			Id:   step.name,
+			Configuration: contracts.Configuration{
+				PluginID:              step.name,
+				PluginName:            testPlugin1,
+				Properties:            step.properties,
+				IsPreconditionEnabled: true,
+				RunAlways:             step.runAlways,
+			},
+		}
+	}
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry[testPlugin1] = pluginFactory
+
+	var executed []string
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		executed = append(executed, config.PluginID)
+		res.Status = stepResults[config.PluginID].status
+		res.Code = stepResults[config.PluginID].code
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	ch := make(chan contracts.PluginResult, len(steps))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	reported := 0
+	for range ch {
+		reported++
+	}
+	assert.Equal(t, len(steps), reported, "every step must be reported exactly once")
+	return executed, outputs
+}
+
+func TestRunPluginsBranchesToRollbackOnFailure(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "deploy", properties: map[string]interface{}{"onFailure": "step:rollback", "nextStep": "verify"}, status: contracts.ResultStatusFailed, code: 1},
+		{name: "rollback", properties: map[string]interface{}{"isEnd": true}, status: contracts.ResultStatusSuccess},
+		{name: "verify", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"deploy", "rollback"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["verify"].Status)
+	assert.Equal(t, "Step execution skipped due to branching. Step name: verify", outputs["verify"].Output)
+}
+
+func TestRunPluginsFollowsNextStepOnSuccess(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "deploy", properties: map[string]interface{}{"onFailure": "step:rollback", "nextStep": "verify"}, status: contracts.ResultStatusSuccess},
+		{name: "rollback", properties: map[string]interface{}{"isEnd": true}, status: contracts.ResultStatusSuccess},
+		{name: "verify", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"deploy", "verify"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["rollback"].Status)
+}
+
+func TestRunPluginsBranchesBackward(t *testing.T) {
+	executed, _ := runBranchingDocument(t, []branchingTestStep{
+		{name: "a", properties: map[string]interface{}{"nextStep": "c"}, status: contracts.ResultStatusSuccess},
+		{name: "b", properties: map[string]interface{}{"isEnd": "true"}, status: contracts.ResultStatusSuccess},
+		{name: "c", properties: map[string]interface{}{"onSuccess": "step:b"}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"a", "c", "b"}, executed)
+}
+
+func TestRunPluginsIsEndStillRunsFinallyStep(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "a", properties: map[string]interface{}{"isEnd": true}, status: contracts.ResultStatusSuccess},
+		{name: "b", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "cleanup", properties: map[string]interface{}{"finallyStep": true}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"a", "cleanup"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["b"].Status)
+}
+
+func TestRunPluginsExitCodeTakesPrecedenceOverBranching(t *testing.T) {
+	executed, outputs := runBranchingDocument(t, []branchingTestStep{
+		{name: "a", properties: map[string]interface{}{"onFailure": "step:c"}, status: contracts.ResultStatusFailed, code: contracts.ExitWithFailure},
+		{name: "b", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+		{name: "c", properties: map[string]interface{}{}, status: contracts.ResultStatusSuccess},
+	})
+
+	assert.Equal(t, []string{"a"}, executed)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["b"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["c"].Status)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutputs"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// runPluginAttempt runs the plugin once and selects the outputs declared by the step from the standard output
+// of the attempt. A successful attempt whose outputs cannot be selected is marked as failed.
+// The secrets of the outputs of the attempt are masked.
+func runPluginAttempt(
+	context context.T,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+
+	res = runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	// the outputs that do not go through the masking writers are masked once the attempt completes
+	defer iohandler.MaskResult(context, &res)
+	if !config.IsPreconditionEnabled || len(config.Outputs) == 0 || !res.Status.IsSuccess() {
+		return
+	}
+
+	stepOutputs, err := stepoutputs.Extract(config.Outputs, res.StandardOutput)
+	if err != nil {
+		context.Log().Errorf("Failed to select the outputs of step %s: %v", config.PluginID, err)
+		res.Status = contracts.ResultStatusFailed
+		res.Code = 1
+		res.StandardError += fmt.Sprintf("\nFailed to select step outputs: %v", err)
+		return
+	}
+	res.StepOutputs = stepOutputs
+	return
+}
+
+// getStepOutputs returns the outputs of the steps that already ran, indexed by step name and output name
+func getStepOutputs(pluginOutputs map[string]*contracts.PluginResult) map[string]map[string]string {
+	stepOutputs := make(map[string]map[string]string)
+	for pluginID, pluginOutput := range pluginOutputs {
+		if len(pluginOutput.StepOutputs) > 0 {
+			stepOutputs[pluginID] = pluginOutput.StepOutputs
+		}
+	}
+	return stepOutputs
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// runStepOutputsDocument runs the steps with a mocked runPlugin that prints the given standard output
+// and returns the properties each executed step was started with, together with the step outputs.
+func runStepOutputsDocument(plugins []contracts.PluginState, stdout map[string]string) (map[string]interface{}, map[string]*contracts.PluginResult) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(new(PluginMock), nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+
+	startedWith := make(map[string]interface{})
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		startedWith[config.PluginID] = config.Properties
+		res.Status = contracts.ResultStatusSuccess
+		res.StandardOutput = stdout[config.PluginID]
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	return startedWith, outputs
+}
+
+func TestRunPluginsPassesStepOutputsToLaterSteps(t *testing.T) {
+	build := newParallelTestPlugin("build", map[string]interface{}{"runCommand": []interface{}{"make"}})
+	build.Configuration.Outputs = []contracts.StepOutput{{Name: "version", Type: contracts.StepOutputTypeKeyValue}}
+	deploy := newParallelTestPlugin("deploy", map[string]interface{}{"runCommand": []interface{}{"deploy {{ steps.build.outputs.version }}"}})
+
+	startedWith, outputs := runStepOutputsDocument([]contracts.PluginState{build, deploy}, map[string]string{"build": "compiling\nversion=1.2.3\n"})
+
+	assert.Equal(t, map[string]string{"version": "1.2.3"}, outputs["build"].StepOutputs)
+	assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{"deploy 1.2.3"}}, startedWith["deploy"])
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["deploy"].Status)
+}
+
+func TestRunPluginsFailsStepWhenOutputCannotBeSelected(t *testing.T) {
+	build := newParallelTestPlugin("build", map[string]interface{}{})
+	build.Configuration.Outputs = []contracts.StepOutput{{Name: "version", Type: contracts.StepOutputTypeKeyValue}}
+	deploy := newParallelTestPlugin("deploy", map[string]interface{}{"runCommand": []interface{}{"deploy {{ steps.build.outputs.version }}"}})
+
+	startedWith, outputs := runStepOutputsDocument([]contracts.PluginState{build, deploy}, map[string]string{"build": "compiling\n"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["build"].Status)
+	assert.Contains(t, outputs["build"].StandardError, "Failed to select step outputs: output version: no line version=<value> found in standard output")
+	assert.NotContains(t, startedWith, "deploy")
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["deploy"].Status)
+	assert.Equal(t, "Failed to resolve step outputs for step deploy: output version of step build is not available", outputs["deploy"].Error)
+}
+
+func TestRunPluginAttemptMasksOutputs(t *testing.T) {
+	defer secretmask.Reset()
+	secretmask.Add("s3cr3t-value")
+	oldRunPlugin := runPlugin
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		res.Status = contracts.ResultStatusSuccess
+		res.Output = map[string]interface{}{"token": "s3cr3t-value"}
+		res.StandardOutput = "token=s3cr3t-value\n"
+		return
+	}
+	defer func() { runPlugin = oldRunPlugin }()
+	config := contracts.Configuration{
+		PluginID:              "build",
+		IsPreconditionEnabled: true,
+		Outputs:               []contracts.StepOutput{{Name: "token", Type: contracts.StepOutputTypeKeyValue}},
+	}
+
+	res := runPluginAttempt(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, map[string]interface{}{"token": "****"}, res.Output)
+	assert.Equal(t, map[string]string{"token": "****"}, res.StepOutputs)
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	// attemptTitleFormat represents the header that is printed before the output of each attempt of a retried step
+	attemptTitleFormat = "\n----------Attempt %d of %d----------\n"
+	// stepRetryMultiplier represents the factor by which the wait grows after each failed attempt
+	stepRetryMultiplier = 2.0
+)
+
+// waitForStepRetry blocks for the given delay and returns false if the document was cancelled in the meantime.
+// Assign to a variable to allow unittest to override
+var waitForStepRetry = func(cancelFlag task.CancelFlag, delay time.Duration) bool {
+	if cancelFlag == nil {
+		time.Sleep(delay)
+		return true
+	}
+
+	flagSet, release := task.WaitChannel(cancelFlag)
+	defer release()
+
+	select {
+	case <-time.After(delay):
+	case <-flagSet:
+	}
+	return !cancelFlag.Canceled()
+}
+
+// runPluginWithRetry executes the plugin once, or up to config.MaxAttempts times when the step declares maxAttempts.
+// Failed attempts are retried with exponential backoff and jitter as configured in appconfig, the output of
+// every attempt and a summary of the failed ones are kept in the standard output and error of the step result.
+func runPluginWithRetry(
+	context context.T,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 1 {
+		return runPluginAttempt(context, factory, pluginName, config, cancelFlag, ioConfig)
+	}
+
+	log := context.Log()
+	retryBackoff := newStepRetryBackoff(context.AppConfig().Ssm)
+
+	var stdout, stderr, summary strings.Builder
+	startDateTime := time.Now()
+	attempt := 1
+	for ; ; attempt++ {
+		res = runPluginAttempt(context, factory, pluginName, config, cancelFlag, ioConfig)
+
+		attemptTitle := fmt.Sprintf(attemptTitleFormat, attempt, maxAttempts)
+		stdout.WriteString(attemptTitle + res.StandardOutput)
+		if res.StandardError != "" {
+			stderr.WriteString(attemptTitle + res.StandardError)
+		}
+
+		if !isRetryableResult(res) || attempt >= maxAttempts {
+			break
+		}
+		if cancelFlag != nil && cancelFlag.Canceled() {
+			log.Infof("Step %s was cancelled, not retrying", config.PluginID)
+			break
+		}
+
+		delay := retryBackoff.NextBackOff()
+		summary.WriteString(fmt.Sprintf("Attempt %d of %d finished with status %s and exit code %d. Retrying in %v.\n",
+			attempt, maxAttempts, res.Status, res.Code, delay))
+		log.Infof("Attempt %d of %d for step %s finished with status %s, retrying in %v",
+			attempt, maxAttempts, config.PluginID, res.Status, delay)
+
+		if !waitForStepRetry(cancelFlag, delay) {
+			log.Infof("Step %s was cancelled while waiting to retry", config.PluginID)
+			break
+		}
+	}
+
+	// a step that succeeded on its first attempt keeps its output untouched
+	if attempt == 1 {
+		return
+	}
+	res.StartDateTime = startDateTime
+	// the history of the attempts goes to the standard output, the output of the step may be structured
+	res.StandardOutput = summary.String() + stdout.String()
+	res.StandardError = stderr.String()
+	return
+}
+
+// isRetryableResult returns true if the attempt failed in a way that another attempt may fix.
+// Exit codes 168 and 169 are explicit requests to stop the document and are never retried.
+func isRetryableResult(res contracts.PluginResult) bool {
+	if res.Code == contracts.ExitWithSuccess || res.Code == contracts.ExitWithFailure {
+		return false
+	}
+	return res.Status == contracts.ResultStatusFailed || res.Status == contracts.ResultStatusTimedOut
+}
+
+// newStepRetryBackoff returns the exponential backoff used between the attempts of a step
+func newStepRetryBackoff(ssmConfig appconfig.SsmCfg) *backoff.ExponentialBackOff {
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.InitialInterval = time.Duration(ssmConfig.StepRetryInitialIntervalMillis) * time.Millisecond
+	retryBackoff.MaxInterval = time.Duration(ssmConfig.StepRetryMaxIntervalMillis) * time.Millisecond
+	retryBackoff.Multiplier = stepRetryMultiplier
+	retryBackoff.RandomizationFactor = float64(ssmConfig.StepRetryJitterPercent) / 100
+	// the number of attempts is bounded by maxAttempts rather than by the total elapsed time
+	retryBackoff.MaxElapsedTime = 0
+	retryBackoff.Reset()
+	return retryBackoff
+}
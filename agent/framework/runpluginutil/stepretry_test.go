@@ -0,0 +1,179 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRunPluginResults replaces runPlugin with a function returning the given results in order
+// and returns a pointer to the number of calls made.
+func mockRunPluginResults(t *testing.T, results []contracts.PluginResult) *int {
+	calls := 0
+	oldRunPlugin := runPlugin
+	oldWaitForStepRetry := waitForStepRetry
+	runPlugin = func(context context.T,
+		factory PluginFactory,
+		pluginName string,
+		config contracts.Configuration,
+		cancelFlag task.CancelFlag,
+		ioConfig contracts.IOConfiguration,
+	) (res contracts.PluginResult) {
+		res = results[calls]
+		calls++
+		return
+	}
+	waitForStepRetry = func(cancelFlag task.CancelFlag, delay time.Duration) bool {
+		return cancelFlag == nil || !cancelFlag.Canceled()
+	}
+	t.Cleanup(func() {
+		runPlugin = oldRunPlugin
+		waitForStepRetry = oldWaitForStepRetry
+	})
+	return &calls
+}
+
+func failedAttempt(attempt int) contracts.PluginResult {
+	return contracts.PluginResult{
+		Status:         contracts.ResultStatusFailed,
+		Code:           1,
+		Output:         fmt.Sprintf("failure %d", attempt),
+		StandardOutput: fmt.Sprintf("out %d", attempt),
+		StandardError:  fmt.Sprintf("err %d", attempt),
+	}
+}
+
+func TestRunPluginWithRetryWithoutMaxAttempts(t *testing.T) {
+	calls := mockRunPluginResults(t, []contracts.PluginResult{failedAttempt(1), failedAttempt(2)})
+	config := contracts.Configuration{PluginID: "step1"}
+
+	res := runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, failedAttempt(1), res)
+}
+
+func TestRunPluginWithRetrySucceedsAfterFailedAttempts(t *testing.T) {
+	success := contracts.PluginResult{Status: contracts.ResultStatusSuccess, Output: "done", StandardOutput: "out 3"}
+	calls := mockRunPluginResults(t, []contracts.PluginResult{failedAttempt(1), failedAttempt(2), success})
+	config := contracts.Configuration{PluginID: "step1", MaxAttempts: 5}
+
+	res := runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, 3, *calls)
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	assert.Equal(t, 0, res.Code)
+	assert.Regexp(t, "^Attempt 1 of 5 finished with status Failed and exit code 1. Retrying in .*\n"+
+		"Attempt 2 of 5 finished with status Failed and exit code 1. Retrying in .*\n$",
+		strings.TrimSuffix(res.StandardOutput, fmt.Sprintf(attemptTitleFormat, 1, 5)+"out 1"+
+			fmt.Sprintf(attemptTitleFormat, 2, 5)+"out 2"+
+			fmt.Sprintf(attemptTitleFormat, 3, 5)+"out 3"))
+	assert.True(t, strings.HasSuffix(res.StandardOutput, fmt.Sprintf(attemptTitleFormat, 3, 5)+"out 3"))
+	assert.Equal(t, fmt.Sprintf(attemptTitleFormat, 1, 5)+"err 1"+
+		fmt.Sprintf(attemptTitleFormat, 2, 5)+"err 2", res.StandardError)
+	assert.Equal(t, "done", res.Output)
+}
+
+func TestRunPluginWithRetryKeepsHistoryWithStructuredOutput(t *testing.T) {
+	failed := failedAttempt(1)
+	failed.Output = map[string]interface{}{"healthy": false}
+	success := contracts.PluginResult{Status: contracts.ResultStatusSuccess, Output: map[string]interface{}{"healthy": true}, StandardOutput: "out 2"}
+	mockRunPluginResults(t, []contracts.PluginResult{failed, success})
+	config := contracts.Configuration{PluginID: "step1", MaxAttempts: 2}
+
+	res := runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, map[string]interface{}{"healthy": true}, res.Output)
+	assert.Contains(t, res.StandardOutput, "Attempt 1 of 2 finished with status Failed and exit code 1.")
+	assert.Contains(t, res.StandardOutput, fmt.Sprintf(attemptTitleFormat, 2, 2)+"out 2")
+}
+
+func TestRunPluginWithRetryStopsAtMaxAttempts(t *testing.T) {
+	calls := mockRunPluginResults(t, []contracts.PluginResult{failedAttempt(1), failedAttempt(2), failedAttempt(3)})
+	config := contracts.Configuration{PluginID: "step1", MaxAttempts: 2}
+
+	res := runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	assert.Contains(t, res.StandardOutput, "out 1")
+	assert.Contains(t, res.StandardOutput, "out 2")
+}
+
+func TestRunPluginWithRetryDoesNotRetryExitCode169(t *testing.T) {
+	exitWithFailure := failedAttempt(1)
+	exitWithFailure.Code = contracts.ExitWithFailure
+	calls := mockRunPluginResults(t, []contracts.PluginResult{exitWithFailure, failedAttempt(2)})
+	config := contracts.Configuration{PluginID: "step1", MaxAttempts: 3}
+
+	res := runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, task.NewChanneledCancelFlag(), contracts.IOConfiguration{})
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, exitWithFailure, res)
+}
+
+func TestRunPluginWithRetryStopsWhenCancelled(t *testing.T) {
+	calls := mockRunPluginResults(t, []contracts.PluginResult{failedAttempt(1), failedAttempt(2)})
+	config := contracts.Configuration{PluginID: "step1", MaxAttempts: 3}
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+
+	runPluginWithRetry(contextmocks.NewMockDefault(), nil, testPlugin1, config, cancelFlag, contracts.IOConfiguration{})
+
+	assert.Equal(t, 1, *calls)
+}
+
+func TestNewStepRetryBackoff(t *testing.T) {
+	ssmConfig := appconfig.SsmCfg{
+		StepRetryInitialIntervalMillis: 1000,
+		StepRetryMaxIntervalMillis:     3000,
+		StepRetryJitterPercent:         0,
+	}
+
+	retryBackoff := newStepRetryBackoff(ssmConfig)
+
+	assert.Equal(t, time.Second, retryBackoff.NextBackOff())
+	assert.Equal(t, 2*time.Second, retryBackoff.NextBackOff())
+	assert.Equal(t, 3*time.Second, retryBackoff.NextBackOff())
+	assert.Equal(t, 3*time.Second, retryBackoff.NextBackOff())
+}
+
+func TestWaitForStepRetryReturnsFalseOnCancel(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	go cancelFlag.Set(task.Canceled)
+
+	assert.False(t, waitForStepRetry(cancelFlag, time.Minute))
+}
+
+func TestWaitForStepRetryDoesNotLeaveRoutinesBehind(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	routines := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, waitForStepRetry(cancelFlag, time.Millisecond))
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), routines)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// stepTimer enforces the step level timeoutSeconds of a document step.
+// It owns a cancel flag that follows the document cancel flag and is additionally
+// cancelled once the timeout elapses, so only the timed out step is stopped.
+type stepTimer struct {
+	cancelFlag *task.ChanneledCancelFlag
+	timer      *time.Timer
+	expired    int32
+	done       chan struct{}
+}
+
+// startStepTimer starts the timer for a step with the given timeout
+func startStepTimer(docCancelFlag task.CancelFlag, timeoutSeconds int) *stepTimer {
+	s := &stepTimer{cancelFlag: task.NewChanneledCancelFlag(), done: make(chan struct{})}
+	s.timer = time.AfterFunc(time.Duration(timeoutSeconds)*time.Second, func() {
+		atomic.StoreInt32(&s.expired, 1)
+		s.cancelFlag.Set(task.Canceled)
+	})
+
+	if docCancelFlag != nil {
+		docFlagSet, release := task.WaitChannel(docCancelFlag)
+		go func() {
+			defer release()
+			// forward a cancel or shutdown of the document to the step until the step is done
+			select {
+			case <-docFlagSet:
+				if state := docCancelFlag.State(); state == task.Canceled || state == task.ShutDown {
+					s.cancelFlag.Set(state)
+				}
+			case <-s.done:
+			}
+		}()
+	}
+	return s
+}
+
+// stop releases the timer once the step is done
+func (s *stepTimer) stop() {
+	close(s.done)
+	if s.timer.Stop() && !s.cancelFlag.Canceled() && !s.cancelFlag.ShutDown() {
+		s.cancelFlag.Set(task.Completed)
+	}
+}
+
+// timedOut returns true if the step timeout elapsed
+func (s *stepTimer) timedOut() bool {
+	return atomic.LoadInt32(&s.expired) == 1
+}
+
+// markStepAsTimedOut overrides the status reported by a plugin that was stopped by the step timeout
+func markStepAsTimedOut(output *iohandler.DefaultIOHandler, timeoutSeconds int) {
+	output.SetStatus(contracts.ResultStatusTimedOut)
+	output.SetExitCode(appconfig.CommandStoppedPreemptivelyExitCode)
+
+	message := fmt.Sprintf("Step timed out after %d seconds.", timeoutSeconds)
+	if stderr := output.GetStderr(); stderr != "" {
+		message = stderr + "\n" + message
+	}
+	output.SetStderr(message)
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runpluginutil run plugin utility functions without referencing the actually plugin impl packages
+package runpluginutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// blockingPlugin ignores its work and waits for the cancel flag, like a plugin stuck on a download
+type blockingPlugin struct {
+	maxWait time.Duration
+}
+
+func (p *blockingPlugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	select {
+	case <-time.After(p.maxWait):
+		output.MarkAsSucceeded()
+	case <-waitChannel(cancelFlag):
+		output.MarkAsCancelled()
+	}
+}
+
+func waitChannel(cancelFlag task.CancelFlag) <-chan task.State {
+	ch := make(chan task.State, 1)
+	go func() { ch <- cancelFlag.Wait() }()
+	return ch
+}
+
+func newBlockingPluginFactory(maxWait time.Duration) PluginFactory {
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(&blockingPlugin{maxWait: maxWait}, nil)
+	return pluginFactory
+}
+
+func TestRunPluginMarksStepTimedOut(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	config := contracts.Configuration{PluginID: "download", PluginName: testPlugin1, TimeoutSeconds: 1}
+
+	res := runPlugin(contextmocks.NewMockDefault(), newBlockingPluginFactory(time.Minute), testPlugin1, config, docCancelFlag, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()})
+
+	assert.Equal(t, contracts.ResultStatusTimedOut, res.Status)
+	assert.Equal(t, appconfig.CommandStoppedPreemptivelyExitCode, res.Code)
+	assert.Contains(t, res.StandardError, "Step timed out after 1 seconds.")
+	assert.False(t, docCancelFlag.Canceled(), "the step timeout must not cancel the document")
+}
+
+func TestRunPluginFinishesWithinStepTimeout(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	config := contracts.Configuration{PluginID: "download", PluginName: testPlugin1, TimeoutSeconds: 60}
+
+	res := runPlugin(contextmocks.NewMockDefault(), newBlockingPluginFactory(10*time.Millisecond), testPlugin1, config, docCancelFlag, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	assert.Equal(t, 0, res.Code)
+}
+
+func TestRunPluginForwardsDocumentCancelWithStepTimeout(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	config := contracts.Configuration{PluginID: "download", PluginName: testPlugin1, TimeoutSeconds: 60}
+	time.AfterFunc(10*time.Millisecond, func() { docCancelFlag.Set(task.Canceled) })
+
+	res := runPlugin(contextmocks.NewMockDefault(), newBlockingPluginFactory(time.Minute), testPlugin1, config, docCancelFlag, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()})
+
+	assert.Equal(t, contracts.ResultStatusCancelled, res.Status)
+}
+
+func TestStepTimerStopReleasesDocumentCancelFlag(t *testing.T) {
+	docCancelFlag := task.NewChanneledCancelFlag()
+	timer := startStepTimer(docCancelFlag, 60)
+	routines := runtime.NumGoroutine()
+	timer.stop()
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() >= routines && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Less(t, runtime.NumGoroutine(), routines)
+	docCancelFlag.Set(task.Canceled)
+	assert.False(t, timer.cancelFlag.Canceled())
+}
@@ -230,6 +230,7 @@ func ParseSendCommandMessage(context context.T, msg model.InstanceMessage, messa
 		Description:   parsedMessage.DocumentContent.Description,
 		RuntimeConfig: parsedMessage.DocumentContent.RuntimeConfig,
 		MainSteps:     parsedMessage.DocumentContent.MainSteps,
+		FinallySteps:  parsedMessage.DocumentContent.FinallySteps,
 		Parameters:    parsedMessage.DocumentContent.Parameters}
 
 	//Data format persisted in Current Folder is defined by the struct - CommandState
@@ -18,6 +18,7 @@ import (
 	"os"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/mock"
 )
@@ -62,6 +63,24 @@ func (m *MockCommandExecuter) NewExecute(
 	return args.Get(0).(int), args.Error(1)
 }
 
+// NewExecuteWithOptions is a mocked method that just returns what mock tells it to.
+func (m *MockCommandExecuter) NewExecuteWithOptions(
+	context context.T,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	cancelFlag task.CancelFlag,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	envVars map[string]string,
+	options executers.ExecuteOptions,
+) (exitCode int, usage *executers.ResourceUsage, err error) {
+	args := m.Called(context, workingDir, stdoutWriter, stderrWriter, cancelFlag, executionTimeout, commandName, commandArguments, envVars, options)
+	usage, _ = args.Get(1).(*executers.ResourceUsage)
+	return args.Get(0).(int), usage, args.Error(2)
+}
+
 // StartExe is a mocked method that just returns what mock tells it to.
 func (m *MockCommandExecuter) StartExe(
 	context context.T,
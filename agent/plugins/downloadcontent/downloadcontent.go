@@ -87,6 +87,8 @@ type DownloadContentPlugin struct {
 	SourceType      string `json:"sourceType"`
 	SourceInfo      string `json:"sourceInfo"`
 	DestinationPath string `json:"destinationPath"`
+	// Signature is the detached minisign or ed25519 signature of the downloaded file, verified with the trust store of the agent
+	Signature string `json:"signature"`
 	// TODO: 08/25/2017 meloniam@ Change the type of SourceInfo and documentParameters to map[string]interface{}
 	// TODO: https://amazon.awsapps.com/workdocs/index.html#/document/7d56a42ea5b040a7c33548d77dc98040f0fb380bbbfb2fd580c861225e2ee1c7
 }
@@ -142,6 +144,11 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 	//Run aws:downloadContent plugin
 	log.Debug("Inside run downloadcontent function")
 
+	if input.Signature == "" && p.context.AppConfig().Ssm.RequireSignedContent {
+		output.MarkAsFailed(errors.New("the agent only downloads signed content and the step has no signature"))
+		return
+	}
+
 	// remoteResourceCreator makes a call to a function that creates a new remote resource based on the source type
 	log.Debug("Creating resource of type - ", input.SourceType)
 	remoteResource, err := p.remoteResourceCreator(p.context, input.SourceType, input.SourceInfo)
@@ -172,7 +179,12 @@ func (p *Plugin) runCopyContent(log log.T, input *DownloadContentPlugin, config
 	var result *remoteresource.DownloadResult
 	log.Debug("Downloading resource")
 
-	if err, result = remoteResource.DownloadRemoteResource(p.filesys, destinationPath); err != nil {
+	if input.Signature != "" {
+		if result, err = p.downloadSignedContent(log, remoteResource, destinationPath, input.Signature); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	} else if err, result = remoteResource.DownloadRemoteResource(p.filesys, destinationPath); err != nil {
 		output.MarkAsFailed(err)
 		return
 	}
@@ -0,0 +1,100 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package downloadcontent implements the aws:downloadContent plugin
+package downloadcontent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+	"github.com/aws/amazon-ssm-agent/agent/signature"
+)
+
+// stagingDirPrefix is the prefix of the directories that hold signed content until its signature is verified
+const stagingDirPrefix = ".ssm-download-"
+
+// verifyContentSignature checks the signature of the downloaded file against the trust store of the agent and
+// returns the name of the key that signed it. Only downloads of a single file can be verified.
+func verifyContentSignature(result *remoteresource.DownloadResult, contentSignature string) (string, error) {
+	if result == nil || len(result.Files) != 1 {
+		fileCount := 0
+		if result != nil {
+			fileCount = len(result.Files)
+		}
+		return "", fmt.Errorf("signature verification requires a single downloaded file, %d files were downloaded", fileCount)
+	}
+
+	trustStore, err := signature.LoadAgentTrustStore()
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the signature of the content: %v", err)
+	}
+	content, err := os.ReadFile(result.Files[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to read the downloaded file: %v", err)
+	}
+	keyName, err := trustStore.Verify(content, contentSignature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature of the downloaded file %v: %v", result.Files[0], err)
+	}
+	return keyName, nil
+}
+
+// downloadSignedContent downloads the content into a private staging directory, verifies its signature there and only
+// then moves it to the destination, so that content with an invalid signature never replaces an existing file.
+func (p *Plugin) downloadSignedContent(log log.T, remoteResource remoteresource.RemoteResource, destinationPath string, contentSignature string) (*remoteresource.DownloadResult, error) {
+	// The content is saved in the destination directory, or in the directory of the destination file, as the resources do
+	isDirectoryDestination := fileutil.IsDirectory(destinationPath) || os.IsPathSeparator(destinationPath[len(destinationPath)-1])
+	destinationDir := filepath.Dir(destinationPath)
+	if isDirectoryDestination {
+		destinationDir = destinationPath
+	}
+
+	// Staging next to the destination keeps the final rename on the same file system
+	if err := fileutil.MakeDirs(destinationDir); err != nil {
+		return nil, err
+	}
+	stagingDir, err := os.MkdirTemp(destinationDir, stagingDirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the staging directory for the content: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			log.Warnf("Failed to delete the staging directory %v: %v", stagingDir, err)
+		}
+	}()
+
+	err, result := remoteResource.DownloadRemoteResource(p.filesys, stagingDir+string(os.PathSeparator))
+	if err != nil {
+		return nil, err
+	}
+	keyName, err := verifyContentSignature(result, contentSignature)
+	if err != nil {
+		log.Errorf("Discarding the downloaded content: %v", err)
+		return nil, err
+	}
+	log.Infof("The downloaded content is signed by trusted key %v", keyName)
+
+	contentPath := destinationPath
+	if isDirectoryDestination {
+		contentPath = filepath.Join(destinationPath, filepath.Base(result.Files[0]))
+	}
+	if err := os.Rename(result.Files[0], contentPath); err != nil {
+		return nil, fmt.Errorf("failed to move the verified content to %v: %v", contentPath, err)
+	}
+	return &remoteresource.DownloadResult{Files: []string{contentPath}}, nil
+}
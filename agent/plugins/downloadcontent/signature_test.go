@@ -0,0 +1,157 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package downloadcontent implements the aws:downloadContent plugin
+package downloadcontent
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource"
+	resourcemock "github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/remoteresource/mock"
+	"github.com/aws/amazon-ssm-agent/agent/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerifyContentSignature(t *testing.T) {
+	privateKey := signature.StubTrustStore(t)
+	content := []byte("#!/bin/sh\necho installing\n")
+	filePath := filepath.Join(t.TempDir(), "install.sh")
+	assert.NoError(t, os.WriteFile(filePath, content, 0600))
+	result := &remoteresource.DownloadResult{Files: []string{filePath}}
+
+	keyName, err := verifyContentSignature(result, base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, content)))
+	assert.NoError(t, err)
+	assert.Equal(t, "release", keyName)
+
+	_, err = verifyContentSignature(result, base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte("other content"))))
+	assert.EqualError(t, err, "invalid signature of the downloaded file "+filePath+": the signature does not match any key of the trust store")
+
+	_, err = verifyContentSignature(&remoteresource.DownloadResult{Files: []string{filePath, filePath + ".bak"}}, "signature")
+	assert.EqualError(t, err, "signature verification requires a single downloaded file, 2 files were downloaded")
+}
+
+// stubSignedDownload returns a remote resource that saves content as install.sh in the directory it is asked to download to
+func stubSignedDownload(t *testing.T, content string) *resourcemock.RemoteResourceMock {
+	result := &remoteresource.DownloadResult{}
+	resourceMock := new(resourcemock.RemoteResourceMock)
+	resourceMock.On("ValidateLocationInfo").Return(true, nil)
+	resourceMock.On("DownloadRemoteResource", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		filePath := filepath.Join(args.String(1), "install.sh")
+		assert.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+		result.Files = []string{filePath}
+	}).Return(nil, result)
+	return resourceMock
+}
+
+func TestRunCopyContent_VerifiesSignedContentBeforeMovingIt(t *testing.T) {
+	privateKey := signature.StubTrustStore(t)
+	signedContent := "#!/bin/sh\necho installing\n"
+	contentSignature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(signedContent)))
+
+	testCases := []struct {
+		name            string
+		content         string
+		destinationPath func(dir string) string
+		expectedContent string
+	}{
+		{
+			name:            "valid signature with a directory destination",
+			content:         signedContent,
+			destinationPath: func(dir string) string { return dir },
+			expectedContent: signedContent,
+		},
+		{
+			name:            "valid signature with a file destination",
+			content:         signedContent,
+			destinationPath: func(dir string) string { return filepath.Join(dir, "install.sh") },
+			expectedContent: signedContent,
+		},
+		{
+			name:            "invalid signature with a directory destination",
+			content:         "#!/bin/sh\necho tampered\n",
+			destinationPath: func(dir string) string { return dir },
+			expectedContent: "#!/bin/sh\necho existing\n",
+		},
+		{
+			name:            "invalid signature with a file destination",
+			content:         "#!/bin/sh\necho tampered\n",
+			destinationPath: func(dir string) string { return filepath.Join(dir, "install.sh") },
+			expectedContent: "#!/bin/sh\necho existing\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filePath := filepath.Join(dir, "install.sh")
+			assert.NoError(t, os.WriteFile(filePath, []byte("#!/bin/sh\necho existing\n"), 0600))
+
+			resourceMock := stubSignedDownload(t, tc.content)
+			p := Plugin{
+				context:               contextmocks.NewMockDefault(),
+				remoteResourceCreator: func(_ context.T, _ string, _ string) (remoteresource.RemoteResource, error) { return resourceMock, nil },
+			}
+			mockIOHandler := new(iohandlermocks.MockIOHandler)
+			if tc.content == signedContent {
+				mockIOHandler.On("AppendInfof", mock.Anything, mock.Anything).Return()
+				mockIOHandler.On("MarkAsSucceeded").Return()
+			} else {
+				mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+			}
+
+			input := DownloadContentPlugin{
+				SourceType:      S3,
+				DestinationPath: tc.destinationPath(dir),
+				Signature:       contentSignature,
+			}
+			p.runCopyContent(logger, &input, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), mockIOHandler)
+
+			mockIOHandler.AssertExpectations(t)
+			content, err := os.ReadFile(filePath)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedContent, string(content))
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1, "the staging directory must be removed")
+		})
+	}
+}
+
+func TestRunCopyContent_RequiresSignature(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RequireSignedContent = true
+	p := Plugin{
+		context: contextmocks.NewMockDefaultWithConfig(appConfig),
+		remoteResourceCreator: func(_ context.T, _ string, _ string) (remoteresource.RemoteResource, error) {
+			assert.Fail(t, "unsigned content must not be downloaded")
+			return nil, nil
+		},
+	}
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	mockIOHandler.On("MarkAsFailed", mock.MatchedBy(func(err error) bool {
+		return err.Error() == "the agent only downloads signed content and the step has no signature"
+	})).Return()
+
+	p.runCopyContent(logger, &DownloadContentPlugin{SourceType: S3, SourceInfo: "{}"}, createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory"), mockIOHandler)
+	mockIOHandler.AssertExpectations(t)
+}
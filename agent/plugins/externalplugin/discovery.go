@@ -0,0 +1,91 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// pluginNamePattern restricts the names of external plugins to characters that are safe in file names and documents
+var pluginNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// IsExternalPluginName returns whether the plugin name refers to an external plugin
+func IsExternalPluginName(pluginName string) bool {
+	return strings.HasPrefix(pluginName, appconfig.PluginNameCustomPrefix)
+}
+
+// Lookup returns the executable of the external plugin with the given name
+func Lookup(pluginName string) (path string, found bool) {
+	return lookup(appconfig.ExternalPluginPath, pluginName)
+}
+
+// Discover returns the executables of the external plugins indexed by plugin name
+func Discover(log log.T) map[string]string {
+	return discover(log, appconfig.ExternalPluginPath)
+}
+
+func lookup(pluginDir string, pluginName string) (path string, found bool) {
+	if !IsExternalPluginName(pluginName) {
+		return "", false
+	}
+	name := strings.TrimPrefix(pluginName, appconfig.PluginNameCustomPrefix)
+	if !pluginNamePattern.MatchString(name) {
+		return "", false
+	}
+	if dirInfo, err := os.Stat(pluginDir); err != nil || !isTrustedDirectory(pluginDir, dirInfo) {
+		return "", false
+	}
+	path = filepath.Join(pluginDir, executableFileName(name))
+	fileInfo, err := os.Stat(path)
+	if err != nil || !isExecutable(path, fileInfo) {
+		return "", false
+	}
+	return path, true
+}
+
+func discover(log log.T, pluginDir string) map[string]string {
+	plugins := make(map[string]string)
+	entries, err := os.ReadDir(pluginDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read external plugin directory %v: %v", pluginDir, err)
+		}
+		return plugins
+	}
+	if dirInfo, err := os.Stat(pluginDir); err != nil || !isTrustedDirectory(pluginDir, dirInfo) {
+		log.Warnf("Ignoring external plugin directory %v, users other than the administrators can modify it", pluginDir)
+		return plugins
+	}
+	for _, entry := range entries {
+		name, ok := pluginNameOf(entry.Name())
+		if !ok || !pluginNamePattern.MatchString(name) {
+			log.Debugf("Ignoring %v in external plugin directory %v", entry.Name(), pluginDir)
+			continue
+		}
+		pluginName := appconfig.PluginNameCustomPrefix + name
+		if path, found := lookup(pluginDir, pluginName); found {
+			plugins[pluginName] = path
+		} else {
+			log.Warnf("Ignoring %v in external plugin directory %v, it is not an executable file only the administrators can modify", entry.Name(), pluginDir)
+		}
+	}
+	return plugins
+}
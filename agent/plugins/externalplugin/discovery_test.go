@@ -0,0 +1,59 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover(t *testing.T) {
+	trustCurrentUser(t)
+	pluginDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, executableFileName("mount-volume")), []byte{}, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, executableFileName(".hidden")), []byte{}, 0755))
+	assert.NoError(t, os.Mkdir(filepath.Join(pluginDir, executableFileName("directory")), 0755))
+
+	plugins := discover(log.NewMockLog(), pluginDir)
+
+	assert.Equal(t, map[string]string{
+		"custom:mount-volume": filepath.Join(pluginDir, executableFileName("mount-volume")),
+	}, plugins)
+}
+
+func TestDiscoverMissingDirectory(t *testing.T) {
+	plugins := discover(log.NewMockLog(), filepath.Join(t.TempDir(), "missing"))
+
+	assert.Empty(t, plugins)
+}
+
+func TestLookup(t *testing.T) {
+	trustCurrentUser(t)
+	pluginDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, executableFileName("mount-volume")), []byte{}, 0755))
+
+	path, found := lookup(pluginDir, "custom:mount-volume")
+	assert.True(t, found)
+	assert.Equal(t, filepath.Join(pluginDir, executableFileName("mount-volume")), path)
+
+	for _, pluginName := range []string{"mount-volume", "custom:missing", "custom:../mount-volume", "aws:runShellScript"} {
+		_, found = lookup(pluginDir, pluginName)
+		assert.False(t, found, pluginName)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"syscall"
+)
+
+// trustedOwnerUID is the user that must own the external plugin directory and executables
+var trustedOwnerUID uint32 = 0
+
+// executableFileName returns the file name of the executable of an external plugin
+func executableFileName(name string) string {
+	return name
+}
+
+// pluginNameOf returns the name of the external plugin of an executable file
+func pluginNameOf(fileName string) (name string, ok bool) {
+	return fileName, true
+}
+
+// isExecutable returns whether the file is a regular file that can be executed and only root can modify
+func isExecutable(path string, fileInfo os.FileInfo) bool {
+	return fileInfo.Mode().IsRegular() && fileInfo.Mode().Perm()&0111 != 0 && isTrusted(fileInfo)
+}
+
+// isTrustedDirectory returns whether the external plugin directory is a directory only root can modify
+func isTrustedDirectory(path string, fileInfo os.FileInfo) bool {
+	return fileInfo.IsDir() && isTrusted(fileInfo)
+}
+
+// isTrusted returns whether the file is owned by root and cannot be written by its group or other users,
+// the agent runs the external plugins as root
+func isTrusted(fileInfo os.FileInfo) bool {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	return ok && stat.Uid == trustedOwnerUID && fileInfo.Mode().Perm()&0022 == 0
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// trustCurrentUser makes the files of the user running the tests trusted like the files of root
+func trustCurrentUser(t *testing.T) {
+	uid := trustedOwnerUID
+	t.Cleanup(func() { trustedOwnerUID = uid })
+	trustedOwnerUID = uint32(os.Getuid())
+}
+
+func TestDiscoverIgnoresPluginsOthersCanModify(t *testing.T) {
+	trustCurrentUser(t)
+	pluginDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "mount-volume"), []byte{}, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "group-writable"), []byte{}, 0755))
+	assert.NoError(t, os.Chmod(filepath.Join(pluginDir, "group-writable"), 0775))
+
+	plugins := discover(log.NewMockLog(), pluginDir)
+	assert.Equal(t, map[string]string{"custom:mount-volume": filepath.Join(pluginDir, "mount-volume")}, plugins)
+
+	assert.NoError(t, os.Chmod(pluginDir, 0777))
+	assert.Empty(t, discover(log.NewMockLog(), pluginDir))
+	_, found := lookup(pluginDir, "custom:mount-volume")
+	assert.False(t, found)
+}
+
+func TestDiscoverIgnoresPluginsOfOtherOwners(t *testing.T) {
+	trustCurrentUser(t)
+	trustedOwnerUID++
+	pluginDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "mount-volume"), []byte{}, 0755))
+
+	assert.Empty(t, discover(log.NewMockLog(), pluginDir))
+}
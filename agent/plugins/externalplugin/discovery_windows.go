@@ -0,0 +1,55 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build windows
+// +build windows
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// executableExtension is the extension of the executables of external plugins
+const executableExtension = ".exe"
+
+// checkHardened checks that only LocalSystem and the Administrators own and can access a path, the agent runs the
+// external plugins as LocalSystem
+var checkHardened = fileutil.CheckHardened
+
+// executableFileName returns the file name of the executable of an external plugin
+func executableFileName(name string) string {
+	return name + executableExtension
+}
+
+// pluginNameOf returns the name of the external plugin of an executable file
+func pluginNameOf(fileName string) (name string, ok bool) {
+	if !strings.HasSuffix(strings.ToLower(fileName), executableExtension) {
+		return "", false
+	}
+	return fileName[:len(fileName)-len(executableExtension)], true
+}
+
+// isExecutable returns whether the file is a regular file that can be executed and only the administrators can access
+func isExecutable(path string, fileInfo os.FileInfo) bool {
+	return fileInfo.Mode().IsRegular() && checkHardened(path) == nil
+}
+
+// isTrustedDirectory returns whether the external plugin directory is a directory only the administrators can access
+func isTrustedDirectory(path string, fileInfo os.FileInfo) bool {
+	return fileInfo.IsDir() && checkHardened(path) == nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build windows
+// +build windows
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// trustCurrentUser makes the files of the user running the tests trusted like the files of the administrators
+func trustCurrentUser(t *testing.T) {
+	check := checkHardened
+	t.Cleanup(func() { checkHardened = check })
+	checkHardened = func(path string) error { return nil }
+}
+
+func TestDiscoverIgnoresPluginsOthersCanAccess(t *testing.T) {
+	pluginDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "mount-volume.exe"), []byte{}, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pluginDir, "user-writable.exe"), []byte{}, 0755))
+	untrustedPaths := map[string]bool{filepath.Join(pluginDir, "user-writable.exe"): true}
+	check := checkHardened
+	t.Cleanup(func() { checkHardened = check })
+	checkHardened = func(path string) error {
+		if untrustedPaths[path] {
+			return fmt.Errorf("%s must be owned by and only be accessible by LocalSystem and the Administrators", path)
+		}
+		return nil
+	}
+
+	plugins := discover(log.NewMockLog(), pluginDir)
+	assert.Equal(t, map[string]string{"custom:mount-volume": filepath.Join(pluginDir, "mount-volume.exe")}, plugins)
+
+	untrustedPaths[pluginDir] = true
+	assert.Empty(t, discover(log.NewMockLog(), pluginDir))
+	_, found := lookup(pluginDir, "custom:mount-volume")
+	assert.False(t, found)
+}
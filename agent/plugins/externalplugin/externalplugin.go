@@ -0,0 +1,244 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// maxResponseSize is the maximum size of a message written by a plugin
+const maxResponseSize = 1024 * 1024
+
+// defaultCancelGracePeriod is the time a plugin has to stop after a cancel request before it is killed
+const defaultCancelGracePeriod = 10 * time.Second
+
+// Plugin is the type for the plugin running an external executable.
+type Plugin struct {
+	context           context.T
+	name              string
+	path              string
+	cancelGracePeriod time.Duration
+}
+
+// NewPlugin returns a new instance of the plugin running the executable at path.
+func NewPlugin(context context.T, pluginName string, path string) (*Plugin, error) {
+	return &Plugin{
+		context:           context,
+		name:              pluginName,
+		path:              path,
+		cancelGracePeriod: defaultCancelGracePeriod,
+	}, nil
+}
+
+// Execute runs the executable of the plugin, sending it the configuration of the step and reporting its result.
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", p.name, config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	status, err := p.run(log, config, cancelFlag, output)
+	switch {
+	case cancelFlag.ShutDown():
+		output.MarkAsShutdown()
+	case cancelFlag.Canceled():
+		output.MarkAsCancelled()
+	case err != nil:
+		output.MarkAsFailed(err)
+	default:
+		output.SetExitCode(status.ExitCode)
+		output.SetStatus(status.Status)
+	}
+}
+
+// run starts the executable and processes its messages until it exits, returning the status it reported.
+func (p *Plugin) run(log log.T, config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) (status Response, err error) {
+	command := exec.Command(p.path)
+	command.Dir = config.DefaultWorkingDirectory
+	command.Stderr = output.GetStderrWriter()
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		return status, err
+	}
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return status, err
+	}
+	if err = command.Start(); err != nil {
+		return status, fmt.Errorf("failed to start external plugin %v: %v", p.name, err)
+	}
+
+	requests := &requestWriter{writer: stdin}
+	if err = requests.write(Request{
+		ProtocolVersion: ProtocolVersion,
+		Type:            RequestTypeExecute,
+		Configuration: &PluginConfiguration{
+			PluginID:                config.PluginID,
+			PluginName:              config.PluginName,
+			MessageID:               config.MessageId,
+			OrchestrationDirectory:  config.OrchestrationDirectory,
+			DefaultWorkingDirectory: config.DefaultWorkingDirectory,
+		},
+		Properties: config.Properties,
+	}); err != nil {
+		log.Warnf("Failed to send the execute request to external plugin %v: %v", p.name, err)
+	}
+
+	exited := make(chan struct{})
+	go p.cancelOnRequest(log, command, requests, cancelFlag, exited)
+
+	status, readErr := p.readResponses(log, stdout, output)
+	if readErr != nil {
+		// the plugin cannot be trusted anymore, it is stopped rather than left blocked on a full pipe
+		command.Process.Kill()
+	}
+	waitErr := command.Wait()
+	close(exited)
+	requests.close()
+
+	if readErr != nil {
+		return status, readErr
+	}
+	if status.Type == ResponseTypeStatus {
+		return status, nil
+	}
+	// the plugin exited without reporting a status, its exit code decides the result
+	status = Response{Type: ResponseTypeStatus, Status: contracts.ResultStatusSuccess}
+	if waitErr != nil {
+		status.Status = contracts.ResultStatusFailed
+		status.ExitCode = 1
+		if exitErr, ok := waitErr.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			status.ExitCode = exitErr.ExitCode()
+		}
+		output.AppendErrorf("external plugin %v exited without reporting a status: %v", p.name, waitErr)
+	}
+	return status, nil
+}
+
+// readResponses writes the output messages of the plugin to the output and returns the last status message
+func (p *Plugin) readResponses(log log.T, stdout io.Reader, output iohandler.IOHandler) (status Response, err error) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxResponseSize)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var response Response
+		if err = json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			return status, fmt.Errorf("external plugin %v wrote an invalid message: %v", p.name, err)
+		}
+		if !isSupportedProtocolVersion(response.ProtocolVersion) {
+			return status, fmt.Errorf("external plugin %v uses protocol version %v, the agent supports version %v",
+				p.name, response.ProtocolVersion, ProtocolVersion)
+		}
+		switch response.Type {
+		case ResponseTypeStdout:
+			output.GetStdoutWriter().WriteString(response.Data)
+		case ResponseTypeStderr:
+			output.GetStderrWriter().WriteString(response.Data)
+		case ResponseTypeStatus:
+			if !isSupportedStatus(response.Status) {
+				return status, fmt.Errorf("external plugin %v reported unsupported status %v", p.name, response.Status)
+			}
+			status = response
+		default:
+			log.Warnf("Ignoring message of unknown type %v from external plugin %v", response.Type, p.name)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return status, fmt.Errorf("failed to read the output of external plugin %v: %v", p.name, err)
+	}
+	return status, nil
+}
+
+// cancelOnRequest sends a cancel request to the plugin when the step is cancelled and kills it
+// if it has not exited after the grace period.
+func (p *Plugin) cancelOnRequest(log log.T, command *exec.Cmd, requests *requestWriter, cancelFlag task.CancelFlag, exited chan struct{}) {
+	flagSet, release := task.WaitChannel(cancelFlag)
+	defer release()
+	select {
+	case <-exited:
+		return
+	case <-flagSet:
+	}
+	if !cancelFlag.Canceled() && !cancelFlag.ShutDown() {
+		return
+	}
+
+	reason := "Canceled"
+	if cancelFlag.ShutDown() {
+		reason = "ShutDown"
+	}
+	log.Infof("Sending cancel request to external plugin %v", p.name)
+	if err := requests.write(Request{ProtocolVersion: ProtocolVersion, Type: RequestTypeCancel, Reason: reason}); err != nil {
+		log.Warnf("Failed to send the cancel request to external plugin %v: %v", p.name, err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(p.cancelGracePeriod):
+		log.Warnf("External plugin %v did not stop within %v after the cancel request, killing it", p.name, p.cancelGracePeriod)
+		if err := command.Process.Kill(); err != nil {
+			log.Errorf("Failed to kill external plugin %v: %v", p.name, err)
+		}
+	}
+}
+
+// requestWriter writes the requests to the standard input of the plugin from several goroutines
+type requestWriter struct {
+	lock   sync.Mutex
+	writer io.WriteCloser
+	closed bool
+}
+
+func (r *requestWriter) write(request Request) error {
+	content, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return fmt.Errorf("the plugin has exited")
+	}
+	_, err = r.writer.Write(append(content, '\n'))
+	return err
+}
+
+func (r *requestWriter) close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.closed = true
+	r.writer.Close()
+}
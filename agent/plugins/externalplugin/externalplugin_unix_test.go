@@ -0,0 +1,136 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// runTestPlugin runs a shell script as external plugin and returns its output once the step completed
+func runTestPlugin(t *testing.T, script string, cancelFlag task.CancelFlag) *iohandler.DefaultIOHandler {
+	return runTestPluginWithGracePeriod(t, script, cancelFlag, defaultCancelGracePeriod)
+}
+
+// runTestPluginWithGracePeriod runs a shell script as external plugin that is killed after the grace period once cancelled
+func runTestPluginWithGracePeriod(t *testing.T, script string, cancelFlag task.CancelFlag, gracePeriod time.Duration) *iohandler.DefaultIOHandler {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plugin")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+
+	ctx := context.NewMockDefault()
+	output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: dir})
+	output.Init("custom")
+	plugin, err := NewPlugin(ctx, "custom:test", path)
+	assert.NoError(t, err)
+	plugin.cancelGracePeriod = gracePeriod
+
+	plugin.Execute(contracts.Configuration{
+		PluginID:                "step",
+		PluginName:              "custom:test",
+		DefaultWorkingDirectory: dir,
+		Properties:              map[string]interface{}{"volume": "data"},
+	}, cancelFlag, output)
+	output.Close()
+	return output
+}
+
+func TestExecuteReportsStatusAndOutput(t *testing.T) {
+	output := runTestPlugin(t, `read request
+case "$request" in
+  *'"type":"execute"'*'"volume":"data"'*) ;;
+  *) echo "unexpected request $request" >&2; exit 2 ;;
+esac
+printf '%s\n' '{"protocolVersion":"1.0","type":"stdout","data":"mounted data\n"}'
+printf '%s\n' '{"protocolVersion":"1.1","type":"stderr","data":"slow disk\n"}'
+echo '{"protocolVersion":"1.0","type":"status","status":"Failed","exitCode":3}'
+`, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, 3, output.GetExitCode())
+	assert.Equal(t, "mounted data\n", output.GetStdout())
+	assert.Equal(t, "slow disk\n", output.GetStderr())
+}
+
+func TestExecuteWithoutStatusUsesExitCode(t *testing.T) {
+	output := runTestPlugin(t, "exit 4\n", task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, 4, output.GetExitCode())
+	assert.Contains(t, output.GetStderr(), "exited without reporting a status")
+
+	output = runTestPlugin(t, "exit 0\n", task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+}
+
+func TestExecuteRejectsUnsupportedProtocolVersion(t *testing.T) {
+	output := runTestPlugin(t, `echo '{"protocolVersion":"2.0","type":"status","status":"Success"}'`, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "protocol version 2.0")
+}
+
+func TestExecuteSendsCancelRequest(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	time.AfterFunc(100*time.Millisecond, func() { cancelFlag.Set(task.Canceled) })
+
+	output := runTestPlugin(t, `read request
+read cancel
+case "$cancel" in
+  *'"type":"cancel"'*) echo '{"protocolVersion":"1.0","type":"stdout","data":"cancelled"}' ;;
+esac
+`, cancelFlag)
+
+	assert.Equal(t, contracts.ResultStatusCancelled, output.GetStatus())
+	assert.Equal(t, "cancelled", output.GetStdout())
+}
+
+func TestExecuteKillsPluginIgnoringCancel(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	time.AfterFunc(100*time.Millisecond, func() { cancelFlag.Set(task.Canceled) })
+
+	start := time.Now()
+	output := runTestPluginWithGracePeriod(t, "exec sleep 30\n", cancelFlag, 100*time.Millisecond)
+
+	assert.Equal(t, contracts.ResultStatusCancelled, output.GetStatus())
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
+
+func TestExecuteReleasesCancelFlag(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	before := runtime.NumGoroutine()
+
+	output := runTestPlugin(t, "exit 0\n", cancelFlag)
+
+	// the step completed without being cancelled, nothing waits on its cancel flag anymore
+	for start := time.Now(); runtime.NumGoroutine() > before && time.Since(start) < time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package externalplugin implements the plugin running the executables found in the external plugins directory.
+package externalplugin
+
+import (
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// The agent and an external plugin exchange newline delimited JSON messages. The agent writes an execute request
+// on the standard input of the plugin, followed by a cancel request if the step is cancelled. The plugin writes
+// stdout and stderr messages on its standard output while it runs and ends with a status message.
+const (
+	// ProtocolVersion is the version of the protocol, plugins must reply with the same major version
+	ProtocolVersion = "1.0"
+
+	// RequestTypeExecute asks the plugin to run the step
+	RequestTypeExecute = "execute"
+
+	// RequestTypeCancel asks the plugin to stop, it is killed if it is still running after the grace period
+	RequestTypeCancel = "cancel"
+
+	// ResponseTypeStdout carries output of the step
+	ResponseTypeStdout = "stdout"
+
+	// ResponseTypeStderr carries error output of the step
+	ResponseTypeStderr = "stderr"
+
+	// ResponseTypeStatus carries the result of the step
+	ResponseTypeStatus = "status"
+)
+
+// PluginConfiguration is the configuration of the step sent to the plugin
+type PluginConfiguration struct {
+	PluginID                string `json:"pluginId"`
+	PluginName              string `json:"pluginName"`
+	MessageID               string `json:"messageId"`
+	OrchestrationDirectory  string `json:"orchestrationDirectory"`
+	DefaultWorkingDirectory string `json:"defaultWorkingDirectory"`
+}
+
+// Request is a message written by the agent on the standard input of the plugin
+type Request struct {
+	ProtocolVersion string               `json:"protocolVersion"`
+	Type            string               `json:"type"`
+	Configuration   *PluginConfiguration `json:"configuration,omitempty"`
+	Properties      interface{}          `json:"properties,omitempty"`
+	// Reason of a cancel request, Canceled or ShutDown
+	Reason string `json:"reason,omitempty"`
+}
+
+// Response is a message written by the plugin on its standard output
+type Response struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Type            string                 `json:"type"`
+	Data            string                 `json:"data,omitempty"`
+	Status          contracts.ResultStatus `json:"status,omitempty"`
+	ExitCode        int                    `json:"exitCode,omitempty"`
+}
+
+// isSupportedProtocolVersion returns whether the version has the major version of ProtocolVersion
+func isSupportedProtocolVersion(version string) bool {
+	return strings.SplitN(version, ".", 2)[0] == strings.SplitN(ProtocolVersion, ".", 2)[0]
+}
+
+// isSupportedStatus returns whether the plugin can report the status as the result of the step
+func isSupportedStatus(status contracts.ResultStatus) bool {
+	switch status {
+	case contracts.ResultStatusSuccess, contracts.ResultStatusFailed, contracts.ResultStatusSuccessAndReboot:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// interpreterSettings describes how scripts of a family of interpreters are written
+type interpreterSettings struct {
+	extension string
+	// strictModeCommands are prepended to the script to stop it at the first error
+	strictModeCommands []string
+}
+
+// interpreterFamilies are indexed by the name of the interpreter without version, e.g. python for python3.11
+var interpreterFamilies = map[string]interpreterSettings{
+	"sh":     {extension: ".sh", strictModeCommands: []string{"set -eu"}},
+	"bash":   {extension: ".sh", strictModeCommands: []string{"set -euo pipefail"}},
+	"python": {extension: ".py"},
+	"perl":   {extension: ".pl", strictModeCommands: []string{"use strict;", "use warnings;"}},
+}
+
+// supportedInterpreterNames are the interpreters that can be selected by name, any other interpreter needs an absolute path
+var supportedInterpreterNames = []string{"bash", "sh", "python3", "perl"}
+
+var lookPath = exec.LookPath
+
+// interpreter is the program running the commands of a step
+type interpreter struct {
+	path     string
+	settings interpreterSettings
+}
+
+// getInterpreter returns the interpreter selected by the interpreter input of a step, nil when the commands
+// run with the default shell of the plugin. Interpreters are only supported by aws:runShellScript.
+func (p *Plugin) getInterpreter(pluginInput RunScriptPluginInput) (*interpreter, error) {
+	strictMode := false
+	if pluginInput.StrictMode != "" {
+		var err error
+		if strictMode, err = strconv.ParseBool(pluginInput.StrictMode); err != nil {
+			return nil, fmt.Errorf("strictMode must be true or false")
+		}
+	}
+	if pluginInput.Interpreter == "" {
+		if strictMode {
+			return nil, fmt.Errorf("strictMode requires interpreter")
+		}
+		return nil, nil
+	}
+	if p.Name != appconfig.PluginNameAwsRunShellScript {
+		return nil, fmt.Errorf("interpreter is not supported by %v", p.Name)
+	}
+
+	selected, err := resolveInterpreter(pluginInput.Interpreter)
+	if err != nil {
+		return nil, err
+	}
+	if !strictMode {
+		selected.settings.strictModeCommands = nil
+	} else if len(selected.settings.strictModeCommands) == 0 {
+		return nil, fmt.Errorf("strictMode is not supported by interpreter %v", pluginInput.Interpreter)
+	}
+	return selected, nil
+}
+
+// resolveInterpreter finds an interpreter by name in the PATH of the agent or checks the interpreter at an absolute path
+func resolveInterpreter(name string) (*interpreter, error) {
+	if filepath.IsAbs(name) {
+		fileInfo, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("interpreter %v was not found: %v", name, err)
+		}
+		if !fileInfo.Mode().IsRegular() || fileInfo.Mode().Perm()&0111 == 0 {
+			return nil, fmt.Errorf("interpreter %v is not an executable file", name)
+		}
+		return &interpreter{path: name, settings: interpreterFamilies[interpreterFamily(name)]}, nil
+	}
+
+	for _, supportedName := range supportedInterpreterNames {
+		if name == supportedName {
+			path, err := lookPath(name)
+			if err != nil {
+				return nil, fmt.Errorf("interpreter %v was not found: %v", name, err)
+			}
+			return &interpreter{path: path, settings: interpreterFamilies[interpreterFamily(name)]}, nil
+		}
+	}
+	return nil, fmt.Errorf("interpreter must be one of %v or an absolute path", strings.Join(supportedInterpreterNames, ", "))
+}
+
+// interpreterFamily returns the name of an interpreter without directory and version, e.g. python for /usr/bin/python3.11
+func interpreterFamily(path string) string {
+	return strings.TrimRight(filepath.Base(path), "0123456789.")
+}
+
+// scriptName returns the name of the script file, with the extension expected by the interpreter
+func (selected *interpreter) scriptName() string {
+	return "_script" + selected.settings.extension
+}
+
+// script returns the commands of the step, after the strict mode commands of the interpreter if it is enabled
+func (selected *interpreter) script(commands []string) []string {
+	return append(append([]string{}, selected.settings.strictModeCommands...), commands...)
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubLookPath(t *testing.T) {
+	defaultLookPath := lookPath
+	lookPath = func(name string) (string, error) {
+		if name == "perl" {
+			return "", errors.New("executable file not found in $PATH")
+		}
+		return "/usr/bin/" + name, nil
+	}
+	t.Cleanup(func() { lookPath = defaultLookPath })
+}
+
+func TestGetInterpreter_ByName(t *testing.T) {
+	stubLookPath(t)
+	p := &Plugin{Name: appconfig.PluginNameAwsRunShellScript}
+
+	selected, err := p.getInterpreter(RunScriptPluginInput{Interpreter: "bash", StrictMode: "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/bash", selected.path)
+	assert.Equal(t, "_script.sh", selected.scriptName())
+	assert.Equal(t, []string{"set -euo pipefail", "echo hello"}, selected.script([]string{"echo hello"}))
+
+	selected, err = p.getInterpreter(RunScriptPluginInput{Interpreter: "python3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/bin/python3", selected.path)
+	assert.Equal(t, "_script.py", selected.scriptName())
+	assert.Equal(t, []string{"print('hello')"}, selected.script([]string{"print('hello')"}))
+
+	selected, err = p.getInterpreter(RunScriptPluginInput{Interpreter: "sh", StrictMode: "false"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo hello"}, selected.script([]string{"echo hello"}))
+
+	selected, err = p.getInterpreter(RunScriptPluginInput{})
+	assert.NoError(t, err)
+	assert.Nil(t, selected)
+}
+
+func TestGetInterpreter_Invalid(t *testing.T) {
+	stubLookPath(t)
+	p := &Plugin{Name: appconfig.PluginNameAwsRunShellScript}
+
+	_, err := p.getInterpreter(RunScriptPluginInput{Interpreter: "perl"})
+	assert.EqualError(t, err, "interpreter perl was not found: executable file not found in $PATH")
+
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: "ruby"})
+	assert.EqualError(t, err, "interpreter must be one of bash, sh, python3, perl or an absolute path")
+
+	_, err = p.getInterpreter(RunScriptPluginInput{StrictMode: "true"})
+	assert.EqualError(t, err, "strictMode requires interpreter")
+
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: "bash", StrictMode: "yes please"})
+	assert.EqualError(t, err, "strictMode must be true or false")
+
+	p.Name = appconfig.PluginNameAwsRunPowerShellScript
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: "bash"})
+	assert.EqualError(t, err, "interpreter is not supported by aws:runPowerShellScript")
+}
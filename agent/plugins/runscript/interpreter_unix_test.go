@@ -0,0 +1,50 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package runscript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInterpreter_ByPath(t *testing.T) {
+	p := &Plugin{Name: appconfig.PluginNameAwsRunShellScript}
+	directory := t.TempDir()
+	python := filepath.Join(directory, "python3.11")
+	assert.NoError(t, os.WriteFile(python, []byte{}, 0755))
+	notExecutable := filepath.Join(directory, "perl")
+	assert.NoError(t, os.WriteFile(notExecutable, []byte{}, 0644))
+
+	selected, err := p.getInterpreter(RunScriptPluginInput{Interpreter: python})
+	assert.NoError(t, err)
+	assert.Equal(t, python, selected.path)
+	assert.Equal(t, "_script.py", selected.scriptName())
+
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: python, StrictMode: "true"})
+	assert.EqualError(t, err, "strictMode is not supported by interpreter "+python)
+
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: notExecutable})
+	assert.EqualError(t, err, "interpreter "+notExecutable+" is not an executable file")
+
+	_, err = p.getInterpreter(RunScriptPluginInput{Interpreter: filepath.Join(directory, "ruby")})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "was not found")
+}
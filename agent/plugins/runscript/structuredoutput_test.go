@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runscript implements the runscript plugin.
+package runscript
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOutputFormat(t *testing.T) {
+	assert.NoError(t, validateOutputFormat(appconfig.PluginNameAwsRunShellScript, ""))
+	assert.NoError(t, validateOutputFormat(appconfig.PluginNameAwsRunShellScript, "json"))
+	assert.NoError(t, validateOutputFormat(appconfig.PluginNameAwsRunShellScript, "keyvalue"))
+	assert.NoError(t, validateOutputFormat(appconfig.PluginNameAwsRunPowerShellScript, ""))
+	assert.EqualError(t, validateOutputFormat(appconfig.PluginNameAwsRunShellScript, "yaml"), "outputFormat must be json or keyvalue")
+	assert.EqualError(t, validateOutputFormat(appconfig.PluginNameAwsRunPowerShellScript, "json"), "outputFormat is not supported by aws:runPowerShellScript")
+}
+
+func TestParseStructuredOutput_JSON(t *testing.T) {
+	values, err := parseStructuredOutput("json", []byte(`{"version": "1.2.3", "replicas": 3, "tags": ["a", "b"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"version": "1.2.3", "replicas": float64(3), "tags": []interface{}{"a", "b"}}, values)
+
+	_, err = parseStructuredOutput("json", []byte(`["not", "an", "object"]`))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "structured output is not a JSON object")
+}
+
+func TestParseStructuredOutput_KeyValue(t *testing.T) {
+	values, err := parseStructuredOutput("keyvalue", []byte("# build info\nversion=1.2.3\n\n url = https://example.com/?a=b \n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"version": "1.2.3", "url": "https://example.com/?a=b"}, values)
+
+	_, err = parseStructuredOutput("keyvalue", []byte("version=1.2.3\nnot a pair\n"))
+	assert.EqualError(t, err, "line 2 of structured output is not a key=value pair")
+}
+
+func TestParseStructuredOutput_Empty(t *testing.T) {
+	for _, outputFormat := range []string{"json", "keyvalue"} {
+		values, err := parseStructuredOutput(outputFormat, []byte("\n"))
+		assert.NoError(t, err)
+		assert.Empty(t, values)
+	}
+}
+
+func TestReadStructuredOutput(t *testing.T) {
+	file, err := createStructuredOutputFile(filepath.Join(t.TempDir(), structuredOutputFileName), nil)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString(`{"status": "ok"}`)
+	assert.NoError(t, err)
+	values, err := readStructuredOutput(file, "json")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"status": "ok"}, values)
+
+	_, err = file.Write(make([]byte, maxStructuredOutputSize))
+	assert.NoError(t, err)
+	_, err = readStructuredOutput(file, "json")
+	assert.EqualError(t, err, "structured output exceeds 65536 bytes")
+}
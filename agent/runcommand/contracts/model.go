@@ -47,6 +47,14 @@ type SendReplyPayload struct {
 	RuntimeStatus       map[string]*contracts.PluginRuntimeStatus `json:"runtimeStatus"`
 }
 
+// DryRunPayload represents the json structure of the execution plan written for a document submitted with dryRun.
+type DryRunPayload struct {
+	DryRun        bool                    `json:"dryRun"`
+	DocumentName  string                  `json:"documentName"`
+	SchemaVersion string                  `json:"schemaVersion"`
+	ExecutionPlan []contracts.PlannedStep `json:"executionPlan"`
+}
+
 // getCommandID gets CommandID from given MessageID
 func getCommandID(messageID string) string {
 	// MdsMessageID is in the format of : aws.ssm.CommandId.InstanceId
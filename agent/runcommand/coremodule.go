@@ -166,6 +166,11 @@ func (s *RunCommandService) processMessage(msg *ssmmds.Message) {
 
 	log.Debugf("Ack done. Received message - messageId - %v", *msg.MessageId)
 
+	if docState.DocumentInformation.DryRun {
+		s.processDryRun(context, *msg.MessageId, docState)
+		return
+	}
+
 	log.Debugf("Processing to send a reply to update the document status to InProgress")
 
 	//TODO This function should be called in service when it submits the document to the engine
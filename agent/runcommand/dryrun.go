@@ -0,0 +1,106 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package runcommand implements runcommand core processing module
+package runcommand
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
+)
+
+var planPlugins = runpluginutil.PlanPlugins
+
+// processDryRun replies with the execution plan of a parsed document instead of submitting it to the processor.
+// The offline service writes the reply to the completed folder.
+func (s *RunCommandService) processDryRun(context context.T, messageID string, docState *contracts.DocumentState) {
+	log := context.Log()
+	log.Infof("Document %v was submitted with dryRun, writing its execution plan instead of running it", docState.DocumentInformation.DocumentName)
+
+	executionPlan := planPlugins(context, docState.InstancePluginsInformation, runpluginutil.SSMPluginRegistry)
+	// the plan shows the inputs with the document parameters and SSM parameters resolved, SecureString and secret
+	// backend references are only resolved when the step runs, and the values of secure local parameters are redacted
+	stepInputs := make(map[string]interface{}, len(docState.InstancePluginsInformation))
+	for _, pluginState := range docState.InstancePluginsInformation {
+		stepInputs[pluginState.Id] = redactSecretValues(pluginState.Configuration.Properties, docState.IOConfig.SecretValues)
+	}
+	for index := range executionPlan {
+		executionPlan[index].Inputs = stepInputs[executionPlan[index].StepName]
+	}
+
+	payload := messageContracts.DryRunPayload{
+		DryRun:        true,
+		DocumentName:  docState.DocumentInformation.DocumentName,
+		SchemaVersion: docState.SchemaVersion,
+		ExecutionPlan: executionPlan,
+	}
+	payloadString, err := jsonutil.Marshal(payload)
+	if err != nil {
+		log.Errorf("Failed to marshal the execution plan of message %v: %v", messageID, err)
+		s.sendDocLevelResponse(messageID, contracts.ResultStatusFailed, err.Error())
+		return
+	}
+	if err = s.service.SendReply(log, messageID, jsonutil.Indent(payloadString)); err != nil {
+		sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
+	}
+}
+
+// redactSecretValues returns a copy of the step inputs where the secret values are replaced by the masked value
+func redactSecretValues(value interface{}, secretValues []string) interface{} {
+	switch value := value.(type) {
+	case string:
+		for _, secretValue := range sortedSecretValues(secretValues) {
+			value = strings.Replace(value, secretValue, secretmask.MaskedValue, -1)
+		}
+		return value
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			redacted[key] = redactSecretValues(item, secretValues)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for index, item := range value {
+			redacted[index] = redactSecretValues(item, secretValues)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(value))
+		for index, item := range value {
+			redacted[index] = redactSecretValues(item, secretValues).(string)
+		}
+		return redacted
+	}
+	return value
+}
+
+// sortedSecretValues returns the non empty secret values, longest first so that a secret containing another one is redacted whole
+func sortedSecretValues(secretValues []string) []string {
+	sorted := make([]string, 0, len(secretValues))
+	for _, secretValue := range secretValues {
+		if secretValue != "" {
+			sorted = append(sorted, secretValue)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return sorted
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
 	processormock "github.com/aws/amazon-ssm-agent/agent/framework/processor/mock"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -93,6 +94,71 @@ func TestProcessMessageWithSendCommandTopicPrefix(t *testing.T) {
 	assert.True(t, *tc.IsDocLevelResponseSent)
 }
 
+// TestProcessMessageWithDryRun tests processMessage writes the execution plan of a dry run instead of submitting it
+func TestProcessMessageWithDryRun(t *testing.T) {
+	var fakeDocState = contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{DocumentName: "testDocument", DryRun: true},
+		DocumentType:        contracts.SendCommandOffline,
+		SchemaVersion:       "2.2",
+		InstancePluginsInformation: []contracts.PluginState{{
+			Id:   "step1",
+			Name: "aws:runShellScript",
+			Configuration: contracts.Configuration{
+				Properties: map[string]interface{}{"runCommand": []interface{}{
+					"echo 1.2.3 us-east-1",
+					"echo {{ssm-secure:password}} {{secretsmanager:token}}",
+					"curl -H 'Authorization: local-api-key' https://example.com",
+				}},
+			},
+		}},
+		IOConfig: contracts.IOConfiguration{SecretValues: []string{"local-api-key"}},
+	}
+	svc, tc := prepareTestProcessMessage(testTopicSend)
+	tc.Message.Payload = aws.String(`{"DocumentContent":{"schemaVersion":"2.2","mainSteps":[{"action":"aws:runShellScript","name":"step1","inputs":{"runCommand":["echo {{ version }} {{ssm:region}}"]}}]}}`)
+
+	tc.MdsMock.On("AcknowledgeMessage", mock.Anything, *tc.Message.MessageId).Return(nil)
+	loadDocStateFromSendCommand = func(context context.T,
+		msg *ssmmds.Message,
+		messagesOrchestrationRootDir string) (*contracts.DocumentState, error) {
+		return &fakeDocState, nil
+	}
+	plannedSteps := []contracts.PlannedStep{{StepName: "step1", Action: "aws:runShellScript", Operation: "execute"}}
+	oldPlanPlugins := planPlugins
+	planPlugins = func(context context.T, plugins []contracts.PluginState, registry runpluginutil.PluginRegistry) []contracts.PlannedStep {
+		return plannedSteps
+	}
+	defer func() { planPlugins = oldPlanPlugins }()
+
+	var reply string
+	tc.MdsMock.On("SendReply", mock.Anything, *tc.Message.MessageId, mock.Anything).Run(func(args mock.Arguments) {
+		reply = args.String(2)
+	}).Return(nil)
+
+	svc.processMessage(&tc.Message)
+
+	tc.MdsMock.AssertExpectations(t)
+	tc.ProcessMock.AssertNotCalled(t, "Submit", mock.Anything)
+	assert.False(t, *tc.IsDocLevelResponseSent)
+
+	var payload messageContracts.DryRunPayload
+	assert.NoError(t, json.Unmarshal([]byte(reply), &payload))
+	assert.Equal(t, messageContracts.DryRunPayload{
+		DryRun:        true,
+		DocumentName:  "testDocument",
+		SchemaVersion: "2.2",
+		ExecutionPlan: []contracts.PlannedStep{{
+			StepName:  "step1",
+			Action:    "aws:runShellScript",
+			Operation: "execute",
+			Inputs: map[string]interface{}{"runCommand": []interface{}{
+				"echo 1.2.3 us-east-1",
+				"echo {{ssm-secure:password}} {{secretsmanager:token}}",
+				"curl -H 'Authorization: ****' https://example.com",
+			}},
+		}},
+	}, payload)
+}
+
 // TestProcessMessageWithCancelCommandTopicPrefix tests processMessage with CancelCommand topic prefix
 func TestProcessMessageWithCancelCommandTopicPrefix(t *testing.T) {
 	// CancelCommand topic prefix
@@ -382,8 +448,8 @@ func getPluginConfigurationsFromMainStep(mainSteps []*contracts.InstancePluginCo
 		for operator, arguments := range instancePluginConfig.Preconditions {
 			for _, arg := range arguments {
 				resolvedPreconditions[operator] = append(resolvedPreconditions[operator], contracts.PreconditionArgument{
-					InitialArgumentValue:  arg,
-					ResolvedArgumentValue: arg,
+					InitialArgumentValue:  arg.(string),
+					ResolvedArgumentValue: arg.(string),
 				})
 			}
 		}
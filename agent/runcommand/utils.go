@@ -181,6 +181,8 @@ func parseSendCommandMessage(context context.T, msg *ssmmds.Message, messagesOrc
 		documentType = contracts.SendCommand
 	}
 	documentInfo := newDocumentInfo(*msg, parsedMessage)
+	// only documents submitted locally can ask for a dry run
+	documentInfo.DryRun = documentType == contracts.SendCommandOffline && parsedMessage.DocumentContent.DryRun
 	parserInfo := docparser.DocumentParserInfo{
 		OrchestrationDir: messageOrchestrationDirectory,
 		S3Bucket:         parsedMessage.OutputS3BucketName,
@@ -195,6 +197,7 @@ func parseSendCommandMessage(context context.T, msg *ssmmds.Message, messagesOrc
 		Description:   parsedMessage.DocumentContent.Description,
 		RuntimeConfig: parsedMessage.DocumentContent.RuntimeConfig,
 		MainSteps:     parsedMessage.DocumentContent.MainSteps,
+		FinallySteps:  parsedMessage.DocumentContent.FinallySteps,
 		Parameters:    parsedMessage.DocumentContent.Parameters}
 	//Data format persisted in Current Folder is defined by the struct - CommandState
 	docState, err := docparser.InitializeDocState(context, documentType, docContent, documentInfo, parserInfo, parsedMessage.Parameters)
@@ -0,0 +1,218 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package signature verifies detached ed25519 and minisign signatures with the public keys of the local trust store.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// minisignAlgorithm signs the message, minisignPrehashedAlgorithm signs its BLAKE2b-512 hash
+	minisignAlgorithm          = "Ed"
+	minisignPrehashedAlgorithm = "ED"
+
+	minisignKeyIDLength     = 8
+	minisignPublicKeyLength = 2 + minisignKeyIDLength + ed25519.PublicKeySize
+	minisignSignatureLength = 2 + minisignKeyIDLength + ed25519.SignatureSize
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+// trustedKeyExtensions are the extensions of the files of the trust store holding a public key
+var trustedKeyExtensions = map[string]bool{".pub": true, ".pem": true}
+
+// publicKey is a key of the trust store, keyID is only set for minisign keys
+type publicKey struct {
+	name  string
+	keyID []byte
+	key   ed25519.PublicKey
+}
+
+// TrustStore holds the public keys trusted to sign scripts and content.
+type TrustStore struct {
+	keys []publicKey
+}
+
+// TrustStorePath returns the folder of the trust store under the program folder of the agent
+func TrustStorePath() string {
+	return filepath.Join(appconfig.DefaultProgramFolder, appconfig.TrustedKeysFolderName)
+}
+
+// trustStoreFolder returns the folder of the trust store of the agent, StubTrustStore replaces it in tests
+var trustStoreFolder = TrustStorePath
+
+// LoadAgentTrustStore reads the public keys of the trust store of the agent
+func LoadAgentTrustStore() (*TrustStore, error) {
+	return LoadTrustStore(trustStoreFolder())
+}
+
+// LoadTrustStore reads the public keys of the .pub and .pem files of the folder. The files hold a minisign public key,
+// a PEM encoded ed25519 public key or the base64 encoded 32 bytes of an ed25519 public key.
+func LoadTrustStore(folder string) (*TrustStore, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store %v: %v", folder, err)
+	}
+
+	store := &TrustStore{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !trustedKeyExtensions[filepath.Ext(entry.Name())] {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(folder, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted key %v: %v", entry.Name(), err)
+		}
+		key, err := parsePublicKey(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted key %v: %v", entry.Name(), err)
+		}
+		store.keys = append(store.keys, key)
+	}
+	if len(store.keys) == 0 {
+		return nil, fmt.Errorf("trust store %v has no keys", folder)
+	}
+	return store, nil
+}
+
+// parsePublicKey parses a public key file of the trust store
+func parsePublicKey(name string, content []byte) (publicKey, error) {
+	if block, _ := pem.Decode(content); block != nil {
+		parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return publicKey{}, err
+		}
+		key, ok := parsedKey.(ed25519.PublicKey)
+		if !ok {
+			return publicKey{}, errors.New("only ed25519 keys are supported")
+		}
+		return publicKey{name: name, key: key}, nil
+	}
+
+	lines := contentLines(string(content))
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return publicKey{}, errors.New("expected a single base64 encoded key")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return publicKey{}, fmt.Errorf("key is not base64 encoded: %v", err)
+	}
+	switch {
+	case len(decoded) == minisignPublicKeyLength && string(decoded[:2]) == minisignAlgorithm:
+		return publicKey{name: name, keyID: decoded[2 : 2+minisignKeyIDLength], key: decoded[2+minisignKeyIDLength:]}, nil
+	case len(decoded) == ed25519.PublicKeySize:
+		return publicKey{name: name, key: decoded}, nil
+	default:
+		return publicKey{}, errors.New("expected a minisign or an ed25519 public key")
+	}
+}
+
+// Verify checks the detached signature of the message and returns the name of the key that signed it.
+// The signature is either the content of a minisign signature file or the base64 encoded 64 bytes of an ed25519 signature.
+func (store *TrustStore) Verify(message []byte, signature string) (string, error) {
+	lines := contentLines(signature)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return store.verifyMinisign(message, lines[1:])
+	}
+	if len(lines) != 1 {
+		return "", errors.New("expected a minisign signature or a single base64 encoded ed25519 signature")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(decoded) != ed25519.SignatureSize {
+		return "", errors.New("expected a minisign signature or a single base64 encoded ed25519 signature")
+	}
+	for _, key := range store.keys {
+		if ed25519.Verify(key.key, message, decoded) {
+			return key.name, nil
+		}
+	}
+	return "", errors.New("the signature does not match any key of the trust store")
+}
+
+// verifyMinisign checks the lines of a minisign signature following its untrusted comment
+func (store *TrustStore) verifyMinisign(message []byte, lines []string) (string, error) {
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], trustedCommentPrefix) {
+		return "", errors.New("malformed minisign signature")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(decoded) != minisignSignatureLength {
+		return "", errors.New("malformed minisign signature")
+	}
+	globalSignature, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return "", errors.New("malformed minisign trusted comment signature")
+	}
+
+	algorithm, keyID, signature := string(decoded[:2]), decoded[2:2+minisignKeyIDLength], decoded[2+minisignKeyIDLength:]
+	switch algorithm {
+	case minisignAlgorithm:
+	case minisignPrehashedAlgorithm:
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	default:
+		return "", fmt.Errorf("unsupported minisign signature algorithm %q", algorithm)
+	}
+
+	for _, key := range store.keys {
+		if key.keyID == nil || !bytes.Equal(key.keyID, keyID) {
+			continue
+		}
+		if !ed25519.Verify(key.key, message, signature) {
+			return "", fmt.Errorf("the signature does not match key %v", key.name)
+		}
+		trustedComment := strings.TrimPrefix(lines[1], trustedCommentPrefix)
+		if !ed25519.Verify(key.key, append(append([]byte{}, signature...), trustedComment...), globalSignature) {
+			return "", fmt.Errorf("the trusted comment signature does not match key %v", key.name)
+		}
+		return key.name, nil
+	}
+	return "", fmt.Errorf("key %v is not in the trust store", strings.ToUpper(hex.EncodeToString(reverse(keyID))))
+}
+
+// contentLines returns the non-empty lines of the content without surrounding whitespace
+func contentLines(content string) (lines []string) {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// reverse returns the bytes in reverse order, minisign prints its little endian key ids as hexadecimal numbers
+func reverse(value []byte) []byte {
+	reversed := make([]byte, len(value))
+	for i, b := range value {
+		reversed[len(value)-1-i] = b
+	}
+	return reversed
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+var testKeyID = []byte{0x1f, 0xe8, 0xb4, 0x42, 0x18, 0x0f, 0x62, 0xe7}
+
+func generateTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	return publicKey, privateKey
+}
+
+func minisignPublicKey(publicKey ed25519.PublicKey) string {
+	encoded := append(append([]byte(minisignAlgorithm), testKeyID...), publicKey...)
+	return "untrusted comment: minisign public key E7620F1842B4E81F\n" + base64.StdEncoding.EncodeToString(encoded) + "\n"
+}
+
+func minisignSignature(privateKey ed25519.PrivateKey, algorithm string, message []byte, trustedComment string) string {
+	if algorithm == minisignPrehashedAlgorithm {
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	}
+	signature := ed25519.Sign(privateKey, message)
+	globalSignature := ed25519.Sign(privateKey, append(append([]byte{}, signature...), trustedComment...))
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), testKeyID...), signature...)) + "\n" +
+		trustedCommentPrefix + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSignature) + "\n"
+}
+
+func writeTestKey(t *testing.T, folder string, fileName string, content string) {
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, fileName), []byte(content), 0600))
+}
+
+func TestLoadTrustStore(t *testing.T) {
+	folder := t.TempDir()
+	_, err := LoadTrustStore(folder)
+	assert.EqualError(t, err, "trust store "+folder+" has no keys")
+
+	rawKey, _ := generateTestKey(t)
+	pemKey, _ := generateTestKey(t)
+	minisignKey, _ := generateTestKey(t)
+	pkix, err := x509.MarshalPKIXPublicKey(pemKey)
+	assert.NoError(t, err)
+	writeTestKey(t, folder, "raw.pub", base64.StdEncoding.EncodeToString(rawKey))
+	writeTestKey(t, folder, "openssl.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})))
+	writeTestKey(t, folder, "minisign.pub", minisignPublicKey(minisignKey))
+	writeTestKey(t, folder, "README", "not a key")
+
+	store, err := LoadTrustStore(folder)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []publicKey{
+		{name: "raw", key: rawKey},
+		{name: "openssl", key: pemKey},
+		{name: "minisign", keyID: testKeyID, key: minisignKey},
+	}, store.keys)
+
+	writeTestKey(t, folder, "invalid.pub", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = LoadTrustStore(folder)
+	assert.EqualError(t, err, "invalid trusted key invalid.pub: expected a minisign or an ed25519 public key")
+}
+
+func TestVerify_Ed25519(t *testing.T) {
+	releaseKey, privateKey := generateTestKey(t)
+	_, otherKey := generateTestKey(t)
+	store := &TrustStore{keys: []publicKey{{name: "release", key: releaseKey}}}
+	message := []byte("echo hello\n")
+
+	keyName, err := store.Verify(message, base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message))+"\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "release", keyName)
+
+	_, err = store.Verify([]byte("echo tampered\n"), base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message)))
+	assert.EqualError(t, err, "the signature does not match any key of the trust store")
+
+	_, err = store.Verify(message, base64.StdEncoding.EncodeToString(ed25519.Sign(otherKey, message)))
+	assert.EqualError(t, err, "the signature does not match any key of the trust store")
+
+	_, err = store.Verify(message, "not a signature")
+	assert.Error(t, err)
+}
+
+func TestVerify_Minisign(t *testing.T) {
+	releaseKey, privateKey := generateTestKey(t)
+	key, err := parsePublicKey("release", []byte(minisignPublicKey(releaseKey)))
+	assert.NoError(t, err)
+	store := &TrustStore{keys: []publicKey{key}}
+	message := []byte("#!/bin/sh\necho hello\n")
+
+	for _, algorithm := range []string{minisignAlgorithm, minisignPrehashedAlgorithm} {
+		keyName, err := store.Verify(message, minisignSignature(privateKey, algorithm, message, "timestamp:1700000000\tfile:install.sh"))
+		assert.NoError(t, err, algorithm)
+		assert.Equal(t, "release", keyName)
+
+		_, err = store.Verify([]byte("#!/bin/sh\necho tampered\n"), minisignSignature(privateKey, algorithm, message, "file:install.sh"))
+		assert.EqualError(t, err, "the signature does not match key release")
+	}
+
+	// the trusted comment is covered by the global signature
+	signature := minisignSignature(privateKey, minisignPrehashedAlgorithm, message, "file:install.sh")
+	tampered := minisignSignature(privateKey, minisignPrehashedAlgorithm, message, "file:other.sh")
+	lines := contentLines(signature)
+	lines[2] = contentLines(tampered)[2]
+	_, err = store.Verify(message, lines[0]+"\n"+lines[1]+"\n"+lines[2]+"\n"+lines[3])
+	assert.EqualError(t, err, "the trusted comment signature does not match key release")
+
+	// the key id of the signature must be in the trust store
+	store.keys[0].keyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	_, err = store.Verify(message, signature)
+	assert.EqualError(t, err, "key E7620F1842B4E81F is not in the trust store")
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// StubTrustStore makes the trust store of the agent hold a single new key named release until the test ends
+// and returns its private key
+func StubTrustStore(t *testing.T) ed25519.PrivateKey {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(folder, "release.pub"), []byte(base64.StdEncoding.EncodeToString(publicKey)), 0600))
+
+	defaultTrustStoreFolder := trustStoreFolder
+	trustStoreFolder = func() string { return folder }
+	t.Cleanup(func() { trustStoreFolder = defaultTrustStoreFolder })
+	return privateKey
+}
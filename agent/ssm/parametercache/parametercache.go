@@ -0,0 +1,167 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package parametercache caches the values of parameter store parameters resolved for documents in memory,
+// so that frequent document executions do not call GetParameters every time.
+package parametercache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+const (
+	secureStringType = "SecureString"
+	arnPrefix        = "arn:"
+	arnResourceType  = ":parameter"
+
+	// maxEntries bounds the number of parameters kept in the cache
+	maxEntries = 10000
+
+	// maskedValue replaces the values of SecureString parameters when the parameters are logged
+	maskedValue = "****"
+)
+
+// Parameter is a parameter returned by parameter store, Selector is the :version or :label the parameter was requested with
+type Parameter struct {
+	Name     string
+	Type     string
+	Value    string
+	Version  int64
+	Selector string
+}
+
+// String describes the parameter for logs, the values of SecureString parameters are masked
+func (p Parameter) String() string {
+	value := p.Value
+	if p.Type == secureStringType {
+		value = maskedValue
+	}
+	return fmt.Sprintf("{Name: %s, Type: %s, Value: %s, Version: %d, Selector: %s}", p.Name, p.Type, value, p.Version, p.Selector)
+}
+
+type entry struct {
+	parameter Parameter
+	expiry    time.Time
+}
+
+var lock sync.Mutex
+var entries = map[string]entry{}
+var timeNow = time.Now
+
+// Get returns the cached parameters among the requested names, keyed by name, and the names to request from parameter store.
+// Requesting a pinned version of a parameter invalidates the other cached values of the parameter.
+// SecureString parameters are only returned to callers that resolve decrypted values, when caching them is enabled.
+func Get(ssmConfig appconfig.SsmCfg, names []string, decrypted bool) (cached map[string]Parameter, missing []string) {
+	cached = make(map[string]Parameter)
+	if ssmConfig.ParameterCacheTTLSeconds <= 0 {
+		return cached, names
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	for _, name := range names {
+		if baseName, selector := splitSelector(name); isVersion(selector) {
+			invalidateUnpinned(baseName)
+		}
+	}
+	now := timeNow()
+	for _, name := range names {
+		cachedEntry, ok := entries[name]
+		if ok && now.Before(cachedEntry.expiry) && (cachedEntry.parameter.Type != secureStringType || (decrypted && ssmConfig.ParameterCacheSecureParameters)) {
+			cached[name] = cachedEntry.parameter
+			continue
+		}
+		delete(entries, name)
+		missing = append(missing, name)
+	}
+	return cached, missing
+}
+
+// Put caches the parameters returned by parameter store. SecureString parameters are only cached when their values
+// are decrypted and caching them is enabled in the agent configuration, the cache is never persisted.
+func Put(ssmConfig appconfig.SsmCfg, parameters []Parameter, decrypted bool) {
+	if ssmConfig.ParameterCacheTTLSeconds <= 0 {
+		return
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	now := timeNow()
+	if len(entries)+len(parameters) > maxEntries {
+		removeExpired(now)
+	}
+	expiry := now.Add(time.Duration(ssmConfig.ParameterCacheTTLSeconds) * time.Second)
+	for _, parameter := range parameters {
+		if parameter.Type == secureStringType && !(decrypted && ssmConfig.ParameterCacheSecureParameters) {
+			continue
+		}
+		if len(entries) >= maxEntries {
+			return
+		}
+		entries[parameter.Name+parameter.Selector] = entry{parameter: parameter, expiry: expiry}
+	}
+}
+
+// Clear removes all the parameters from the cache
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+	entries = map[string]entry{}
+}
+
+// invalidateUnpinned removes the cached values of a parameter that were not requested with a version
+func invalidateUnpinned(baseName string) {
+	for name := range entries {
+		if entryBaseName, selector := splitSelector(name); entryBaseName == baseName && !isVersion(selector) {
+			delete(entries, name)
+		}
+	}
+}
+
+func removeExpired(now time.Time) {
+	for name, cachedEntry := range entries {
+		if !now.Before(cachedEntry.expiry) {
+			delete(entries, name)
+		}
+	}
+}
+
+// splitSelector splits a parameter name like /app/version:3 into the name of the parameter and the :version or :label selector
+func splitSelector(name string) (baseName string, selector string) {
+	start := 0
+	if strings.HasPrefix(name, arnPrefix) {
+		// the colons of the arn are not selectors
+		if index := strings.Index(name, arnResourceType); index >= 0 {
+			start = index + len(arnResourceType)
+		}
+	}
+	if index := strings.Index(name[start:], ":"); index >= 0 {
+		return name[:start+index], name[start+index:]
+	}
+	return name, ""
+}
+
+// isVersion returns whether the selector pins a version rather than a label
+func isVersion(selector string) bool {
+	if selector == "" {
+		return false
+	}
+	_, err := strconv.ParseInt(selector[1:], 10, 64)
+	return err == nil
+}
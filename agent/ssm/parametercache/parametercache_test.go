@@ -0,0 +1,144 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parametercache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSsmConfig(ttlSeconds int) appconfig.SsmCfg {
+	return appconfig.SsmCfg{ParameterCacheTTLSeconds: ttlSeconds}
+}
+
+func newSecureSsmConfig(ttlSeconds int) appconfig.SsmCfg {
+	return appconfig.SsmCfg{ParameterCacheTTLSeconds: ttlSeconds, ParameterCacheSecureParameters: true}
+}
+
+func setTime(t *testing.T, now time.Time) {
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		timeNow = time.Now
+		Clear()
+	})
+}
+
+func TestGetCachedParameters(t *testing.T) {
+	now := time.Now()
+	setTime(t, now)
+	ssmConfig := newSsmConfig(60)
+	version := Parameter{Name: "/app/version", Type: "String", Value: "1.2.3", Version: 4}
+	pinnedVersion := Parameter{Name: "/app/version", Type: "String", Value: "1.2.0", Version: 2, Selector: ":2"}
+	Put(ssmConfig, []Parameter{version, pinnedVersion}, false)
+
+	cached, missing := Get(ssmConfig, []string{"/app/version", "/app/name"}, false)
+
+	assert.Equal(t, map[string]Parameter{"/app/version": version}, cached)
+	assert.Equal(t, []string{"/app/name"}, missing)
+
+	cached, missing = Get(ssmConfig, []string{"/app/version:2"}, false)
+
+	assert.Equal(t, map[string]Parameter{"/app/version:2": pinnedVersion}, cached)
+	assert.Empty(t, missing)
+
+	setTime(t, now.Add(61*time.Second))
+	cached, missing = Get(ssmConfig, []string{"/app/version:2"}, false)
+
+	assert.Empty(t, cached)
+	assert.Equal(t, []string{"/app/version:2"}, missing)
+}
+
+func TestGetWithCacheDisabled(t *testing.T) {
+	setTime(t, time.Now())
+	Put(newSsmConfig(60), []Parameter{{Name: "/app/version", Type: "String", Value: "1.2.3"}}, false)
+
+	cached, missing := Get(newSsmConfig(0), []string{"/app/version"}, false)
+
+	assert.Empty(t, cached)
+	assert.Equal(t, []string{"/app/version"}, missing)
+}
+
+func TestPinnedVersionInvalidatesParameter(t *testing.T) {
+	setTime(t, time.Now())
+	ssmConfig := newSsmConfig(60)
+	Put(ssmConfig, []Parameter{
+		{Name: "/app/version", Type: "String", Value: "1.2.3", Version: 4},
+		{Name: "/app/version", Type: "String", Value: "1.2.3", Version: 4, Selector: ":stable"},
+		{Name: "/app/version", Type: "String", Value: "1.2.0", Version: 2, Selector: ":2"},
+		{Name: "/app/name", Type: "String", Value: "app", Version: 1},
+	}, false)
+
+	_, missing := Get(ssmConfig, []string{"/app/version:5"}, false)
+	assert.Equal(t, []string{"/app/version:5"}, missing)
+
+	cached, missing := Get(ssmConfig, []string{"/app/version", "/app/version:stable", "/app/version:2", "/app/name"}, false)
+	assert.Equal(t, []string{"/app/version", "/app/version:stable"}, missing)
+	assert.Len(t, cached, 2)
+}
+
+func TestSecureParametersAreNotCachedByDefault(t *testing.T) {
+	setTime(t, time.Now())
+	secure := Parameter{Name: "/app/password", Type: "SecureString", Value: "p4ssw0rd", Version: 1}
+
+	Put(newSsmConfig(60), []Parameter{secure}, true)
+	_, missing := Get(newSecureSsmConfig(60), []string{"/app/password"}, true)
+
+	assert.Equal(t, []string{"/app/password"}, missing)
+}
+
+func TestSecureParametersAreOptIn(t *testing.T) {
+	setTime(t, time.Now())
+	secure := Parameter{Name: "/app/password", Type: "SecureString", Value: "p4ssw0rd", Version: 1}
+
+	// values that are not decrypted are never cached
+	Put(newSecureSsmConfig(60), []Parameter{secure}, false)
+	_, missing := Get(newSecureSsmConfig(60), []string{"/app/password"}, true)
+	assert.Equal(t, []string{"/app/password"}, missing)
+
+	Put(newSecureSsmConfig(60), []Parameter{secure}, true)
+	cached, _ := Get(newSecureSsmConfig(60), []string{"/app/password"}, true)
+	assert.Equal(t, map[string]Parameter{"/app/password": secure}, cached)
+
+	// the decrypted values are not returned to callers that do not resolve decrypted values
+	_, missing = Get(newSecureSsmConfig(60), []string{"/app/password"}, false)
+	assert.Equal(t, []string{"/app/password"}, missing)
+
+	_, missing = Get(newSsmConfig(60), []string{"/app/password"}, true)
+	assert.Equal(t, []string{"/app/password"}, missing)
+}
+
+func TestParameterStringMasksSecureValues(t *testing.T) {
+	secure := Parameter{Name: "/app/password", Type: "SecureString", Value: "p4ssw0rd", Version: 1}
+	version := Parameter{Name: "/app/version", Type: "String", Value: "1.2.3", Version: 4, Selector: ":4"}
+
+	assert.Equal(t, "{Name: /app/password, Type: SecureString, Value: ****, Version: 1, Selector: }", fmt.Sprintf("%v", secure))
+	assert.Equal(t, "{Name: /app/version, Type: String, Value: 1.2.3, Version: 4, Selector: :4}", fmt.Sprintf("%v", version))
+}
+
+func TestSplitSelector(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"/app/version":   {"/app/version", ""},
+		"/app/version:3": {"/app/version", ":3"},
+		"version:stable": {"version", ":stable"},
+		"arn:aws:ssm:us-east-1:123456789012:parameter/app/version":   {"arn:aws:ssm:us-east-1:123456789012:parameter/app/version", ""},
+		"arn:aws:ssm:us-east-1:123456789012:parameter/app/version:3": {"arn:aws:ssm:us-east-1:123456789012:parameter/app/version", ":3"},
+	} {
+		baseName, selector := splitSelector(name)
+		assert.Equal(t, expected, [2]string{baseName, selector}, name)
+	}
+}
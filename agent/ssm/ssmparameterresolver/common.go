@@ -39,7 +39,7 @@ type SsmParameterInfo struct {
 
 // ResolveOptions structure represents a set of options for the parameter resolution.
 // At this time it has only one flag IgnoreSecureParameters
-// if IgnoreSecureParameters == true the parameters prefixed with ssm-secure: will not be resolved.
+// if IgnoreSecureParameters == true the parameters prefixed with ssm-secure: and the secret backend references will not be resolved.
 type ResolveOptions struct {
 	IgnoreSecureParameters bool
 }
@@ -0,0 +1,140 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// localSecretKeySize is the size of the AES-256 key of the local secrets file
+const localSecretKeySize = 32
+
+// localSecretBackend resolves references like (localsecret:name) from a file on the instance.
+// The file holds the nonce followed by the AES-256-GCM encrypted JSON object of the secrets,
+// the key file holds the base64 encoded key. Both must only be accessible by the user running the agent.
+type localSecretBackend struct {
+	secretsFile string
+	keyFile     string
+}
+
+// newLocalSecretBackend creates a local secret backend for the files configured in the agent configuration.
+func newLocalSecretBackend(context context.T) (ISecretBackend, error) {
+	appConfig := context.AppConfig()
+	if appConfig.Ssm.LocalSecretsFile == "" || appConfig.Ssm.LocalSecretsKeyFile == "" {
+		return nil, errors.New("the localsecret secret backend is not configured, LocalSecretsFile and LocalSecretsKeyFile must be set in the agent configuration")
+	}
+	return &localSecretBackend{secretsFile: appConfig.Ssm.LocalSecretsFile, keyFile: appConfig.Ssm.LocalSecretsKeyFile}, nil
+}
+
+// GetSecrets decrypts the secrets file and returns the referenced secrets.
+func (b *localSecretBackend) GetSecrets(log log.T, references []string) (map[string]SsmParameterInfo, error) {
+	encodedKey, err := readProtectedFile(b.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the local secrets key: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedKey)))
+	if err != nil || len(key) != localSecretKeySize {
+		return nil, fmt.Errorf("the local secrets key must be a base64 encoded %d bytes key", localSecretKeySize)
+	}
+	sealedSecrets, err := readProtectedFile(b.secretsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the local secrets: %v", err)
+	}
+	secrets, err := OpenLocalSecrets(key, sealedSecrets)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedSecrets := make(map[string]SsmParameterInfo)
+	for _, reference := range references {
+		name := extractParameterNameFromReference(reference)
+		value, ok := secrets[name]
+		if !ok {
+			return nil, fmt.Errorf("local secret %s does not exist", name)
+		}
+		resolvedSecrets[reference] = SsmParameterInfo{Name: name, Type: secureStringType, Value: value}
+	}
+	return resolvedSecrets, nil
+}
+
+// SealLocalSecrets encrypts the secrets in the format of the local secrets file.
+func SealLocalSecrets(key []byte, secrets map[string]string) ([]byte, error) {
+	aesgcm, err := newLocalSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aesgcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// OpenLocalSecrets decrypts the content of a local secrets file.
+func OpenLocalSecrets(key []byte, sealedSecrets []byte) (map[string]string, error) {
+	aesgcm, err := newLocalSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealedSecrets) < aesgcm.NonceSize() {
+		return nil, errors.New("the local secrets file is corrupted")
+	}
+	nonce, ciphertext := sealedSecrets[:aesgcm.NonceSize()], sealedSecrets[aesgcm.NonceSize():]
+	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt the local secrets file, the key does not match")
+	}
+	var secrets map[string]string
+	if err = json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, errors.New("the local secrets file is corrupted")
+	}
+	return secrets, nil
+}
+
+func newLocalSecretCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readProtectedFile reads a file holding secrets after checking that no other user can access it
+func readProtectedFile(path string) ([]byte, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkFileIsProtected(path, fileInfo); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
@@ -45,7 +45,7 @@ func ExtractParametersFromText(
 		return nil, err
 	}
 
-	parametersWithValues, err := getParametersFromSsmParameterStore(service, log, uniqueParameterReferences)
+	parametersWithValues, err := getParameterValues(service, log, uniqueParameterReferences)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +78,7 @@ func ResolveParameterReferenceList(
 		parameterReferencesToResolve = append(parameterReferencesToResolve, uniqueParameterReferences...)
 	}
 
-	parametersWithValues, err := getParametersFromSsmParameterStore(service, log, parameterReferencesToResolve)
+	parametersWithValues, err := getParameterValues(service, log, parameterReferencesToResolve)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +105,7 @@ func ResolveParametersInText(
 	}
 
 	for ref, param := range resolvedParametersMap {
-		var placeholder = regexp.MustCompile("{{\\s*" + ref + "\\s*}}")
+		var placeholder = regexp.MustCompile("{{\\s*" + regexp.QuoteMeta(ref) + "\\s*}}")
 		input = placeholder.ReplaceAllString(input, param.Value)
 	}
 
@@ -157,6 +157,11 @@ func parseParametersFromTextIntoDedupedSlice(text string, ignoreSecureParameters
 		for i := 0; i < len(matchedSecurePhrases); i++ {
 			parameterNamesDeduped[matchedSecurePhrases[i][1]] = true
 		}
+
+		// secrets are always secure
+		for _, reference := range findSecretBackendReferences(text) {
+			parameterNamesDeduped[reference] = true
+		}
 	}
 
 	result := []string{}
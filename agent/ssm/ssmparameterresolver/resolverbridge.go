@@ -46,8 +46,9 @@ func NewSsmParameterResolverBridge(ssmParameterService ISsmParameterService) ISs
 }
 
 // IsValidParameterStoreReference determines whether the given value is a valid ssm parameter store parameter reference
+// or a reference to a registered secret backend
 func (bridge *ssmParameterResolverBridgeImpl) IsValidParameterStoreReference(value string) bool {
-	return ssmParamReferencePattern.MatchString(value)
+	return ssmParamReferencePattern.MatchString(value) || TextContainsSecretBackendParameters(value)
 }
 
 // GetParameterFromSsmParameterStore returns the value of the given parameter store parameter
@@ -70,6 +71,12 @@ func (bridge *ssmParameterResolverBridgeImpl) extractParameterStoreParameterRefe
 	// Regex to extract the contents of the parameter from within {{ }} to get parameter value
 	// for. e.g. {{ ssm-secure:parameter-name }} will extract ssm-secure:parameter-name
 	matchGroups := ssmParamReferencePattern.FindStringSubmatch(parameter)
+	if matchGroups == nil {
+		// references to secret backends, e.g. {{ vault:secret/data/app#token }} are resolved the same way
+		if secretReferences := findSecretBackendReferences(parameter); len(secretReferences) > 0 {
+			return secretReferences[0], nil
+		}
+	}
 	if matchGroups == nil || len(matchGroups) != 2 {
 		return "", fmt.Errorf("Invalid SSM parameter store parameter reference format: %s", parameter)
 	}
@@ -47,6 +47,7 @@ func TestIsValidParameterStoreReference(t *testing.T) {
 		"{{ssm-secure:test}}",
 		"{{ssm:test}}",
 		"{{ssm-secure:p-a.r/a_m}}",
+		"{{ vault:secret/data/app#token }}",
 	}
 	for _, reference := range references {
 		assert.True(t, ssmParameterResolverBridge.IsValidParameterStoreReference(reference), reference)
@@ -0,0 +1,199 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
+)
+
+const (
+	secretsManagerPrefix = "secretsmanager:"
+	vaultPrefix          = "vault:"
+	localSecretPrefix    = "localsecret:"
+
+	// secretKeySeparator separates the name of a secret from the key of a value within the secret, e.g. vault:secret/data/app#password
+	secretKeySeparator = "#"
+)
+
+// ISecretBackend resolves the parameter references of a secret store other than parameter store.
+type ISecretBackend interface {
+	// GetSecrets takes a list of references like (vault:path#key) and returns a map<param-ref, SsmParameterInfo>.
+	GetSecrets(log log.T, references []string) (map[string]SsmParameterInfo, error)
+}
+
+// SecretBackendFactory creates the secret backend of a prefix the first time one of its references is resolved.
+type SecretBackendFactory func(context context.T) (ISecretBackend, error)
+
+var secretBackendsLock sync.RWMutex
+var secretBackendFactories = map[string]SecretBackendFactory{
+	secretsManagerPrefix: newSecretsManagerBackend,
+	vaultPrefix:          newVaultBackend,
+	localSecretPrefix:    newLocalSecretBackend,
+}
+
+// Secret backend placeholder, the prefix must be registered for the reference to be resolved
+var secretParameterPlaceholderRegEx = regexp.MustCompile("{{\\s*([a-z][a-z0-9-]*:[\\w-./:#+=@]+)\\s*}}")
+var secretBackendPrefixRegEx = regexp.MustCompile("^[a-z][a-z0-9-]*:$")
+
+// reservedSecretBackendPrefixes are the prefixes of the parameter store and local parameter references
+var reservedSecretBackendPrefixes = map[string]bool{
+	ssmNonSecurePrefix: true,
+	ssmSecurePrefix:    true,
+	"env:":             true,
+	"file:":            true,
+	"imds:":            true,
+	"agent:":           true,
+}
+
+// RegisterSecretBackend registers the backend resolving the references of the format {{prefix:name}}.
+// The prefix must end with a colon, e.g. vault:, and cannot replace the parameter store or local parameter prefixes.
+func RegisterSecretBackend(prefix string, factory SecretBackendFactory) error {
+	if !secretBackendPrefixRegEx.MatchString(prefix) || reservedSecretBackendPrefixes[prefix] {
+		return fmt.Errorf("invalid secret backend prefix %s", prefix)
+	}
+	secretBackendsLock.Lock()
+	defer secretBackendsLock.Unlock()
+	secretBackendFactories[prefix] = factory
+	return nil
+}
+
+// TextContainsSecretBackendParameters determines whether a given text contains a reference to a registered secret backend
+func TextContainsSecretBackendParameters(input string) bool {
+	return len(findSecretBackendReferences(input)) > 0
+}
+
+// findSecretBackendReferences returns the references of the text whose prefix is registered for a secret backend
+func findSecretBackendReferences(text string) []string {
+	references := []string{}
+	for _, match := range secretParameterPlaceholderRegEx.FindAllStringSubmatch(text, -1) {
+		if _, ok := getSecretBackendFactory(match[1]); ok {
+			references = append(references, match[1])
+		}
+	}
+	return references
+}
+
+// getSecretBackendFactory returns the factory of the secret backend registered for the prefix of the reference
+func getSecretBackendFactory(reference string) (SecretBackendFactory, bool) {
+	secretBackendsLock.RLock()
+	defer secretBackendsLock.RUnlock()
+	factory, ok := secretBackendFactories[secretReferencePrefix(reference)]
+	return factory, ok
+}
+
+// secretReferencePrefix returns the prefix of a reference including the colon
+func secretReferencePrefix(reference string) string {
+	return reference[:strings.Index(reference, ":")+1]
+}
+
+// splitSecretReference splits a reference like (vault:path#key) into the name of the secret and the optional key of the value
+func splitSecretReference(reference string) (name string, key string) {
+	name = extractParameterNameFromReference(reference)
+	if index := strings.LastIndex(name, secretKeySeparator); index >= 0 {
+		return name[:index], name[index+1:]
+	}
+	return name, ""
+}
+
+// getSecretValue returns the whole secret, or the value of the key when the secret is a JSON object
+func getSecretValue(name string, secret string, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, key %s cannot be read from it", name, key)
+	}
+	return getSecretKeyValue(name, values, key)
+}
+
+// getSecretKeyValue returns the value of a key of a secret, the value must be a string
+func getSecretKeyValue(name string, values map[string]interface{}, key string) (string, error) {
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", name, key)
+	}
+	valueString, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s of secret %s is not a string", key, name)
+	}
+	return valueString, nil
+}
+
+// getParameterValues resolves the references through parameter store or through the secret backend registered for their prefix.
+// The values of secure parameters and secrets are marked for masking.
+func getParameterValues(
+	service ISsmParameterService,
+	log log.T,
+	parameterReferences []string) (map[string]SsmParameterInfo, error) {
+
+	ssmReferences := []string{}
+	secretReferences := map[string][]string{}
+	for _, reference := range parameterReferences {
+		if _, ok := getSecretBackendFactory(reference); ok {
+			prefix := secretReferencePrefix(reference)
+			secretReferences[prefix] = append(secretReferences[prefix], reference)
+		} else {
+			ssmReferences = append(ssmReferences, reference)
+		}
+	}
+
+	parametersWithValues, err := getParametersFromSsmParameterStore(service, log, ssmReferences)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]string, 0, len(secretReferences))
+	for prefix := range secretReferences {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		backend, err := service.getSecretBackend(prefix)
+		if err != nil {
+			return nil, err
+		}
+		secrets, err := backend.GetSecrets(log, secretReferences[prefix])
+		if err != nil {
+			return nil, err
+		}
+		for _, reference := range secretReferences[prefix] {
+			secret, ok := secrets[reference]
+			if !ok {
+				return nil, errors.New("The following parameter(s) cannot be resolved: " + reference)
+			}
+			// secrets are always secure, whatever the backend reports
+			secret.Type = secureStringType
+			parametersWithValues[reference] = secret
+		}
+	}
+
+	for _, parameter := range parametersWithValues {
+		if parameter.Type == secureStringType {
+			secretmask.Add(parameter.Value)
+		}
+	}
+	return parametersWithValues, nil
+}
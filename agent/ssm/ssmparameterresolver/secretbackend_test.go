@@ -0,0 +1,208 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/stretchr/testify/assert"
+)
+
+// getSecretBackend resolves the references of every secret backend from the records of the mocked service
+func (m *serviceMockedObjectWithRecords) getSecretBackend(prefix string) (ISecretBackend, error) {
+	return m, nil
+}
+
+func (m *serviceMockedObjectWithRecords) GetSecrets(log log.T, references []string) (map[string]SsmParameterInfo, error) {
+	return m.getParameters(log, references)
+}
+
+type secretsManagerMock struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+	calls   int
+}
+
+func (m *secretsManagerMock) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	m.calls++
+	secret, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+}
+
+func TestResolveParametersInTextWithSecretBackends(t *testing.T) {
+	secretmask.Reset()
+	defer secretmask.Reset()
+	serviceObject := newServiceMockedObjectWithExtraRecords(map[string]SsmParameterInfo{
+		"ssm:user":                       {Name: "user", Type: stringType, Value: "admin"},
+		"vault:secret/data/app#password": {Name: "secret/data/app", Type: stringType, Value: "vault-password"},
+		"localsecret:token":              {Name: "token", Type: secureStringType, Value: "local-token"},
+	})
+
+	text := "login {{ssm:user}} {{ vault:secret/data/app#password }} {{localsecret:token}} {{ unknown:value }}"
+	resolvedText, err := ResolveParametersInText(&serviceObject, logger.DefaultLogger(), text, ResolveOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "login admin vault-password local-token {{ unknown:value }}", resolvedText)
+	assert.Equal(t, []string{"vault-password", "local-token"}, secretmask.Values())
+
+	resolvedText, err = ResolveParametersInText(&serviceObject, logger.DefaultLogger(), text, ResolveOptions{IgnoreSecureParameters: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "login admin {{ vault:secret/data/app#password }} {{localsecret:token}} {{ unknown:value }}", resolvedText)
+}
+
+func TestRegisterSecretBackend(t *testing.T) {
+	defer delete(secretBackendFactories, "test-secrets:")
+	factory := func(context context.T) (ISecretBackend, error) { return nil, nil }
+
+	assert.False(t, TextContainsSecretBackendParameters("{{ test-secrets:name }}"))
+	assert.NoError(t, RegisterSecretBackend("test-secrets:", factory))
+	assert.True(t, TextContainsSecretBackendParameters("{{ test-secrets:name }}"))
+
+	for _, prefix := range []string{"ssm:", "ssm-secure:", "env:", "file:", "imds:", "agent:", "test-secrets", "Test:", ""} {
+		assert.Error(t, RegisterSecretBackend(prefix, factory), prefix)
+	}
+}
+
+func TestGetSecretValue(t *testing.T) {
+	value, err := getSecretValue("app", "plain", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", value)
+
+	value, err = getSecretValue("app", `{"password":"p4ssw0rd","port":5432}`, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "p4ssw0rd", value)
+
+	_, err = getSecretValue("app", `{"password":"p4ssw0rd","port":5432}`, "port")
+	assert.EqualError(t, err, "key port of secret app is not a string")
+	_, err = getSecretValue("app", `{"password":"p4ssw0rd"}`, "user")
+	assert.EqualError(t, err, "secret app has no key user")
+	_, err = getSecretValue("app", "plain", "user")
+	assert.EqualError(t, err, "secret app is not a JSON object, key user cannot be read from it")
+}
+
+func TestSecretsManagerBackend(t *testing.T) {
+	sdk := &secretsManagerMock{secrets: map[string]string{
+		"app": `{"user":"admin","password":"p4ssw0rd"}`,
+		"arn:aws:secretsmanager:us-east-1:1:secret:db": "db-password",
+	}}
+	backend := &secretsManagerBackend{sdk: sdk}
+
+	secrets, err := backend.GetSecrets(logger.DefaultLogger(), []string{
+		"secretsmanager:app#user",
+		"secretsmanager:app#password",
+		"secretsmanager:arn:aws:secretsmanager:us-east-1:1:secret:db",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SsmParameterInfo{
+		"secretsmanager:app#user":     {Name: "app", Type: secureStringType, Value: "admin"},
+		"secretsmanager:app#password": {Name: "app", Type: secureStringType, Value: "p4ssw0rd"},
+		"secretsmanager:arn:aws:secretsmanager:us-east-1:1:secret:db": {
+			Name: "arn:aws:secretsmanager:us-east-1:1:secret:db", Type: secureStringType, Value: "db-password"},
+	}, secrets)
+	assert.Equal(t, 2, sdk.calls)
+
+	_, err = backend.GetSecrets(logger.DefaultLogger(), []string{"secretsmanager:missing"})
+	assert.EqualError(t, err, "failed to read secret missing from secrets manager: ResourceNotFoundException")
+}
+
+func TestVaultBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			w.Write([]byte(`{"data":{"data":{"password":"p4ssw0rd"},"metadata":{"version":3}}}`))
+		case "/v1/kv/app":
+			w.Write([]byte(`{"data":{"password":"kv1-password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("vault-token\n"), 0600))
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.VaultAddress = server.URL + "/"
+	config.Ssm.VaultTokenFile = tokenFile
+
+	backend, err := newVaultBackend(contextmocks.NewMockDefaultWithConfig(config))
+	assert.NoError(t, err)
+	secrets, err := backend.GetSecrets(logger.DefaultLogger(), []string{"vault:secret/data/app#password", "vault:kv/app#password"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SsmParameterInfo{
+		"vault:secret/data/app#password": {Name: "secret/data/app", Type: secureStringType, Value: "p4ssw0rd"},
+		"vault:kv/app#password":          {Name: "kv/app", Type: secureStringType, Value: "kv1-password"},
+	}, secrets)
+
+	_, err = backend.GetSecrets(logger.DefaultLogger(), []string{"vault:secret/data/missing#password"})
+	assert.EqualError(t, err, "failed to read secret secret/data/missing from vault: vault responded with status 404 Not Found")
+	_, err = backend.GetSecrets(logger.DefaultLogger(), []string{"vault:secret/data/app"})
+	assert.Error(t, err)
+
+	_, err = newVaultBackend(contextmocks.NewMockDefault())
+	assert.Error(t, err)
+}
+
+func TestLocalSecretBackend(t *testing.T) {
+	key := make([]byte, localSecretKeySize)
+	_, err := rand.Read(key)
+	assert.NoError(t, err)
+	sealedSecrets, err := SealLocalSecrets(key, map[string]string{"token": "local-token"})
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.LocalSecretsFile = filepath.Join(dir, "secrets")
+	config.Ssm.LocalSecretsKeyFile = filepath.Join(dir, "key")
+	assert.NoError(t, os.WriteFile(config.Ssm.LocalSecretsFile, sealedSecrets, 0600))
+	assert.NoError(t, os.WriteFile(config.Ssm.LocalSecretsKeyFile, []byte(base64.StdEncoding.EncodeToString(key)), 0600))
+
+	backend, err := newLocalSecretBackend(contextmocks.NewMockDefaultWithConfig(config))
+	assert.NoError(t, err)
+	secrets, err := backend.GetSecrets(logger.DefaultLogger(), []string{"localsecret:token"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SsmParameterInfo{"localsecret:token": {Name: "token", Type: secureStringType, Value: "local-token"}}, secrets)
+
+	_, err = backend.GetSecrets(logger.DefaultLogger(), []string{"localsecret:missing"})
+	assert.EqualError(t, err, "local secret missing does not exist")
+
+	otherKey := make([]byte, localSecretKeySize)
+	_, err = OpenLocalSecrets(otherKey, sealedSecrets)
+	assert.EqualError(t, err, "failed to decrypt the local secrets file, the key does not match")
+}
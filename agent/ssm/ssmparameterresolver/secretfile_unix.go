@@ -0,0 +1,33 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkFileIsProtected checks that the file is owned by the user running the agent and that no other user can access it
+func checkFileIsProtected(path string, fileInfo os.FileInfo) error {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok || stat.Uid != uint32(os.Geteuid()) || fileInfo.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s must be owned by the user running the agent and only be accessible by its owner", path)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadProtectedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(path, []byte("secret"), 0600))
+
+	content, err := readProtectedFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+
+	assert.NoError(t, os.Chmod(path, 0644))
+	_, err = readProtectedFile(path)
+	assert.EqualError(t, err, path+" must be owned by the user running the agent and only be accessible by its owner")
+}
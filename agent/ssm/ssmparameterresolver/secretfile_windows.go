@@ -0,0 +1,30 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build windows
+// +build windows
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"os"
+
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+// checkFileIsProtected checks that the file is owned by and only grants access to LocalSystem and the Administrators,
+// like the agent data folder once it is hardened
+func checkFileIsProtected(path string, fileInfo os.FileInfo) error {
+	return fileutil.CheckHardened(path)
+}
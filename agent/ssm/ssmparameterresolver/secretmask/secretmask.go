@@ -0,0 +1,98 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package secretmask keeps the secret values resolved for parameter references so that they can be masked in output.
+package secretmask
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// MaskedValue replaces the secret values in masked text
+	MaskedValue = "****"
+
+	// minSecretLength is the length under which values are not masked, masking them would garble the output
+	minSecretLength = 4
+)
+
+var lock sync.RWMutex
+var secrets = map[string]bool{}
+
+// executions is the number of document executions running in the process, their secret values are kept until they all complete
+var executions int
+
+// Begin starts the execution of a document whose output masks the given values and the ones added while it runs.
+// The returned function ends the execution, the secret values are cleared once no document execution is running anymore.
+func Begin(values ...string) (end func()) {
+	lock.Lock()
+	executions++
+	lock.Unlock()
+	Add(values...)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			lock.Lock()
+			defer lock.Unlock()
+			if executions--; executions == 0 {
+				secrets = map[string]bool{}
+			}
+		})
+	}
+}
+
+// Add marks secret values for masking
+func Add(values ...string) {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets[value] = true
+		}
+	}
+}
+
+// Values returns the secret values marked for masking, longest first so that a secret containing another one is masked whole
+func Values() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	values := make([]string, 0, len(secrets))
+	for value := range secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// Mask replaces the secret values marked for masking in text
+func Mask(text string) string {
+	for _, value := range Values() {
+		text = strings.Replace(text, value, MaskedValue, -1)
+	}
+	return text
+}
+
+// Reset clears the secret values marked for masking
+func Reset() {
+	lock.Lock()
+	defer lock.Unlock()
+	secrets = map[string]bool{}
+}
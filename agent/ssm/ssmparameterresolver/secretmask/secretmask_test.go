@@ -0,0 +1,50 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secretmask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMask(t *testing.T) {
+	defer Reset()
+	Add("password", "password123", "abc", "")
+
+	assert.Equal(t, []string{"password123", "password"}, Values())
+	assert.Equal(t, "login with **** and ****, abc", Mask("login with password123 and password, abc"))
+}
+
+func TestReset(t *testing.T) {
+	Add("password")
+	Reset()
+
+	assert.Empty(t, Values())
+	assert.Equal(t, "password", Mask("password"))
+}
+
+func TestBegin(t *testing.T) {
+	defer Reset()
+	endFirst := Begin("first-secret")
+	endSecond := Begin()
+	Add("second-secret")
+
+	endFirst()
+	endFirst()
+	assert.Equal(t, []string{"second-secret", "first-secret"}, Values())
+
+	endSecond()
+	assert.Empty(t, Values())
+}
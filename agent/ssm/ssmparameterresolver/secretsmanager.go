@@ -0,0 +1,75 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// secretsManagerBackend resolves references like (secretsmanager:secret-id) or (secretsmanager:secret-id#key)
+// through AWS Secrets Manager with the credentials of the agent.
+type secretsManagerBackend struct {
+	sdk secretsmanageriface.SecretsManagerAPI
+}
+
+// newSecretsManagerBackend creates a secrets manager client in the region of the agent.
+func newSecretsManagerBackend(context context.T) (ISecretBackend, error) {
+	awsConfig := sdkutil.AwsConfig(context, "secretsmanager")
+	appConfig := context.AppConfig()
+	if appConfig.Agent.Region != "" {
+		awsConfig.Region = &appConfig.Agent.Region
+	}
+
+	sess := session.New(awsConfig)
+	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(appConfig.Agent.Name, appConfig.Agent.Version))
+	return &secretsManagerBackend{sdk: secretsmanager.New(sess)}, nil
+}
+
+// GetSecrets reads each secret once, whatever the number of keys referenced in it.
+func (b *secretsManagerBackend) GetSecrets(log log.T, references []string) (map[string]SsmParameterInfo, error) {
+	secrets := make(map[string]string)
+	resolvedSecrets := make(map[string]SsmParameterInfo)
+	for _, reference := range references {
+		name, key := splitSecretReference(reference)
+		secret, ok := secrets[name]
+		if !ok {
+			output, err := b.sdk.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read secret %s from secrets manager: %v", name, err)
+			}
+			if output.SecretString == nil {
+				return nil, fmt.Errorf("secret %s has no string value", name)
+			}
+			secret = *output.SecretString
+			secrets[name] = secret
+		}
+
+		value, err := getSecretValue(name, secret, key)
+		if err != nil {
+			return nil, err
+		}
+		resolvedSecrets[reference] = SsmParameterInfo{Name: name, Type: secureStringType, Value: value}
+	}
+	return resolvedSecrets, nil
+}
@@ -16,27 +16,59 @@ package ssmparameterresolver
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/ssm"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/parametercache"
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 // ISsmParameterService interface represents SSM Parameter service API.
 type ISsmParameterService interface {
 	getParameters(log log.T, parameterReferences []string) (map[string]SsmParameterInfo, error)
+	getSecretBackend(prefix string) (ISecretBackend, error)
 }
 
 // SsmParameterService structure represents an SSM parameter service and implements ISsmParameterService interface.
 type SsmParameterService struct {
 	ISsmParameterService
-	sdk ssm.Service
+	sdk            ssm.Service
+	context        context.T
+	secretBackends map[string]ISecretBackend
+	lock           sync.Mutex
 }
 
 // NewService creates an instance of the SsmParameterService.
 func NewService(context context.T) (service ISsmParameterService) {
-	return &SsmParameterService{sdk: ssm.NewService(context)}
+	return &SsmParameterService{
+		sdk:            ssm.NewService(context),
+		context:        context,
+		secretBackends: make(map[string]ISecretBackend),
+	}
+}
+
+// getSecretBackend returns the secret backend registered for the prefix, it is created the first time it is used.
+func (s *SsmParameterService) getSecretBackend(prefix string) (ISecretBackend, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if backend, ok := s.secretBackends[prefix]; ok {
+		return backend, nil
+	}
+	factory, ok := getSecretBackendFactory(prefix)
+	if !ok {
+		return nil, fmt.Errorf("no secret backend is registered for %s", prefix)
+	}
+	backend, err := factory(s.context)
+	if err != nil {
+		return nil, err
+	}
+	s.secretBackends[prefix] = backend
+	return backend, nil
 }
 
 // This function takes a list of at most maxParametersRetrievedFromSsm(=10) ssm parameter name references like (ssm:name).
@@ -53,7 +85,25 @@ func (s *SsmParameterService) getParameters(
 		parameterReferences[i] = nameWithoutPrefix
 	}
 
-	parametersOutput, err := s.sdk.GetDecryptedParameters(log, parameterReferences)
+	var ssmConfig appconfig.SsmCfg
+	if s.context != nil {
+		ssmConfig = s.context.AppConfig().Ssm
+	}
+	cachedParameters, missingParameters := parametercache.Get(ssmConfig, parameterReferences, true)
+
+	resolvedParametersMap := map[string]SsmParameterInfo{}
+	for name, param := range cachedParameters {
+		resolvedParametersMap[ref2NameMapper[name]] = SsmParameterInfo{
+			Name:  param.Name,
+			Type:  param.Type,
+			Value: param.Value,
+		}
+	}
+	if len(missingParameters) == 0 {
+		return resolvedParametersMap, nil
+	}
+
+	parametersOutput, err := s.sdk.GetDecryptedParameters(log, missingParameters)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +116,7 @@ func (s *SsmParameterService) getParameters(
 		return nil, errors.New("The following parameter(s) cannot be resolved: " + strings.Join(invalidParameters, ","))
 	}
 
-	resolvedParametersMap := map[string]SsmParameterInfo{}
+	parametersToCache := []parametercache.Parameter{}
 	for i := 0; i < len(parametersOutput.Parameters); i++ {
 		param := parametersOutput.Parameters[i]
 		resolvedParametersMap[ref2NameMapper[*param.Name]] = SsmParameterInfo{
@@ -74,7 +124,15 @@ func (s *SsmParameterService) getParameters(
 			Type:  *param.Type,
 			Value: *param.Value,
 		}
+		parametersToCache = append(parametersToCache, parametercache.Parameter{
+			Name:     *param.Name,
+			Type:     *param.Type,
+			Value:    *param.Value,
+			Version:  aws.Int64Value(param.Version),
+			Selector: aws.StringValue(param.Selector),
+		})
 	}
+	parametercache.Put(ssmConfig, parametersToCache, true)
 
 	return resolvedParametersMap, nil
 }
@@ -20,8 +20,12 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/parametercache"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/secretmask"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -119,3 +123,22 @@ func TestGetParametersFromSsmParameterStoreWithUnresolvedIgnoreNoPaging(t *testi
 	_, err := getParametersFromSsmParameterStore(&serviceObject, log, parametersList)
 	assert.NotNil(t, err)
 }
+
+func TestGetParameterValuesMasksCachedSecureParameters(t *testing.T) {
+	parametercache.Clear()
+	defer parametercache.Clear()
+	secretmask.Reset()
+	defer secretmask.Reset()
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.ParameterCacheTTLSeconds = 60
+	config.Ssm.ParameterCacheSecureParameters = true
+	parametercache.Put(config.Ssm, []parametercache.Parameter{{Name: "/app/password", Type: secureStringType, Value: "p4ssw0rd", Version: 1}}, true)
+	// the cached value is returned without calling parameter store
+	service := &SsmParameterService{context: contextmocks.NewMockDefaultWithConfig(config)}
+
+	parameters, err := getParameterValues(service, logger.DefaultLogger(), []string{"ssm-secure:/app/password"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "p4ssw0rd", parameters["ssm-secure:/app/password"].Value)
+	assert.Equal(t, []string{"p4ssw0rd"}, secretmask.Values())
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ssmparameterresolver provides helper methods to detect, validate and extract parameter store parameter references.
+package ssmparameterresolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+)
+
+const (
+	vaultTokenHeader    = "X-Vault-Token"
+	vaultRequestTimeout = 30 * time.Second
+
+	// maxVaultResponseSize bounds the size of a secret read from vault
+	maxVaultResponseSize = 1024 * 1024
+)
+
+// vaultBackend resolves references like (vault:path#key) through the HTTP API of a HashiCorp Vault KV secrets engine.
+// The path is the API path of the secret, e.g. secret/data/app for version 2 of the KV secrets engine.
+type vaultBackend struct {
+	address   string
+	tokenFile string
+	client    *http.Client
+}
+
+// newVaultBackend creates a vault client for the server configured in the agent configuration.
+func newVaultBackend(context context.T) (ISecretBackend, error) {
+	appConfig := context.AppConfig()
+	if appConfig.Ssm.VaultAddress == "" || appConfig.Ssm.VaultTokenFile == "" {
+		return nil, errors.New("the vault secret backend is not configured, VaultAddress and VaultTokenFile must be set in the agent configuration")
+	}
+	return &vaultBackend{
+		address:   strings.TrimSuffix(appConfig.Ssm.VaultAddress, "/"),
+		tokenFile: appConfig.Ssm.VaultTokenFile,
+		client: &http.Client{
+			Timeout:   vaultRequestTimeout,
+			Transport: network.GetDefaultTransport(context.Log(), appConfig),
+		},
+	}, nil
+}
+
+// GetSecrets reads each secret once, whatever the number of keys referenced in it.
+func (b *vaultBackend) GetSecrets(log log.T, references []string) (map[string]SsmParameterInfo, error) {
+	token, err := readProtectedFile(b.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the vault token: %v", err)
+	}
+
+	secrets := make(map[string]map[string]interface{})
+	resolvedSecrets := make(map[string]SsmParameterInfo)
+	for _, reference := range references {
+		path, key := splitSecretReference(reference)
+		if key == "" {
+			return nil, fmt.Errorf("vault reference %s must name the key of the secret, e.g. vault:secret/data/app#password", reference)
+		}
+		values, ok := secrets[path]
+		if !ok {
+			if values, err = b.readSecret(strings.TrimSpace(string(token)), path); err != nil {
+				return nil, fmt.Errorf("failed to read secret %s from vault: %v", path, err)
+			}
+			secrets[path] = values
+		}
+
+		value, err := getSecretKeyValue(path, values, key)
+		if err != nil {
+			return nil, err
+		}
+		resolvedSecrets[reference] = SsmParameterInfo{Name: path, Type: secureStringType, Value: value}
+	}
+	return resolvedSecrets, nil
+}
+
+// readSecret returns the key values of a secret, for version 2 of the KV secrets engine the values are nested in the data of the response
+func (b *vaultBackend) readSecret(token string, path string) (map[string]interface{}, error) {
+	request, err := http.NewRequest(http.MethodGet, b.address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(vaultTokenHeader, token)
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded with status %s", response.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.NewDecoder(io.LimitReader(response.Body, maxVaultResponseSize)).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %v", err)
+	}
+	if values, ok := secret.Data["data"].(map[string]interface{}); ok && secret.Data["metadata"] != nil {
+		return values, nil
+	}
+	return secret.Data, nil
+}
@@ -16,8 +16,12 @@ package task
 
 import (
 	"sync"
+	"time"
 )
 
+// waitChannelPollInterval is how often WaitChannel checks the state of a flag that does not expose its channel
+var waitChannelPollInterval = 100 * time.Millisecond
+
 // State represents the state of a job.
 type State int
 
@@ -101,6 +105,11 @@ func (t *ChanneledCancelFlag) Wait() (state State) {
 	return t.State()
 }
 
+// Done returns a channel that is closed once the state of this flag is set.
+func (t *ChanneledCancelFlag) Done() <-chan struct{} {
+	return t.ch
+}
+
 // Set sets the state of this flag and wakes up waiting callers.
 func (t *ChanneledCancelFlag) Set(state State) {
 	t.m.Lock()
@@ -114,3 +123,32 @@ func (t *ChanneledCancelFlag) Set(state State) {
 		t.closed = true
 	}
 }
+
+// WaitChannel returns a channel that is closed once the state of the flag is set, so that the caller can select
+// on it together with its own events instead of blocking a routine in Wait until the flag is set.
+// release must be called once the caller stops listening.
+func WaitChannel(flag CancelFlag) (ch <-chan struct{}, release func()) {
+	if channeled, ok := flag.(interface{ Done() <-chan struct{} }); ok {
+		return channeled.Done(), func() {}
+	}
+
+	set := make(chan struct{})
+	released := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(waitChannelPollInterval)
+		defer ticker.Stop()
+		for {
+			if flag.State() != State(0) {
+				close(set)
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-released:
+				return
+			}
+		}
+	}()
+	return set, func() { once.Do(func() { close(released) }) }
+}
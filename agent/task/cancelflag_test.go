@@ -14,7 +14,9 @@
 package task
 
 import (
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +69,45 @@ func testWait(t *testing.T, state State) {
 	assert.Equal(t, state, <-ch)
 	assert.Equal(t, flag.Canceled(), state == Canceled)
 }
+
+// stateFlag is a cancel flag that does not expose its channel
+type stateFlag struct {
+	CancelFlag
+	m     sync.Mutex
+	state State
+}
+
+func (f *stateFlag) State() State {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.state
+}
+
+// TestWaitChannel tests that the channel returned by WaitChannel is closed once the flag is set
+func TestWaitChannel(t *testing.T) {
+	waitChannelPollInterval = time.Millisecond
+	defer func() { waitChannelPollInterval = 100 * time.Millisecond }()
+
+	flag := NewChanneledCancelFlag()
+	ch, release := WaitChannel(flag)
+	defer release()
+	select {
+	case <-ch:
+		assert.Fail(t, "channel closed before the flag was set")
+	default:
+	}
+	flag.Set(Canceled)
+	<-ch
+
+	polled := &stateFlag{}
+	ch, release = WaitChannel(polled)
+	defer release()
+	polled.m.Lock()
+	polled.state = ShutDown
+	polled.m.Unlock()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		assert.Fail(t, "channel not closed after the flag was set")
+	}
+}